@@ -2,14 +2,16 @@ module github.com/e1z0/qanotherrtsp
 
 go 1.23.1
 
-require github.com/mappu/miqt v0.11.0
+require (
+	github.com/asticode/go-astiav v0.38.0
+	github.com/hajimehoshi/oto/v2 v2.4.2
+	github.com/mappu/miqt v0.11.0
+	gopkg.in/yaml.v2 v2.4.0
+)
 
 require (
-	github.com/asticode/go-astiav v0.38.0 // indirect
 	github.com/asticode/go-astikit v0.42.0 // indirect
 	github.com/ebitengine/purego v0.7.0 // indirect
-	github.com/hajimehoshi/oto/v2 v2.4.2 // indirect
 	github.com/prashantgupta24/mac-sleep-notifier v1.0.1 // indirect
 	golang.org/x/sys v0.7.0 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
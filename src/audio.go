@@ -21,9 +21,15 @@
 package main
 
 import (
+	"encoding/binary"
+	"io"
 	"log"
+	"math"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/asticode/go-astiav"
 	"github.com/hajimehoshi/oto/v2"
 )
 
@@ -33,11 +39,27 @@ var (
 	globalMu           sync.Mutex
 	globalRate         int
 	globalCh           int
+	// set when the Oto context could not be created (no audio device, headless box);
+	// every playback path checks audioAvailable() and the app runs video-only.
+	audioDisabled atomic.Bool
 )
 
+// audioOutput is a selectable playback device (see listAudioOutputs).
+type audioOutput struct {
+	ID   string // persisted in AppConfig.AudioDevice
+	Name string // shown in Settings
+}
+
+// audioAvailable reports whether audio playback can be used at all.
+func audioAvailable() bool {
+	return !audioDisabled.Load() && GlobalAudioContext != nil
+}
+
 // InitGlobalAudio initializes the global Oto context once.
 // We call this early on the main thread before starting cameras.
-func InitGlobalAudio(sampleRate, channels int) error {
+// bufferSize is the device buffer (latency); 0 uses the driver default.
+// Larger buffers smooth out stuttering on slow machines.
+func InitGlobalAudio(sampleRate, channels int, bufferSize time.Duration) error {
 	globalMu.Lock()
 	defer globalMu.Unlock()
 
@@ -50,9 +72,15 @@ func InitGlobalAudio(sampleRate, channels int) error {
 		return nil
 	}
 
-	ctx, ready, err := oto.NewContext(sampleRate, channels, oto.FormatSignedInt16LE)
+	ctx, ready, err := oto.NewContextWithOptions(&oto.NewContextOptions{
+		SampleRate:   sampleRate,
+		ChannelCount: channels,
+		Format:       oto.FormatSignedInt16LE,
+		BufferSize:   bufferSize,
+	})
 
 	if err != nil {
+		audioDisabled.Store(true)
 		return err
 	}
 
@@ -65,6 +93,129 @@ func InitGlobalAudio(sampleRate, channels int) error {
 	GlobalAudioContext = ctx
 	globalRate = sampleRate
 	globalCh = channels
+	if bufferSize > 0 {
+		log.Printf("audio: initialized Oto v2 context %d Hz/%d ch, buffer %v", globalRate, globalCh, bufferSize)
+		return nil
+	}
 	log.Printf("audio: initialized Oto v2 context %d Hz/%d ch", globalRate, globalCh)
 	return nil
 }
+
+// audioUnderrunSilence reports whether underruns should be filled with
+// silence ("silence", the default) instead of waiting for data ("skip").
+func audioUnderrunSilence() bool {
+	return globalConfig.AudioUnderrun != "skip"
+}
+
+// pcmBuffer is a bounded PCM queue between the decode loop and an Oto player.
+// Writes never block: when the player lags, the oldest audio is dropped.
+// On underrun, reads either hand out silence to keep the device fed at a
+// steady pace (no clicks), or block until audio arrives (skip mode).
+type pcmBuffer struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	buf     []byte
+	max     int // bytes
+	silence bool
+	closed  bool
+}
+
+func newPCMBuffer(maxBytes int, silence bool) *pcmBuffer {
+	b := &pcmBuffer{max: maxBytes, silence: silence}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+func (b *pcmBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return 0, io.ErrClosedPipe
+	}
+	b.buf = append(b.buf, p...)
+	if over := len(b.buf) - b.max; over > 0 {
+		over += over & 1 // keep 16-bit sample alignment
+		if over > len(b.buf) {
+			over = len(b.buf)
+		}
+		b.buf = append(b.buf[:0], b.buf[over:]...)
+	}
+	b.cond.Signal()
+	return len(p), nil
+}
+
+func (b *pcmBuffer) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for len(b.buf) == 0 && !b.closed {
+		if b.silence {
+			clear(p)
+			return len(p), nil
+		}
+		b.cond.Wait()
+	}
+	if len(b.buf) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, b.buf)
+	b.buf = append(b.buf[:0], b.buf[n:]...)
+	return n, nil
+}
+
+// Close makes pending and future reads return io.EOF.
+func (b *pcmBuffer) Close() {
+	b.mu.Lock()
+	b.closed = true
+	b.buf = nil
+	b.mu.Unlock()
+	b.cond.Broadcast()
+}
+
+// framePeak returns the peak amplitude (0..1) over all samples/channels of a
+// decoded audio frame, for the level meter. ok is false for sample formats
+// it doesn't read (s64, double).
+func framePeak(f *astiav.Frame) (peak float32, ok bool) {
+	b, err := f.Data().Bytes(1)
+	if err != nil || len(b) == 0 {
+		return 0, false
+	}
+	ne := binary.NativeEndian
+	switch f.SampleFormat() {
+	case astiav.SampleFormatU8, astiav.SampleFormatU8P:
+		for _, v := range b {
+			peak = max32(peak, float32(math.Abs(float64(int(v)-128)))/128)
+		}
+	case astiav.SampleFormatS16, astiav.SampleFormatS16P:
+		for i := 0; i+1 < len(b); i += 2 {
+			v := int16(ne.Uint16(b[i:]))
+			peak = max32(peak, float32(math.Abs(float64(v)))/32768)
+		}
+	case astiav.SampleFormatS32, astiav.SampleFormatS32P:
+		for i := 0; i+3 < len(b); i += 4 {
+			v := int32(ne.Uint32(b[i:]))
+			peak = max32(peak, float32(math.Abs(float64(v))/2147483648))
+		}
+	case astiav.SampleFormatFlt, astiav.SampleFormatFltp:
+		for i := 0; i+3 < len(b); i += 4 {
+			v := math.Float32frombits(ne.Uint32(b[i:]))
+			peak = max32(peak, float32(math.Abs(float64(v))))
+		}
+	default:
+		return 0, false
+	}
+	return min32(peak, 1), true
+}
+
+func max32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func min32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
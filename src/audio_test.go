@@ -0,0 +1,136 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+// seq returns n bytes counting up from start, so order and drops show.
+func seq(start, n int) []byte {
+	p := make([]byte, n)
+	for i := range p {
+		p[i] = byte(start + i)
+	}
+	return p
+}
+
+func readN(t *testing.T, b *pcmBuffer, n int) []byte {
+	t.Helper()
+	p := make([]byte, n)
+	got, err := b.Read(p)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	return p[:got]
+}
+
+func TestPCMBufferWrapAround(t *testing.T) {
+	b := newPCMBuffer(8, true)
+	// writes and partial reads interleaved, many times the capacity: the
+	// bytes come out in order, none lost or repeated
+	next, want := 0, 0
+	for round := 0; round < 50; round++ {
+		b.Write(seq(next, 6))
+		next += 6
+		if got := readN(t, b, 4); !bytes.Equal(got, seq(want, 4)) {
+			t.Fatalf("round %d: read %v, want %v", round, got, seq(want, 4))
+		}
+		want += 4
+		if got := readN(t, b, 2); !bytes.Equal(got, seq(want, 2)) {
+			t.Fatalf("round %d: read %v, want %v", round, got, seq(want, 2))
+		}
+		want += 2
+	}
+}
+
+func TestPCMBufferOverflowDropsOldest(t *testing.T) {
+	b := newPCMBuffer(8, true)
+	if n, err := b.Write(seq(0, 6)); n != 6 || err != nil {
+		t.Fatalf("Write = %d, %v", n, err)
+	}
+	// 5 more bytes: 3 over, rounded up to 4 to keep whole 16-bit samples
+	if n, err := b.Write(seq(6, 5)); n != 5 || err != nil {
+		t.Fatalf("Write = %d, %v; writes never block or fail", n, err)
+	}
+	if got := readN(t, b, 16); !bytes.Equal(got, seq(4, 7)) {
+		t.Fatalf("read %v, want the newest %v", got, seq(4, 7))
+	}
+	// a single write larger than the buffer keeps its tail
+	b.Write(seq(0, 20))
+	if got := readN(t, b, 32); !bytes.Equal(got, seq(12, 8)) {
+		t.Fatalf("read %v, want %v", got, seq(12, 8))
+	}
+}
+
+func TestPCMBufferUnderrunSilence(t *testing.T) {
+	b := newPCMBuffer(8, true)
+	p := bytes.Repeat([]byte{0xff}, 6)
+	n, err := b.Read(p)
+	if n != len(p) || err != nil {
+		t.Fatalf("Read = %d, %v; want a full buffer of silence", n, err)
+	}
+	if !bytes.Equal(p, make([]byte, 6)) {
+		t.Fatalf("underrun read %v, want zeros", p)
+	}
+	// data written afterwards comes through as is
+	b.Write(seq(1, 4))
+	if got := readN(t, b, 6); !bytes.Equal(got, seq(1, 4)) {
+		t.Fatalf("read %v, want %v", got, seq(1, 4))
+	}
+}
+
+func TestPCMBufferUnderrunSkipWaits(t *testing.T) {
+	b := newPCMBuffer(8, false)
+	got := make(chan []byte)
+	go func() {
+		p := make([]byte, 4)
+		n, _ := b.Read(p)
+		got <- p[:n]
+	}()
+	select {
+	case p := <-got:
+		t.Fatalf("Read returned %v before any data", p)
+	case <-time.After(50 * time.Millisecond):
+	}
+	b.Write(seq(7, 2))
+	if p := <-got; !bytes.Equal(p, seq(7, 2)) {
+		t.Fatalf("read %v, want %v", p, seq(7, 2))
+	}
+
+	// Close wakes a waiting reader with EOF
+	done := make(chan error)
+	go func() {
+		_, err := b.Read(make([]byte, 4))
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	b.Close()
+	if err := <-done; err != io.EOF {
+		t.Fatalf("Read after Close = %v, want io.EOF", err)
+	}
+	if _, err := b.Write(seq(0, 2)); err == nil {
+		t.Fatal("Write after Close succeeded")
+	}
+}
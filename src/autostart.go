@@ -0,0 +1,53 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// appExecutable is the path the login item starts: this binary, symlinks
+// resolved. An AppImage is started through the image, not its mount point.
+func appExecutable() (string, error) {
+	if p := os.Getenv("APPIMAGE"); p != "" {
+		return p, nil
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if p, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = p
+	}
+	return exe, nil
+}
+
+// applyAutostart makes the login item match start_at_login. When on, the
+// entry is rewritten at every start so it follows the app if it was moved.
+func applyAutostart() error {
+	err := setAutostart(globalConfig.StartAtLogin)
+	if err != nil {
+		log.Printf("autostart: %v", err)
+	}
+	return err
+}
@@ -0,0 +1,166 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	astiav "github.com/asticode/go-astiav"
+)
+
+/*
+Hardware vs software decode benchmark (camera dialog → Benchmark)
+*/
+
+const (
+	benchWarmup = 3 * time.Second // let the stream settle after a restart
+	benchSample = 5 * time.Second // then average the metrics over this long
+)
+
+// benchResult is the measurement for one HwAccel mode.
+type benchResult struct {
+	HwAccel string
+	FPS     float64 // decoded frames/s (camera metrics)
+	CamCPU  float64 // camera's own CPU estimate (convert/copy), % of one core
+	ProcCPU float64 // whole-process CPU, % of one core (includes decoder threads)
+	Err     string
+}
+
+// openHwDevice opens the FFmpeg hardware device for a HwAccel setting.
+func openHwDevice(name string) (*astiav.HardwareDeviceContext, error) {
+	devName := name
+	if devName == "nvdec" {
+		devName = "cuda" // NVDEC runs on the CUDA device
+	}
+	t := astiav.FindHardwareDeviceTypeByName(devName)
+	if t == astiav.HardwareDeviceTypeNone {
+		return nil, errors.New("not supported by this FFmpeg build")
+	}
+	return astiav.CreateHardwareDeviceContext(t, "", nil, 0)
+}
+
+// benchmarkDecoders restarts the live camera with each mode in turn
+// ("none" = software), samples fps/CPU and finally restores the original
+// settings. Takes ~8s per mode, so run it off the UI thread; closing cancel
+// cuts it short (the original settings are still restored).
+func (w *CamWindow) benchmarkDecoders(modes []string, cancel <-chan struct{}) []benchResult {
+	var orig CameraConfig
+	CallOnQtMain(func() { orig = w.cfg })
+	// restart applies the config on the Qt thread, then waits out the restart here
+	restart := func(c CameraConfig, reason string) {
+		CallOnQtMain(func() { w.applyConfig(c) })
+		w.restartDecoder(reason)
+	}
+	sleep := func(d time.Duration) bool {
+		select {
+		case <-cancel:
+			return false
+		case <-time.After(d):
+			return true
+		}
+	}
+	var out []benchResult
+modes:
+	for _, m := range modes {
+		r := benchResult{HwAccel: m}
+		if m != "none" {
+			hdc, err := openHwDevice(m)
+			if err != nil {
+				r.Err = err.Error()
+				out = append(out, r)
+				continue
+			}
+			hdc.Free()
+		}
+		c := orig
+		c.HwAccel = m
+		restart(c, "benchmark "+m)
+		if !sleep(benchWarmup) {
+			break modes
+		}
+
+		n := 0
+		cpu0, t0 := processCPUTime(), time.Now()
+		for time.Since(t0) < benchSample {
+			if !sleep(time.Second) {
+				break modes
+			}
+			var fps, cam float64
+			CallOnQtMain(func() { fps, _, _, cam, _ = w.MetricsSnapshot() })
+			r.FPS += fps
+			r.CamCPU += cam
+			n++
+		}
+		if n > 0 {
+			r.FPS /= float64(n)
+			r.CamCPU /= float64(n)
+		}
+		r.ProcCPU = 100 * float64(processCPUTime()-cpu0) / float64(time.Since(t0))
+		out = append(out, r)
+	}
+	restart(orig, "benchmark done")
+	return out
+}
+
+// recommendDecoder picks the mode with the lowest process CPU among those that
+// keep at least 90% of the best frame rate. Software wins ties.
+func recommendDecoder(res []benchResult) string {
+	best := 0.0
+	for _, r := range res {
+		if r.Err == "" && r.FPS > best {
+			best = r.FPS
+		}
+	}
+	pick, pickCPU := "", 0.0
+	for _, r := range res {
+		if r.Err != "" || r.FPS < 0.9*best {
+			continue
+		}
+		if pick == "" || r.ProcCPU < pickCPU*0.9 { // need a clear (10%) win to leave software
+			pick, pickCPU = r.HwAccel, r.ProcCPU
+		}
+	}
+	if pick == "" {
+		return "none"
+	}
+	return pick
+}
+
+// formatBenchResults renders the results for a message box.
+func formatBenchResults(res []benchResult, pick string) string {
+	var b strings.Builder
+	for _, r := range res {
+		name := r.HwAccel
+		if name == "none" {
+			name = "software"
+		}
+		if r.Err != "" {
+			fmt.Fprintf(&b, "%s: unavailable (%s)\n", name, r.Err)
+			continue
+		}
+		fmt.Fprintf(&b, "%s: %.1f fps, process CPU %.0f%%, camera CPU %.0f%%\n", name, r.FPS, r.ProcCPU, r.CamCPU)
+	}
+	fmt.Fprintf(&b, "\nRecommended: %s", pick)
+	return b.String()
+}
@@ -23,6 +23,9 @@ package main
 import (
 	"fmt"
 	"log"
+	"math"
+	"runtime"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -37,8 +40,12 @@ type CamWindow struct {
 	win  *qt.QMainWindow
 	view *VideoWidget
 
-	stop chan struct{}
-	done chan struct{}
+	// decoder generation; guarded by loopMu (see startLoop/signalStop)
+	loopMu     sync.Mutex
+	stop       chan struct{}
+	done       chan struct{}
+	startDelay time.Duration // the next generation waits this long before connecting (staggered start)
+	restartMu  sync.Mutex    // serializes restartDecoderWith
 
 	buf frameBuf
 
@@ -48,13 +55,16 @@ type CamWindow struct {
 	// supervisor state
 	lastAdvance      time.Time     // last time we saw progress
 	backoff          time.Duration // starts at 1s, doubles to 30s max
-	nextTry          time.Time     // when to attempt next reconnect
+	nextTry          atomic.Int64  // unix nanos of the next reconnect attempt (read by the overlay)
 	saveTimer        *qt.QTimer
 	idKey            string // stable key to find this camera in config (prefer ID, else Name)
 	idx              int
 	onClosed         func(idx int)
-	suppressOnClosed bool // one-shot: do not call onClosed on next close
+	onHidden         func(idx int) // close-to-tray: window hidden but still decoding
+	suppressOnClosed bool          // one-shot: do not call onClosed on next close
 	isFullscreen     bool
+	useMain          atomic.Bool // decode cfg.URL rather than cfg.SubURL (fullscreen)
+	streamTimer      *qt.QTimer  // debounces substream/main stream switches
 	// geometry to restore when leaving fullscreen
 	prevX, prevY int
 	prevW, prevH int
@@ -63,6 +73,8 @@ type CamWindow struct {
 	tmpBGRA       []byte
 	tmpStride     int
 	repaintTimer  *qt.QTimer
+	snapTimer     *qt.QTimer // periodic snapshots (snapshot.go)
+	lastSnapSeq   uint64
 	lastPaintSeq  uint64
 	contextHooked bool
 	// some statistics metrics / overlay
@@ -88,11 +100,22 @@ type CamWindow struct {
 	busyNS      int64   // total busy nanoseconds accumulated
 	lastMBusyNS int64   // snapshot for delta-per-second
 	cpuPct      float64 // percent of one core, last interval
+	// --- bitrate watch (max_kbps) ---
+	kbpsHist    [60]float64 // last minute of 1s samples, for the peak
+	kbpsHistIx  int
+	peakKbps    float64 // highest 1s bitrate of the last minute
+	overSecs    int     // seconds above the limit, counted back down below it
+	bitrateHigh bool    // sustained above the limit: warning overlay
 	// recording
 	recording atomic.Bool
+	clipReq   atomic.Bool // Save clip pressed: the decoder writes out its replay buffer (clip.go)
 	recStop   chan struct{}
 	recDone   chan struct{}
 	recPath   string
+	// status line of the current file (recstatus.go)
+	recOpen    atomic.Pointer[recFile] // set by the decode goroutine
+	recLine    string                  // "Recording 0:12:34 · 1.2 GiB · 80 GiB free"; main thread
+	lowDiskFor string                  // recording file the low-disk warning was shown for
 
 	// per-camera recorder FFmpeg state (used in video.go)
 	recCtx      *astiav.FormatContext
@@ -102,13 +125,133 @@ type CamWindow struct {
 	aEncCtx    *astiav.CodecContext
 	aEncStream *astiav.Stream
 	aSwr       *astiav.SoftwareResampleContext
-	aEncFrame  *astiav.Frame
-	audioPts   int64 // running PTS in samples for AAC encoder
+	aSwrFrame  *astiav.Frame     // resampled samples on their way into aFifo
+	aEncFrame  *astiav.Frame     // one encoder-sized frame taken from aFifo
+	aFifo      *astiav.AudioFifo // resampled samples waiting for a full AAC frame
+	audioPts   int64             // running PTS in samples: where the next sample entering aFifo goes
+
+	vEnc *recEncoder // record_video_mode "h264": decoded frames are re-encoded; nil = stream copy
 
 	recMu sync.Mutex
+
+	reprobe atomic.Bool  // set by ReloadStream; consumed by the next openAndDecode
+	state   atomic.Int32 // connState, written by the decode goroutine
+	probeOf atomic.Int32 // connState the current connDetecting phase started from
+	paused  atomic.Bool  // stopped on purpose (StopCamera); the window stays open
+	muted   atomic.Bool  // live cfg.Mute, read by the decode loop for every audio packet
+
+	clog camLogger // per-camera log file (see logf)
+
+	paramIssues atomic.Pointer[[]string] // FFmpeg params problems from the last open
+	badCodec    atomic.Pointer[string]   // codec without a decoder (overlay text while connUnsupported)
+	streamInfo  atomic.Pointer[string]   // "H264 1920x1080 yuvj420p" of the opened stream (stream info overlay)
+	lastErr     atomic.Pointer[string]   // last decode error (detail of the "disconnected" event)
+	audio       atomic.Int32             // audioState of the opened stream
+	audioFormat atomic.Pointer[string]   // "AAC 16000 Hz 1ch" (why audio is unsupported)
+
+	ptz ptzControl // ONVIF PTZ command queue (ptz.go)
+
+	// audio level meter: last frame's peak (float32 bits) and when it was seen
+	audioPeak   atomic.Uint32
+	audioPeakAt atomic.Int64
+}
+
+// connState is the stream connection state shown by the overlay.
+type connState int32
+
+const (
+	connConnecting   connState = iota // (re)started, no frame yet
+	connConnected                     // frames are flowing
+	connReconnecting                  // stream dropped, waiting for nextTry
+	connUnreachable                   // reachability pre-check failed, waiting for nextTry
+	connUnsupported                   // no decoder for the stream's codec; retried only every unsupportedRetry
+	connDetecting                     // connected, FindStreamInfo still analyzing the stream
+)
+
+func (w *CamWindow) connState() connState { return connState(w.state.Load()) }
+
+// isDown: the camera is meant to run but has no stream (dropped, unreachable
+// or undecodable). Paused cameras aren't down.
+func (w *CamWindow) isDown() bool {
+	if w.IsPaused() {
+		return false
+	}
+	s := w.connState()
+	return s == connReconnecting || s == connUnreachable || s == connUnsupported
+}
+
+// setConnState records a new connection state, adds it to the event
+// history and raises the tray notification when the camera goes down or
+// comes back.
+func (w *CamWindow) setConnState(s connState) {
+	old := connState(w.state.Swap(int32(s)))
+	// stream detection is a phase of (re)connecting: events are judged
+	// against the state it started from
+	if old == connDetecting {
+		old = connState(w.probeOf.Load())
+	}
+	if s == connDetecting {
+		w.probeOf.Store(int32(old))
+		return
+	}
+	if old == s {
+		return
+	}
+	if s == connConnected && w.cfg.AlwaysRecord && !w.recording.Load() {
+		// 24/7: (re)start recording with every connection
+		w.logf("always_record: recording on")
+		w.recording.Store(true)
+	}
+	down := s == connReconnecting || s == connUnreachable
+	wasDown := old == connReconnecting || old == connUnreachable
+	switch {
+	case old == connConnected && down:
+		w.logf("disconnected")
+		detail := ""
+		if e := w.lastErr.Load(); e != nil {
+			detail = *e
+		}
+		recordConnEvent(w.cfg.Name, "disconnected", detail)
+		tray.notifyConnection(w.cfg.Name, false)
+	case wasDown && s == connConnected:
+		w.logf("reconnected")
+		recordConnEvent(w.cfg.Name, "reconnected", "")
+		tray.notifyConnection(w.cfg.Name, true)
+	case s == connConnected:
+		recordConnEvent(w.cfg.Name, "connected", "")
+	case s == connUnreachable && old == connConnecting:
+		recordConnEvent(w.cfg.Name, "unreachable", "")
+	}
+}
+
+// unsupportedRetry is the retry interval for a stream whose codec this
+// FFmpeg build can't decode: retrying sooner won't help, Reload retries now.
+const unsupportedRetry = 10 * time.Minute
+
+// setUnsupported parks the camera in connUnsupported: overlay, one tray
+// notification per episode, and a long wait before the next attempt.
+func (w *CamWindow) setUnsupported(codec string) {
+	w.badCodec.Store(&codec)
+	w.nextTry.Store(time.Now().Add(unsupportedRetry).UnixNano())
+	if connState(w.state.Swap(int32(connUnsupported))) == connUnsupported {
+		return
+	}
+	w.logf("no %s decoder in this FFmpeg build; next attempt in %v", codec, unsupportedRetry)
+	recordConnEvent(w.cfg.Name, "unsupported codec", codec)
+	tray.notifyUnsupportedCodec(w.cfg.Name, codec)
+}
+
+// retryIn returns how long until the next reconnect attempt (0 if due).
+func (w *CamWindow) retryIn() time.Duration {
+	d := time.Until(time.Unix(0, w.nextTry.Load()))
+	if d < 0 {
+		return 0
+	}
+	return d
 }
 
 func (w *CamWindow) SetOnClosed(fn func(int)) { w.onClosed = fn }
+func (w *CamWindow) SetOnHidden(fn func(int)) { w.onHidden = fn }
 
 // Called by tray before Close()
 func (w *CamWindow) SuppressOnClosedOnce() { w.suppressOnClosed = true }
@@ -124,6 +267,9 @@ func newCamWindow(cfg CameraConfig, idx int) (*CamWindow, error) {
 		idx:         idx,
 	}
 
+	w.useMain.Store(cfg.Fullscreen)
+	w.muted.Store(cfg.Mute)
+
 	w.idKey = cfg.ID
 	if w.idKey == "" {
 		w.idKey = genID()
@@ -148,6 +294,18 @@ func newCamWindow(cfg CameraConfig, idx int) (*CamWindow, error) {
 
 	effectiveTop := globalConfig.AlwaysOnTopAll || cfg.AlwaysOnTop
 	win.SetWindowFlag2(qt.WindowStaysOnTopHint, effectiveTop)
+	win.SetWindowFlag2(qt.WindowTransparentForInput, cfg.ClickThrough)
+	if taskbarHidden() {
+		// only ever set: clearing the Tool bits would also strip the Window type
+		win.SetWindowFlag2(qt.Tool, true)
+	}
+
+	// never placed before (first run / newly added): take a tile instead of stacking at 0,0
+	autoPlaced := cfg.X == 0 && cfg.Y == 0 && cfg.Width == 0 && cfg.Height == 0
+	if autoPlaced {
+		cfg.X, cfg.Y, cfg.Width, cfg.Height = firstRunSlot(idx, len(globalConfig.Cameras))
+		w.cfg = cfg
+	}
 
 	var width, height int
 	if cfg.Width > 0 {
@@ -162,16 +320,27 @@ func newCamWindow(cfg CameraConfig, idx int) (*CamWindow, error) {
 	}
 
 	win.Resize(width, height)
-	if cfg.X > 0 && cfg.Y > 0 {
-		win.Move(cfg.X, cfg.Y)
-	} else {
-		win.Move(0, 0)
-	}
+	win.Move(cfg.X, cfg.Y) // negative on monitors left of/above the primary
+	w.win = win
+	w.rescueOffscreen() // saved on a monitor that is gone
 	if cfg.AlwaysOnTop {
 		win.SetWindowFlag2(qt.WindowStaysOnTopHint, true)
 	}
 
 	win.OnCloseEvent(func(super func(event *qt.QCloseEvent), event *qt.QCloseEvent) {
+		// user closed the window (title bar/OS) while close-to-tray is on: hide it, keep decoding.
+		// Programmatic closes (tray, settings) are not spontaneous and go through normally.
+		if !w.closing && globalConfig.CloseToTray && event.Spontaneous() && !appQuitting.Load() {
+			event.Ignore()
+			w.win.Hide()
+			w.logf("window hidden to tray")
+			if w.onHidden != nil {
+				cb := w.onHidden
+				i := w.idx
+				postToUI(*w.win.QObject, func() { cb(i) })
+			}
+			return
+		}
 		super(event)
 		if w.closing {
 			return
@@ -204,34 +373,31 @@ func newCamWindow(cfg CameraConfig, idx int) (*CamWindow, error) {
 			return
 		}
 		// Persist to config.yml
-		if err := UpdateCameraGeometry(w.idKey, w.win.Pos().X(), w.win.Pos().Y(), w.win.Size().Width(), w.win.Size().Height()); err != nil {
+		x, y := w.windowPos() // native Wayland can't report it: keep the saved one
+		if err := UpdateCameraGeometry(w.idKey, x, y, w.win.Size().Width(), w.win.Size().Height()); err != nil {
 			log.Printf("save geometry failed: %v", err)
 		}
 	})
 
 	view := NewVideoWidget(&w.buf, nil, cfg.Stretch)
+	view.FullscreenAspect = cfg.FullscreenKeepAspect
+	view.SetTransform(cfg.Rotate, cfg.FlipH, cfg.FlipV)
+	view.SetAspectRatio(cfg.AspectRatio)
+	view.SetAttribute2(qt.WA_TransparentForMouseEvents, cfg.ClickThrough)
 	win.SetCentralWidget(view.QWidget)
 	view.SetOverlayTitle(safeCamTitle(cfg), globalConfig.NoWindowsTitles)
 	view.SetOwner(w)
+	w.installPTZPad()
 
 	// Single-click on the camera window
 	win.OnMousePressEvent(func(super func(event *qt.QMouseEvent), event *qt.QMouseEvent) {
 		if w.isFullscreen {
 			return
 		}
-		env.activeWin = w
+		setActiveWin(w)
 
-		if !globalConfig.NoWindowsTitles && globalConfig.ActiveOnWin {
-			log.Printf("focus activated")
-			for _, w := range wins {
-				if w == nil {
-					continue
-				}
-				log.Printf("Activating window: %s", w.cfg.Name)
-				w.win.Raise()
-				w.win.ActivateWindow()
-				w.win.SetFocus()
-			}
+		if !globalConfig.NoWindowsTitles {
+			raiseOnClick(w)
 		}
 		log.Printf("active window set to: %s", env.activeWin.cfg.Name)
 	})
@@ -256,7 +422,7 @@ func newCamWindow(cfg CameraConfig, idx int) (*CamWindow, error) {
 
 			return
 		}
-		log.Printf("[%s] window moved to %dx%d", w.cfg.Name, event.Pos().X(), event.Pos().Y())
+		w.logf("window moved to %dx%d", event.Pos().X(), event.Pos().Y())
 		w.saveTimer.Stop()
 		w.saveTimer.Start2()
 	})
@@ -271,18 +437,23 @@ func newCamWindow(cfg CameraConfig, idx int) (*CamWindow, error) {
 		w.saveTimer.Start2()
 	})
 
-	// Allow SPACE to toggle recording when this window has focus
+	// Keyboard shortcuts (SPACE = record, Tab = next camera, ...; see keys.go)
 	win.OnKeyPressEvent(func(super func(event *qt.QKeyEvent), ev *qt.QKeyEvent) {
-		if ev.Key() == int(qt.Key_Space) {
-			if env.activeWin != nil {
-				env.activeWin.ToggleRecording()
-				//w.ToggleRecording()
-			}
+		if w.handlePTZKey(ev, true) || w.handleKey(ev) {
+			ev.Accept()
+			return
+		}
+		super(ev)
+	})
+	win.OnKeyReleaseEvent(func(super func(event *qt.QKeyEvent), ev *qt.QKeyEvent) {
+		if w.handlePTZKey(ev, false) {
 			ev.Accept()
 			return
 		}
 		super(ev)
 	})
+	// no focus chain to walk: let Tab/Shift+Tab reach OnKeyPressEvent
+	win.OnFocusNextPrevChild(func(super func(next bool) bool, next bool) bool { return false })
 
 	w.win = win
 	w.view = view
@@ -290,9 +461,20 @@ func newCamWindow(cfg CameraConfig, idx int) (*CamWindow, error) {
 	win.Show()
 	win.Raise()
 	win.ActivateWindow()
+	if cfg.Fullscreen {
+		w.restoreFullscreen()
+	}
+	if autoPlaced {
+		// persist the chosen tile right away (debounced saver reads the real position)
+		w.saveTimer.Start2()
+	}
 
-	// Start decoder loop
-	go w.decodeLoop()
+	// Start decoder loop; at app start each camera waits its turn
+	if startupSlot >= 0 {
+		w.delayNextStart(w.staggerDelay(startupSlot))
+		startupSlot++
+	}
+	w.startLoop()
 
 	// Very important: repaint on the GUI thread ~30 FPS
 	// when creating it (in newCamWindow)
@@ -301,7 +483,8 @@ func newCamWindow(cfg CameraConfig, idx int) (*CamWindow, error) {
 	w.ApplyGuiRefreshSettings()
 	t.OnTimeout(func() {
 		if w != nil && w.view != nil {
-			if globalConfig.RepaintOnNewFrame {
+			// while disconnected keep repainting so the status overlay/countdown stays live
+			if globalConfig.RepaintOnNewFrame && w.connState() == connConnected && !w.IsPaused() {
 				seq, _, _, _ := w.buf.get()
 				if seq == 0 || seq == w.lastPaintSeq {
 					return
@@ -312,6 +495,7 @@ func newCamWindow(cfg CameraConfig, idx int) (*CamWindow, error) {
 		}
 	})
 	t.Start2()
+	w.ApplySnapshotSettings()
 
 	w.lastMAt = time.Now()
 	w.metricsTimer = qt.NewQTimer()
@@ -362,6 +546,7 @@ func newCamWindow(cfg CameraConfig, idx int) (*CamWindow, error) {
 		w.fps = float64(dF) / dt
 		// bits/sec -> kbps
 		w.bitrateKbps = (float64(dB) * 8.0 / dt) / 1000.0
+		w.watchBitrate()
 		den := dF + dD
 		if den > 0 {
 			pct := 100.0 * float64(dD) / float64(den)
@@ -376,16 +561,20 @@ func newCamWindow(cfg CameraConfig, idx int) (*CamWindow, error) {
 			w.dropsPct = 0
 		}
 
-		// simple health heuristic 0..5
+		// simple health heuristic 0..5 (a low fps cap is not a health problem: scale up)
+		hfps := w.fps
+		if capFPS := w.maxFPS(); capFPS > 0 && capFPS < 24 {
+			hfps = w.fps * 24 / float64(capFPS)
+		}
 		score := 0
 		switch {
-		case w.fps >= 24:
+		case hfps >= 24:
 			score = 5
-		case w.fps >= 15:
+		case hfps >= 15:
 			score = 4
-		case w.fps >= 5:
+		case hfps >= 5:
 			score = 3
-		case w.fps > 0:
+		case hfps > 0:
 			score = 2
 		default: // stalled
 			score = 0
@@ -431,17 +620,12 @@ func (w *CamWindow) Close() {
 	//	w.stopRecording()
 	//}
 
-	log.Printf("[%s] closing camera", w.cfg.Name)
+	w.logf("closing camera")
 	w.closing = true
 	w.wantPlaying = false
 
 	// stop current decoder
-	select {
-	case <-w.stop:
-		// already closed
-	default:
-		close(w.stop)
-	}
+	done := w.signalStop()
 	if w.repaintTimer != nil {
 		w.repaintTimer.Stop()
 		w.repaintTimer.DeleteLater()
@@ -457,21 +641,54 @@ func (w *CamWindow) Close() {
 	}
 
 	// Don't block UI; log if the decoder doesn't stop promptly.
-	done := w.done
 	name := w.cfg.Name
 	go func() {
 		if done == nil {
+			w.clog.close()
 			return
 		}
 		select {
 		case <-done:
-			return
 		case <-time.After(2 * time.Second):
+			// a decoder stuck in FFmpeg may never return; don't wait on it
+			// (anything it still logs reopens the camera log)
 			log.Printf("[%s] close: decoder still running after timeout", name)
 		}
+		w.clog.close()
 	}()
 }
 
+// shutdownTimeout bounds how long quitting waits for the decoders to exit.
+const shutdownTimeout = 8 * time.Second
+
+// shutdownCameras closes every camera window and waits for the decoders to
+// exit, so recordings in progress get their trailer written (the decode loop
+// finalizes them on the way out) before the process ends. Main thread only.
+func shutdownCameras(ws []*CamWindow) {
+	var pending []chan struct{}
+	for _, w := range ws {
+		if w == nil {
+			continue
+		}
+		if w.IsRecording() {
+			w.logf("quit: finalizing recording")
+		}
+		w.Close()
+		if done := w.signalStop(); done != nil {
+			pending = append(pending, done)
+		}
+	}
+	deadline := time.After(shutdownTimeout)
+	for i, done := range pending {
+		select {
+		case <-done:
+		case <-deadline:
+			log.Printf("quit: %d camera(s) still stopping after %v, exiting anyway", len(pending)-i, shutdownTimeout)
+			return
+		}
+	}
+}
+
 func (w *CamWindow) ApplyGuiRefreshSettings() {
 	if w == nil || w.repaintTimer == nil {
 		return
@@ -497,14 +714,136 @@ func guiRefreshIntervalMs() int {
 	return ms
 }
 
+// stallCutoff is how long the decoder may go without progress before it
+// reconnects; 0 means the watchdog is off (reconnect on hard errors only).
+func (w *CamWindow) stallCutoff() time.Duration {
+	cutoff := 10 * time.Second
+	switch {
+	case w.cfg.StallTimeout < 0:
+		return 0
+	case w.cfg.StallTimeout > 0:
+		cutoff = time.Duration(w.cfg.StallTimeout) * time.Second
+	}
+	// never shorter than FFmpeg's own timeout: a slow link gets that long
+	if t := w.connectTimeout(); cutoff < t {
+		cutoff = t
+	}
+	return cutoff
+}
+
+const defaultConnectTimeout = 5 * time.Second
+
+// connectTimeout bounds connecting and every blocking read of the stream
+// (FFmpeg timeout / rw_timeout).
+func (w *CamWindow) connectTimeout() time.Duration {
+	if w.cfg.ConnectTimeout > 0 {
+		return time.Duration(w.cfg.ConnectTimeout) * time.Second
+	}
+	return defaultConnectTimeout
+}
+
+// restartAfter is how many failed connection attempts in a row trigger a
+// full stream reset; 0 means never.
+func restartAfter() int {
+	switch {
+	case globalConfig.RestartAfterFailures < 0:
+		return 0
+	case globalConfig.RestartAfterFailures > 0:
+		return globalConfig.RestartAfterFailures
+	}
+	return 8
+}
+
+// maxFPS is the preview frame-rate cap: the camera's own MaxFPS, else the
+// global default; 0 = unlimited.
+func (w *CamWindow) maxFPS() int {
+	if w.cfg.MaxFPS > 0 {
+		return w.cfg.MaxFPS
+	}
+	if globalConfig.DefaultMaxFPS > 0 {
+		return globalConfig.DefaultMaxFPS
+	}
+	return 0
+}
+
+// maxKbps is the expected maximum input bitrate: the camera's own, else the
+// global default; 0 means no limit is watched.
+func (w *CamWindow) maxKbps() int {
+	if w.cfg.MaxKbps != 0 {
+		return max(0, w.cfg.MaxKbps)
+	}
+	return max(0, globalConfig.DefaultMaxKbps)
+}
+
+// bitrateSustain is how many seconds the input has to stay above max_kbps
+// before the warning shows (and below it before the warning clears).
+const bitrateSustain = 5
+
+// watchBitrate runs once per metrics tick on the UI thread: it keeps the
+// one-minute peak and flags a sustained overrun of maxKbps.
+func (w *CamWindow) watchBitrate() {
+	w.kbpsHist[w.kbpsHistIx] = w.bitrateKbps
+	w.kbpsHistIx = (w.kbpsHistIx + 1) % len(w.kbpsHist)
+	w.peakKbps = slices.Max(w.kbpsHist[:])
+
+	limit := w.maxKbps()
+	if limit == 0 {
+		w.overSecs, w.bitrateHigh = 0, false
+		return
+	}
+	if w.bitrateKbps > float64(limit) {
+		w.overSecs = min(w.overSecs+1, bitrateSustain)
+	} else {
+		w.overSecs = max(w.overSecs-1, 0)
+	}
+	switch {
+	case !w.bitrateHigh && w.overSecs == bitrateSustain:
+		w.bitrateHigh = true
+		detail := fmt.Sprintf("%.0f kbps, limit %d kbps", w.bitrateKbps, limit)
+		w.logf("bitrate above limit: %s", detail)
+		recordConnEvent(w.cfg.Name, "bitrate high", detail)
+	case w.bitrateHigh && w.overSecs == 0:
+		w.bitrateHigh = false
+		w.logf("bitrate back under %d kbps", limit)
+		recordConnEvent(w.cfg.Name, "bitrate normal", "")
+	}
+}
+
+// decodeThreads is the video decoder thread count for a camera without its
+// own threads setting; 0 leaves it to FFmpeg (one thread per core).
+func decodeThreads() int {
+	t := globalConfig.DecodeThreads
+	if t >= 0 {
+		return t
+	}
+	// auto: share the cores between the running cameras
+	cams := 0
+	for _, c := range globalConfig.Cameras {
+		if !c.Disabled {
+			cams++
+		}
+	}
+	return max(1, runtime.NumCPU()/max(1, cams))
+}
+
+// unreachableRetry is the retry interval while the reachability pre-check
+// fails: the probe is cheap, so a camera that comes back is picked up fast.
+const unreachableRetry = 3 * time.Second
+
 func (w *CamWindow) setReconnectSoon() {
+	if w.connState() == connUnreachable {
+		// fixed and short; the backoff is kept for real stream failures
+		w.nextTry.Store(time.Now().Add(unreachableRetry).UnixNano())
+		return
+	}
 	if w.backoff == 0 {
 		w.backoff = time.Second
 	}
 	if w.backoff > 30*time.Second {
 		w.backoff = 30 * time.Second
 	}
-	w.nextTry = time.Now().Add(w.backoff)
+	w.nextTry.Store(time.Now().Add(w.backoff).UnixNano())
+	w.setConnState(connReconnecting)
 	if w.backoff < 30*time.Second {
 		w.backoff *= 2
 		if w.backoff > 30*time.Second {
@@ -525,12 +864,58 @@ func (w *CamWindow) SetContextMenu(menu *qt.QMenu) {
 // ToggleFullscreen switches between normal windowed mode and fullscreen.
 // While fullscreen, we suppress move/resize persistence.
 // On exit we restore the exact previous geometry.
+// The state (and monitor) is saved so the next start comes back fullscreen.
 func (w *CamWindow) ToggleFullscreen() {
 	if w == nil || w.win == nil {
 		return
 	}
+	// entering: on the camera's chosen monitor, if set and connected
+	w.setFullscreen(!w.isFullscreen, screenByName(w.cfg.FullscreenMonitor))
+
+	on, screen := w.isFullscreen, ""
+	if on {
+		if scr := w.win.Screen(); scr != nil {
+			screen = scr.Name()
+		}
+	}
+	w.cfg.Fullscreen, w.cfg.FullscreenScreen = on, screen
+	if err := UpdateCamera(w.idKey, func(c *CameraConfig) {
+		c.Fullscreen, c.FullscreenScreen = on, screen
+	}); err != nil {
+		log.Printf("save fullscreen state failed: %v", err)
+	}
+}
+
+// restoreFullscreen re-enters fullscreen on the saved monitor at startup.
+// The windowed geometry from the config becomes the one restored on exit.
+func (w *CamWindow) restoreFullscreen() {
+	w.logf("restoring fullscreen")
+	w.setFullscreen(true, screenByName(w.cfg.FullscreenScreen))
+}
+
+// screenByName finds a connected monitor by its Qt name; nil when name is
+// empty or no such monitor is attached.
+func screenByName(name string) *qt.QScreen {
+	if name == "" {
+		return nil
+	}
+	for _, scr := range qt.QGuiApplication_Screens() {
+		if scr.Name() == name {
+			return scr
+		}
+	}
+	return nil
+}
 
-	if !w.isFullscreen {
+// setFullscreen enters (on the given screen, or the current one when nil)
+// or leaves fullscreen without touching the saved config.
+func (w *CamWindow) setFullscreen(on bool, scr *qt.QScreen) {
+	if on == w.isFullscreen {
+		return
+	}
+	w.scheduleStreamSwitch()
+
+	if on {
 		// going fullscreen: remember current geometry
 		g := w.win.Geometry()
 		w.prevX, w.prevY = g.X(), g.Y()
@@ -539,6 +924,13 @@ func (w *CamWindow) ToggleFullscreen() {
 		// don’t persist any geometry changes triggered by FS transition
 		w.suppressSave = true
 		w.isFullscreen = true
+		if scr != nil {
+			sg := scr.Geometry()
+			w.win.Move(sg.X(), sg.Y())
+			if wh := w.win.WindowHandle(); wh != nil {
+				wh.SetScreen(scr) // the move alone may not have landed yet
+			}
+		}
 		w.win.ShowFullScreen()
 
 		// small single-shot to re-enable normal saves after FS settled
@@ -573,24 +965,210 @@ func (w *CamWindow) ToggleFullscreen() {
 	t.Start2()
 }
 
+// streamSwitchDelay is how long the fullscreen state must hold before the
+// decoder is restarted on the other stream.
+const streamSwitchDelay = 1500 // ms
+
+// streamURL is the URL the decoder should open: the substream for the
+// windowed preview, the main stream when fullscreen or no substream is set.
+func (w *CamWindow) streamURL() string {
+	if w.cfg.SubURL != "" && !w.useMain.Load() {
+		return w.cfg.SubURL
+	}
+	return w.cfg.URL
+}
+
+// scheduleStreamSwitch (re)arms the debounce timer; rapid fullscreen toggles
+// collapse into at most one decoder restart.
+func (w *CamWindow) scheduleStreamSwitch() {
+	if w.cfg.SubURL == "" || w.cfg.SubURL == w.cfg.URL {
+		return // no substream/main stream pair: nothing to switch
+	}
+	if w.streamTimer == nil {
+		w.streamTimer = qt.NewQTimer2(w.win.QObject)
+		w.streamTimer.SetSingleShot(true)
+		w.streamTimer.SetInterval(streamSwitchDelay)
+		w.streamTimer.OnTimeout(w.applyStreamChoice)
+	}
+	w.streamTimer.Start2()
+}
+
+// applyStreamChoice restarts the decoder on the stream matching the current
+// fullscreen state, if it isn't already on it.
+func (w *CamWindow) applyStreamChoice() {
+	want := w.isFullscreen
+	if w.closing || w.useMain.Load() == want {
+		return
+	}
+	w.useMain.Store(want)
+	if w.IsPaused() {
+		return // picked up on resume
+	}
+	go w.restartDecoderWith(map[bool]string{true: "main stream", false: "substream"}[want], true)
+}
+
+// SetClickThrough makes the window ignore mouse input (it goes to whatever
+// is below) or take it again, and saves the choice. Once on, the window
+// can't open its own context menu: the tray menu turns it off.
+func (w *CamWindow) SetClickThrough(on bool) {
+	if w == nil || w.win == nil || w.cfg.ClickThrough == on {
+		return
+	}
+	w.cfg.ClickThrough = on
+	visible := w.win.IsVisible()
+	w.win.SetWindowFlag2(qt.WindowTransparentForInput, on)
+	w.view.SetAttribute2(qt.WA_TransparentForMouseEvents, on)
+	if visible {
+		w.win.Show() // changing window flags hides the window
+	}
+	if err := UpdateCamera(w.idKey, func(c *CameraConfig) { c.ClickThrough = on }); err != nil {
+		log.Printf("save config failed: %v", err)
+	}
+	w.logf("click-through %s", map[bool]string{true: "on", false: "off"}[on])
+}
+
+// SetStretch switches between letterboxing and filling the window, and
+// remembers the choice in the camera's config.
+func (w *CamWindow) SetStretch(on bool) {
+	if w == nil || w.view == nil {
+		return
+	}
+	w.cfg.Stretch = on
+	w.view.Stretch = on
+	w.view.Update()
+	if err := UpdateCamera(w.idKey, func(c *CameraConfig) { c.Stretch = on }); err != nil {
+		log.Printf("save config failed: %v", err)
+	}
+}
+
+// SetFullscreenKeepAspect makes fullscreen letterbox the picture even when
+// the window stretches it.
+func (w *CamWindow) SetFullscreenKeepAspect(on bool) {
+	if w == nil || w.view == nil {
+		return
+	}
+	w.cfg.FullscreenKeepAspect = on
+	w.view.FullscreenAspect = on
+	w.view.Update()
+	if err := UpdateCamera(w.idKey, func(c *CameraConfig) { c.FullscreenKeepAspect = on }); err != nil {
+		log.Printf("save config failed: %v", err)
+	}
+}
+
+// CopyStreamURL puts the camera's stream URL on the clipboard, with the
+// configured credentials in it (ready for VLC/ffplay) or, redacted, with the
+// password masked.
+func (w *CamWindow) CopyStreamURL(redacted bool) {
+	clip := qt.QGuiApplication_Clipboard()
+	if w == nil || clip == nil {
+		return
+	}
+	u := streamURLWithAuth(w.cfg.URL, w.cfg.Username, w.cfg.Password)
+	if redacted {
+		u = redactedStreamURL(w.cfg.URL, w.cfg.Username)
+	}
+	clip.SetText2(u, qt.QClipboard__Clipboard)
+}
+
+// OpenExternally hands the stream, credentials included, to the external
+// player (Settings → Advanced → External player) or the system's handler.
+func (w *CamWindow) OpenExternally() {
+	w.logf("open in external player: %s", redactedStreamURL(w.cfg.URL, w.cfg.Username))
+	openExternalPlayer(streamURLWithAuth(w.cfg.URL, w.cfg.Username, w.cfg.Password))
+}
+
 // OnResumeFromSleep is called when the app detects a system wake.
-// We just restart the decoder loop (non-blocking).
-func (w *CamWindow) OnResumeFromSleep() {
+// We just restart the decoder loop (non-blocking), staggered like at app
+// start: slot is the window's place among those being woken.
+func (w *CamWindow) OnResumeFromSleep(slot int) {
 	if w == nil || w.closing || w.cfg.Disabled {
 		return
 	}
+	w.delayNextStart(w.staggerDelay(slot))
 	// Restart in its own goroutine so we don't block UI.
 	go w.restartDecoder("Wake")
 }
 
 // Update config then restart decode pipeline.
 func (w *CamWindow) RestartWith(c CameraConfig, reason string) {
+	w.applyConfig(c)
+	w.restartDecoder(reason)
+}
+
+// applyConfig swaps in a new camera config and updates the view to match;
+// the running stream is not touched. Qt main thread only.
+func (w *CamWindow) applyConfig(c CameraConfig) {
 	w.cfg = c
+	w.muted.Store(c.Mute)
+	if w.view != nil {
+		w.view.SetTransform(c.Rotate, c.FlipH, c.FlipV)
+		w.view.SetAspectRatio(c.AspectRatio)
+		w.view.Stretch = c.Stretch
+		w.view.FullscreenAspect = c.FullscreenKeepAspect
+	}
 	w.backoff = 250 * time.Millisecond
-	w.restartDecoder(reason)
+	w.ApplySnapshotSettings()
 }
 
 func (w *CamWindow) StopCamera() {
+	w.paused.Store(true)
+	w.signalStop()
+}
+
+// runDecodeLoop is the body of a decoder generation (tests replace it).
+// Assigned in init: decodeLoop restarts through startLoop, which would make
+// an initializer an initialization cycle.
+var runDecodeLoop func(w *CamWindow, stop <-chan struct{}, done chan struct{}, delay time.Duration)
+
+func init() { runDecodeLoop = (*CamWindow).decodeLoop }
+
+// startLoop starts a new decoder generation with fresh stop/done channels.
+// A generation still running is told to stop first, so there is never more
+// than one that isn't stopping.
+func (w *CamWindow) startLoop() {
+	w.loopMu.Lock()
+	defer w.loopMu.Unlock()
+	w.closeStopLocked()
+	w.stop = make(chan struct{})
+	w.done = make(chan struct{})
+	delay := w.startDelay
+	w.startDelay = 0
+	go runDecodeLoop(w, w.stop, w.done, delay)
+}
+
+// delayNextStart makes the next decoder generation wait d before it
+// connects; a stop during the wait ends it like any other.
+func (w *CamWindow) delayNextStart(d time.Duration) {
+	w.loopMu.Lock()
+	defer w.loopMu.Unlock()
+	w.startDelay = d
+}
+
+// startupSlot counts the cameras main has opened so far while it starts
+// them all (-1 otherwise). Qt main thread only.
+var startupSlot = -1
+
+// staggerDelay is how long the camera opened as the slot-th one in a batch
+// (app start, wake from sleep) waits before connecting: start_stagger_ms per
+// camera before it plus its own start_delay_ms, so an NVR isn't asked for
+// every stream at once.
+func (w *CamWindow) staggerDelay(slot int) time.Duration {
+	ms := slot*globalConfig.StartStaggerMs + w.cfg.StartDelayMs
+	return time.Duration(ms) * time.Millisecond
+}
+
+// signalStop asks the current generation to stop (idempotent) and returns
+// its done channel, nil if none was started.
+func (w *CamWindow) signalStop() chan struct{} {
+	w.loopMu.Lock()
+	defer w.loopMu.Unlock()
+	w.closeStopLocked()
+	return w.done
+}
+
+// closeStopLocked closes the current stop channel unless it already is.
+// Caller holds loopMu.
+func (w *CamWindow) closeStopLocked() {
 	if w.stop != nil {
 		select {
 		case <-w.stop: // already closed
@@ -600,38 +1178,143 @@ func (w *CamWindow) StopCamera() {
 	}
 }
 
+// setAudioPeak records the peak (0..1) of the latest decoded audio frame.
+// The meter falls back slowly, so short peaks stay readable.
+func (w *CamWindow) setAudioPeak(peak float32) {
+	old := math.Float32frombits(w.audioPeak.Load())
+	if decayed := old * 0.85; peak < decayed {
+		peak = decayed
+	}
+	w.audioPeak.Store(math.Float32bits(peak))
+	w.audioPeakAt.Store(time.Now().UnixNano())
+}
+
+// audioLevel is the meter level (0..1); ok is false when no audio was
+// decoded in the last second.
+func (w *CamWindow) audioLevel() (level float64, ok bool) {
+	if time.Since(time.Unix(0, w.audioPeakAt.Load())) > time.Second {
+		return 0, false
+	}
+	return float64(math.Float32frombits(w.audioPeak.Load())), true
+}
+
+// audioState is what the last open found out about the stream's audio.
+type audioState int32
+
+const (
+	audioUnknown     audioState = iota // not opened yet
+	audioNoTrack                       // the stream has no audio
+	audioUnsupported                   // no decoder, or a format the player can't take
+	audioPlayable                      // G.711-style 8 kHz mono S16
+)
+
+// setAudioState records the audio found by openAndDecode; format describes
+// the track for the unsupported case.
+func (w *CamWindow) setAudioState(s audioState, format string) {
+	w.audioFormat.Store(&format)
+	w.audio.Store(int32(s))
+}
+
+// audioStatus describes the camera's audio for the menu and the meter:
+// no track, unsupported, muted or playing ("" before the first open).
+func (w *CamWindow) audioStatus() string {
+	switch audioState(w.audio.Load()) {
+	case audioNoTrack:
+		return "no audio track"
+	case audioUnsupported:
+		if f := w.audioFormat.Load(); f != nil && *f != "" {
+			return "unsupported format (" + *f + ")"
+		}
+		return "unsupported format"
+	case audioPlayable:
+		switch {
+		case w.muted.Load() || w.cfg.MutePlayback:
+			return "muted"
+		case !audioAvailable():
+			return "no audio output"
+		}
+		return "playing"
+	}
+	return ""
+}
+
+// IsPaused reports whether the camera was stopped with StopCamera and not
+// started again.
+func (w *CamWindow) IsPaused() bool { return w != nil && w.paused.Load() }
+
 func (w *CamWindow) StartCamera() {
 	w.restartDecoder("Start")
 }
 
+// ReconnectNow skips whatever backoff is pending and retries right away,
+// keeping the stream state (unlike ReloadStream). Blocks like restartDecoder.
+func (w *CamWindow) ReconnectNow() {
+	if w == nil || w.closing {
+		return
+	}
+	w.backoff = 250 * time.Millisecond
+	w.nextTry.Store(time.Now().UnixNano())
+	w.restartDecoder("Reconnect")
+}
+
+// ReloadStream restarts the decoder with a full re-probe of the stream:
+// everything learned from the previous session (last frame, timebase, fps)
+// is dropped so a changed resolution or codec is picked up without closing
+// the window. Blocks like restartDecoder, so call it off the UI thread.
+func (w *CamWindow) ReloadStream() {
+	if w == nil || w.closing {
+		return
+	}
+	w.restartDecoderWith("Reload", true)
+}
+
 // Restart stops the current decode goroutine (if any) and starts a fresh one.
 // Safe to call from any goroutine. Never blocks the UI thread indefinitely.
 func (w *CamWindow) restartDecoder(reason string) {
+	w.restartDecoderWith(reason, false)
+}
+
+// audioToRecording reports whether decoded audio goes into a recording:
+// record_audio when set, else only while the camera isn't muted.
+func (w *CamWindow) audioToRecording(muted bool) bool {
+	if r := w.cfg.RecordAudio; r != nil {
+		return *r
+	}
+	return !muted
+}
+
+// recOutStream is the recording stream packets of input stream si are
+// copied to. Copied audio (record_audio_mode: copy) follows mute and
+// record_audio like encoded audio does: ok is false while it is left out.
+func (w *CamWindow) recOutStream(si, aIdx int) (out int, ok bool) {
+	out, ok = w.recStreamIx[si]
+	if ok && si == aIdx && !w.audioToRecording(w.muted.Load()) {
+		return 0, false
+	}
+	return out, ok
+}
+
+// watchdogRestart is the restart reason of the failed-reconnect watchdog.
+const watchdogRestart = "Watchdog"
+
+// restartDecoderWith is restartDecoder with an optional full re-probe.
+func (w *CamWindow) restartDecoderWith(reason string, reprobe bool) {
 	if w == nil {
 		return
 	}
-	// Stop the current loop, if running.
-	if w.stop != nil {
+	// one restart at a time, or two callers could each start a loop
+	w.restartMu.Lock()
+	defer w.restartMu.Unlock()
+
+	// Stop the current loop, if running, and wait for it to report done,
+	// but don't hang forever. A loop that overruns keeps its own channels
+	// and exits on its own; it never sees the new generation's.
+	if done := w.signalStop(); done != nil {
 		select {
-		case <-w.stop: // already closed
-		default:
-			close(w.stop)
-		}
-	}
-
-	// Wait for the loop to report done, but don't hang forever.
-	waitCh := make(chan struct{})
-	go func(done <-chan struct{}) {
-		if done != nil {
-			<-done
+		case <-done:
+		case <-time.After(2 * time.Second):
+			w.logf("restart: previous decoder still running after timeout")
 		}
-		close(waitCh)
-	}(w.done)
-
-	select {
-	case <-waitCh:
-	case <-time.After(2 * time.Second):
-		// Timed out waiting; proceed anyway.
 	}
 
 	// small grace so the RTSP server releases the old session
@@ -641,22 +1324,145 @@ func (w *CamWindow) restartDecoder(reason string) {
 		w.backoff = 250 * time.Millisecond
 	}
 
-	// Re-create channels and start decoding again.
-	w.stop = make(chan struct{})
-	w.done = make(chan struct{})
+	if reprobe {
+		w.reprobe.Store(true) // taken by the next openAndDecode
+	}
+	if reason == watchdogRestart && w.IsPaused() {
+		return // paused (or hidden) while the old loop wound down: stay stopped
+	}
+
+	// a deliberate restart is not a disconnect: no notification
+	w.state.Store(int32(connConnecting))
+	w.paused.Store(false)
 
 	// Optional: reset “progress” markers if we have them
 	// (only touch fields we actually have in our struct).
 	// w.lastAdvance = time.Time{}
 
-	log.Printf("[%s] restarting decoder (%s)", w.cfg.Name, reason)
-	go w.decodeLoop()
+	if w.closing {
+		return // window closed meanwhile: don't resurrect its decoder
+	}
+	w.logf("restarting decoder (%s)", reason)
+	// Re-create channels and start decoding again.
+	w.startLoop()
 }
 
 func (w *CamWindow) MetricsSnapshot() (fps, kbps, drops, cpu float64, health int) {
 	return w.fps, w.bitrateKbps, w.dropsPct, w.cpuPct, int(atomic.LoadInt32(&w.health))
 }
 
+// win_click values
+const (
+	winClickRaiseAll     = "raise_all"
+	winClickRaiseClicked = "raise_clicked"
+)
+
+// taskbarHidden: with hide_dock_icon the camera windows are Qt::Tool (utility)
+// windows, which taskbars on Linux and Windows leave out. Not on macOS: tool
+// windows vanish there when the app loses focus, so the Dock icon goes instead.
+func taskbarHidden() bool {
+	return globalConfig.HideDockIcon && runtime.GOOS != "darwin"
+}
+
+// raiseOnClick brings windows to front after a click on w, as configured by
+// win_click: all camera windows (w last, so it stays on top) or w alone.
+func raiseOnClick(w *CamWindow) {
+	switch globalConfig.WinClick {
+	case winClickRaiseAll:
+		log.Printf("focus activated")
+		for _, o := range wins {
+			if o == nil || o == w {
+				continue
+			}
+			log.Printf("Activating window: %s", o.cfg.Name)
+			o.win.Raise()
+			o.win.ActivateWindow()
+		}
+		fallthrough
+	case winClickRaiseClicked:
+		w.win.Raise()
+		w.win.ActivateWindow()
+		w.win.SetFocus()
+	}
+}
+
+// setActiveWin makes w the active camera (SPACE target) and repaints the
+// previous and new one so the active border follows.
+func setActiveWin(w *CamWindow) {
+	old := env.activeWin
+	env.activeWin = w
+	if old != nil && old != w && old.view != nil && !old.closing {
+		old.view.Update()
+	}
+	if w != nil && w.view != nil {
+		w.view.Update()
+	}
+}
+
+// cycleWindows brings the next (step=1) or previous (step=-1) camera window
+// to front, in camera config order, skipping disabled and hidden cameras.
+// Bound to camera window keys (keys.go), so it only runs while a camera
+// window has the focus; showAndFocus hands the focus on to the next one.
+func cycleWindows(from *CamWindow, step int) {
+	n := len(wins)
+	if n == 0 {
+		return
+	}
+	start := -1
+	if step < 0 {
+		start = 0
+	}
+	for i, c := range wins {
+		if c == from {
+			start = i
+			break
+		}
+	}
+	for k := 1; k <= n; k++ {
+		j := ((start+step*k)%n + n) % n
+		c := wins[j]
+		if c == nil || c.win == nil || c.closing || c.cfg.Disabled || !c.win.IsVisible() {
+			continue
+		}
+		setActiveWin(c)
+		showAndFocus(c.win)
+		return
+	}
+}
+
+// defaultWindowW/H is the size of a camera window without saved geometry.
+const defaultWindowW, defaultWindowH = 640, 480
+
+// firstRunSlot lays cameras that have no saved geometry out in a grid over
+// the primary screen, in config order. Cells keep 4:3 and are capped at 640x480.
+func firstRunSlot(idx, total int) (x, y, width, height int) {
+	width, height = defaultWindowW, defaultWindowH
+	scr := qt.QGuiApplication_PrimaryScreen()
+	if scr == nil || total <= 0 {
+		return 0, 0, width, height
+	}
+	sg := scr.AvailableGeometry()
+	cols := int(math.Ceil(math.Sqrt(float64(total))))
+	rows := (total + cols - 1) / cols
+	cellW, cellH := sg.Width()/cols, sg.Height()/rows
+	if cellW < width {
+		width = cellW
+	}
+	if cellH < height {
+		height = cellH
+	}
+	if width*3 > height*4 {
+		width = height * 4 / 3
+	} else {
+		height = width * 3 / 4
+	}
+	if mw, mh := minWindowSize(); width < mw || height < mh {
+		width, height = max(width, mw), max(height, mh)
+	}
+	col, row := idx%cols, (idx/cols)%rows
+	return sg.X() + col*cellW, sg.Y() + row*cellH, width, height
+}
+
 func looksFullscreenish(win *qt.QMainWindow) bool {
 	if win == nil {
 		return false
@@ -708,11 +1514,7 @@ func (w *CamWindow) ToggleRecording() {
 
 	now := !w.recording.Load()
 	w.recording.Store(now)
-
-	// Optional: clear last path when starting
-	if now {
-		w.recPath = ""
-	}
+	// recPath belongs to the decode loop: it clears it once the file is closed
 
 	// Just repaint OSD
 	/*
@@ -723,5 +1525,5 @@ func (w *CamWindow) ToggleRecording() {
 		}
 	*/
 
-	log.Printf("[%s] recording %s", w.cfg.Name, map[bool]string{true: "ON", false: "OFF"}[now])
+	w.logf("recording %s", map[bool]string{true: "ON", false: "OFF"}[now])
 }
@@ -0,0 +1,114 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Start, stop and restart race each other (tray, settings, wake, watchdog).
+// However they interleave, at most one decoder generation may be running
+// without having been told to stop, and every one of them must exit.
+func TestDecoderGenerationsUnderConcurrentRestarts(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		stops   []<-chan struct{} // every generation's stop channel
+		live    atomic.Int32      // generations that haven't returned
+		overlap atomic.Int32      // times two generations were running unstopped
+	)
+	orig := runDecodeLoop
+	t.Cleanup(func() { runDecodeLoop = orig })
+	runDecodeLoop = func(w *CamWindow, stop <-chan struct{}, done chan struct{}, delay time.Duration) {
+		defer close(done)
+		live.Add(1)
+		defer live.Add(-1)
+		mu.Lock()
+		stops = append(stops, stop)
+		running := 0
+		for _, s := range stops {
+			select {
+			case <-s:
+			default:
+				running++
+			}
+		}
+		if running > 1 {
+			overlap.Add(1)
+		}
+		mu.Unlock()
+		<-stop
+	}
+
+	before := runtime.NumGoroutine()
+	w := &CamWindow{}
+	w.cfg.Name = "stress"
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 6; i++ {
+				switch (g + i) % 3 {
+				case 0:
+					w.startLoop()
+				case 1:
+					w.signalStop()
+				case 2:
+					w.restartDecoderWith("stress", i%2 == 0)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if done := w.signalStop(); done != nil {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("the last generation did not stop")
+		}
+	}
+	if n := overlap.Load(); n > 0 {
+		t.Errorf("%d times more than one generation was running", n)
+	}
+	mu.Lock()
+	gens := len(stops)
+	mu.Unlock()
+	if gens == 0 {
+		t.Fatal("no generation was started")
+	}
+
+	// every generation returns, and nothing else is left behind
+	deadline := time.Now().Add(2 * time.Second)
+	for (live.Load() > 0 || runtime.NumGoroutine() > before) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := live.Load(); n > 0 {
+		t.Errorf("%d of %d generations still running", n, gens)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("%d goroutines leaked", n-before)
+	}
+}
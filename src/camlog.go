@@ -0,0 +1,91 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// camLogger mirrors one camera's log lines into <config>/logs/<camera>.log
+// (Settings → Write per-camera log files). The main debug.log keeps getting
+// everything as before; this is only a filtered copy.
+type camLogger struct {
+	mu   sync.Mutex
+	path string
+	f    *os.File
+	l    *log.Logger
+}
+
+// logf logs a camera-scoped line ("[Name] ...") to the main log and, when
+// enabled, to the camera's own log file.
+func (w *CamWindow) logf(format string, args ...any) {
+	msg := fmt.Sprintf("[%s] "+format, append([]any{w.cfg.Name}, args...)...)
+	_ = log.Output(2, msg)
+	if globalConfig.PerCameraLogs {
+		w.clog.write(camLogPath(w.cfg), msg)
+	}
+}
+
+// camLogPath returns the per-camera log file path (named after the camera).
+func camLogPath(c CameraConfig) string {
+	name := c.Name
+	if name == "" {
+		name = c.ID
+	}
+	return filepath.Join(env.configDir, "logs", sanitizeFSComponent(name)+".log")
+}
+
+func (cl *camLogger) write(path, msg string) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	// (re)open lazily; a renamed camera switches to a new file
+	if cl.f == nil || cl.path != path {
+		cl.closeLocked()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return
+		}
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			log.Printf("camera log %s: %v", path, err)
+			return
+		}
+		cl.f, cl.path = f, path
+		cl.l = log.New(f, "", log.LstdFlags|log.Lshortfile)
+	}
+	_ = cl.l.Output(3, msg) // skip write+logf: report the caller of logf
+}
+
+func (cl *camLogger) close() {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	cl.closeLocked()
+}
+
+func (cl *camLogger) closeLocked() {
+	if cl.f != nil {
+		_ = cl.f.Close()
+	}
+	cl.f, cl.l, cl.path = nil, nil, ""
+}
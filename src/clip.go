@@ -0,0 +1,259 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	astiav "github.com/asticode/go-astiav"
+)
+
+/*
+Instant replay: a camera with clip_seconds > 0 keeps the last N seconds of
+its stream as compressed packets in memory (no decoding, nothing on disk),
+and "Save clip" (context menu or the C key) writes them to a file next to
+its recordings. The kept window always starts on a video keyframe so the
+clip plays from its first frame; it can run up to one keyframe interval
+longer than N. Audio is kept when the source is AAC.
+*/
+
+// clipMaxBytes caps one camera's replay buffer, whatever the bitrate.
+const clipMaxBytes = 256 << 20
+
+type clipPacket struct {
+	pkt *astiav.Packet
+	at  time.Time // arrival
+	key bool      // video keyframe
+}
+
+// packetRing is the replay buffer. Only the decode goroutine touches it.
+type packetRing struct {
+	keep  time.Duration
+	pkts  []clipPacket
+	bytes int
+}
+
+// push keeps a reference to pkt. Nothing is kept before the first keyframe.
+func (r *packetRing) push(pkt *astiav.Packet, key bool) {
+	if len(r.pkts) == 0 && !key {
+		return
+	}
+	c := pkt.Clone()
+	if c == nil {
+		return
+	}
+	r.pkts = append(r.pkts, clipPacket{pkt: c, at: time.Now(), key: key})
+	r.bytes += c.Size()
+	r.trim()
+}
+
+// trim drops whole keyframe intervals from the front: the buffer starts at
+// the newest keyframe that is at least keep old (or over the size cap, the
+// next keyframe).
+func (r *packetRing) trim() {
+	cutoff := time.Now().Add(-r.keep)
+	start := 0
+	kept, from := r.bytes, r.bytes // bytes from start / from i onward
+	for i, c := range r.pkts {
+		if i > 0 && c.key {
+			if c.at.After(cutoff) && kept <= clipMaxBytes {
+				break
+			}
+			start, kept = i, from
+		}
+		from -= c.pkt.Size()
+	}
+	if start == 0 {
+		return
+	}
+	for _, c := range r.pkts[:start] {
+		r.bytes -= c.pkt.Size()
+		c.pkt.Free()
+	}
+	n := copy(r.pkts, r.pkts[start:])
+	clear(r.pkts[n:])
+	r.pkts = r.pkts[:n]
+}
+
+// clone returns new references to everything buffered, for a clip writer.
+func (r *packetRing) clone() []*astiav.Packet {
+	out := make([]*astiav.Packet, 0, len(r.pkts))
+	for _, c := range r.pkts {
+		if p := c.pkt.Clone(); p != nil {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// reset frees the buffer (reconnect, decoder exit).
+func (r *packetRing) reset() {
+	for _, c := range r.pkts {
+		c.pkt.Free()
+	}
+	r.pkts, r.bytes = nil, 0
+}
+
+// clipStream is an input stream going into a clip: a copy of its codec
+// parameters, so the writer doesn't depend on the live demuxer.
+type clipStream struct {
+	index int
+	par   *astiav.CodecParameters
+	tb    astiav.Rational
+}
+
+// SaveClip asks the decoder to write out its replay buffer. Any goroutine.
+func (w *CamWindow) SaveClip() {
+	if w.cfg.ClipSeconds <= 0 {
+		w.logf("clip: instant replay is off for this camera (clip_seconds)")
+		return
+	}
+	if w.IsPaused() || w.connState() != connConnected {
+		w.logf("clip: not connected, nothing to save")
+		return
+	}
+	w.clipReq.Store(true)
+}
+
+// writeClipFrom hands the buffered packets of the streams at vIdx (and aIdx,
+// when >= 0) to a background clip writer. Decode goroutine only.
+func (w *CamWindow) writeClipFrom(ring *packetRing, fc *astiav.FormatContext, vIdx, aIdx int) {
+	pkts := ring.clone()
+	if len(pkts) == 0 {
+		w.logf("clip: nothing buffered yet")
+		return
+	}
+	var streams []clipStream
+	for _, i := range []int{vIdx, aIdx} {
+		if i < 0 {
+			continue
+		}
+		is := fc.Streams()[i]
+		par := astiav.AllocCodecParameters()
+		if err := is.CodecParameters().Copy(par); err != nil {
+			par.Free()
+			continue
+		}
+		streams = append(streams, clipStream{index: i, par: par, tb: is.TimeBase()})
+	}
+	muxer, ext := recordingFormat(w.cfg)
+	dir, err := recordingDir(w)
+	if err != nil {
+		w.logf("clip: %v", err)
+		freeClip(streams, pkts)
+		return
+	}
+	path := filepath.Join(dir, "clip_"+snapshotStamp(time.Now())+ext)
+	name := safeCamTitle(w.cfg)
+	go func() {
+		defer freeClip(streams, pkts)
+		if err := writeClip(path, muxer, streams, pkts); err != nil {
+			w.logf("clip: %v", err)
+			tray.notifyClip(name, "Saving the clip failed: "+err.Error())
+			return
+		}
+		w.logf("clip saved -> %s", path)
+		tray.notifyClip(name, "Clip saved: "+filepath.Base(path))
+	}()
+}
+
+func freeClip(streams []clipStream, pkts []*astiav.Packet) {
+	for _, s := range streams {
+		s.par.Free()
+	}
+	for _, p := range pkts {
+		p.Free()
+	}
+}
+
+// writeClip muxes pkts (starting with a video keyframe, stream indexes of
+// the input) into a new file at path, shifted so the clip starts at zero.
+func writeClip(path, muxer string, streams []clipStream, pkts []*astiav.Packet) error {
+	oc, err := astiav.AllocOutputFormatContext(nil, muxer, path)
+	if err != nil || oc == nil {
+		return fmt.Errorf("AllocOutputFormatContext: %w", err)
+	}
+	defer oc.Free()
+
+	out := map[int]*astiav.Stream{}
+	in := map[int]clipStream{}
+	for _, s := range streams {
+		os := oc.NewStream(nil)
+		if os == nil {
+			continue
+		}
+		if err := s.par.Copy(os.CodecParameters()); err != nil {
+			return fmt.Errorf("copy codec params: %w", err)
+		}
+		os.SetTimeBase(s.tb)
+		out[s.index], in[s.index] = os, s
+	}
+	if len(out) == 0 {
+		return errors.New("no streams")
+	}
+
+	pb, err := astiav.OpenIOContext(path, astiav.NewIOContextFlags(astiav.IOContextFlagWrite), nil, nil)
+	if err != nil {
+		return fmt.Errorf("OpenIOContext: %w", err)
+	}
+	defer pb.Free()
+	defer pb.Close()
+	oc.SetPb(pb)
+	if err := oc.WriteHeader(nil); err != nil {
+		return fmt.Errorf("WriteHeader: %w", err)
+	}
+
+	// the first packet is the video keyframe the clip starts on
+	first := pkts[0]
+	base, baseTB := first.Dts(), in[first.StreamIndex()].tb
+	if base == astiav.NoPtsValue {
+		base = first.Pts()
+	}
+	if base == astiav.NoPtsValue {
+		base = 0
+	}
+	for _, p := range pkts {
+		os, ok := out[p.StreamIndex()]
+		if !ok {
+			continue
+		}
+		tb := in[p.StreamIndex()].tb
+		off := astiav.RescaleQ(base, baseTB, tb)
+		if p.Pts() != astiav.NoPtsValue {
+			p.SetPts(p.Pts() - off)
+		}
+		if p.Dts() != astiav.NoPtsValue {
+			p.SetDts(p.Dts() - off)
+		}
+		p.RescaleTs(tb, os.TimeBase())
+		p.SetStreamIndex(os.Index())
+		if err := oc.WriteInterleavedFrame(p); err != nil && !errors.Is(err, astiav.ErrEagain) {
+			return fmt.Errorf("WriteInterleavedFrame: %w", err)
+		}
+	}
+	if err := oc.WriteTrailer(); err != nil {
+		return fmt.Errorf("WriteTrailer: %w", err)
+	}
+	return nil
+}
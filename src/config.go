@@ -21,11 +21,11 @@
 package main
 
 import (
-	"io"
 	"log"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"sync"
 
 	"gopkg.in/yaml.v2"
@@ -51,37 +51,119 @@ type AppConfig struct {
 	Cameras         []CameraConfig `yaml:"cameras"`
 	NoWindowsTitles bool           `yaml:"nowindowstitles,omitempty"`
 	SnapEnabled     bool           `yaml:"snap_enabled,omitempty"`      //enable/disable snapping+glue
+	SnapDistance    int            `yaml:"snap_distance,omitempty"`     // snap threshold in px; 0 = default (12)
+	GlueGroups      [][]string     `yaml:"glue_groups,omitempty"`       // camera IDs that always move together (context menu → Glue to neighbors)
 	AlwaysOnTopAll  bool           `yaml:"always_on_top_all,omitempty"` //all camera windows are always on top
 	ActiveOnTray    bool           `yaml:"activate_on_tray,omitempty"`
+	TrayClick       string         `yaml:"tray_click,omitempty"`  // with activate_on_tray: "raise" (default) or "toggle" (show/hide all windows)
+	HidePauses      bool           `yaml:"hide_pauses,omitempty"` // windows hidden by the tray toggle also stop decoding
 	ActiveOnWin     bool           `yaml:"activate_in_win,omitempty"`
+	WinClick        string         `yaml:"win_click,omitempty"`        // clicking a camera window: "" = nothing extra, "raise_all" or "raise_clicked"
+	CloseToTray     bool           `yaml:"close_to_tray,omitempty"`    // closing a camera window only hides it; decoding continues
+	NotifyConnLoss  bool           `yaml:"notify_conn_loss,omitempty"` // tray balloon when a camera disconnects/reconnects
+	EventsCSV       bool           `yaml:"events_csv,omitempty"`       // also append connection events to events.csv in the config dir
+	PreferXWayland  bool           `yaml:"prefer_xwayland,omitempty"`  // on a Wayland session run through XWayland so window positions apply (restart)
+	WaylandWarned   bool           `yaml:"wayland_warned,omitempty"`   // the native-Wayland positioning notice was shown
+	HideDockIcon    bool           `yaml:"hide_dock_icon,omitempty"`   // tray-only app: no Dock icon (macOS) / no taskbar entries (restart)
 	Formations      []Formation    `yaml:"formations,omitempty"`
 	LastFormation   string         `yaml:"last_formation,omitempty"`
 	// GUI refresh tuning
 	LimitGuiRefresh   bool `yaml:"limit_gui_refresh,omitempty"`    // cap GUI refresh interval
 	GuiRefreshMs      int  `yaml:"gui_refresh_ms,omitempty"`       // ms; used when LimitGuiRefresh=true
 	RepaintOnNewFrame bool `yaml:"repaint_on_new_frame,omitempty"` // only repaint when a new frame arrives
+	DefaultMaxFPS     int  `yaml:"default_max_fps,omitempty"`      // preview fps cap for cameras without their own max_fps; 0 = unlimited
+	DefaultMaxKbps    int  `yaml:"default_max_kbps,omitempty"`     // warn when a camera's input stays above this; 0 = off
+	DecodeThreads     int  `yaml:"decode_threads,omitempty"`       // video decoder threads for cameras without their own threads; 0 = FFmpeg default, -1 = CPU cores / enabled cameras
+	// preview memory
+	MaxDecodeRes string `yaml:"max_decode_res,omitempty"` // "WxH" cap for preview frames; larger streams are downscaled (recording is unaffected); "" = full resolution
+	// recordings
+	RecordNameTemplate string `yaml:"record_name_template,omitempty"` // file name without extension; {camera} {id} {date} {time} {seq}; default "{date}_{time}"
+	SnapshotQuality    int    `yaml:"snapshot_quality,omitempty"`     // JPEG quality 1..100 for snapshots; 0 = default (90)
+	// grid recording (tray → Record grid)
+	GridRecordSize string `yaml:"grid_record_size,omitempty"` // "WxH" of the composite video; default 1280x720
+	GridRecordFPS  int    `yaml:"grid_record_fps,omitempty"`  // composite frame rate; default 5
+	// reconnect watchdog
+	RestartAfterFailures int `yaml:"restart_after_failures,omitempty"` // full stream reset after N failed reconnects in a row; 0 = default (8), <0 = never
+	// logging
+	PerCameraLogs bool   `yaml:"per_camera_logs,omitempty"` // also write each camera's lines to logs/<camera>.log
+	LogMaxMB      int    `yaml:"log_max_mb,omitempty"`      // rotate debug.log when it reaches this size; 0 = default (10)
+	LogKeep       int    `yaml:"log_keep,omitempty"`        // rotated files kept (debug.log.1 …); 0 = default (5)
+	LogFormat     string `yaml:"log_format,omitempty"`      // "text" (default) or "json": one object per line with time, level, camera, source, msg
+	// audio
+	AudioDevice   string `yaml:"audio_device,omitempty"`    // output device id from listAudioOutputs; "" = system default (applies after restart)
+	AudioUnderrun string `yaml:"audio_underrun,omitempty"`  // "silence" (default): fill gaps with silence; "skip": wait for data
+	AudioBufferMs int    `yaml:"audio_buffer_ms,omitempty"` // output device buffer (latency); 0 = driver default (applies after restart)
+	// keyboard: action -> key sequence ("Space", "Ctrl+S", ...); see keys.go for actions and defaults
+	KeyBindings map[string]string `yaml:"key_bindings,omitempty"`
 	// overlays
-	HealthChip   bool `yaml:"health_chip,omitempty"` // show 0–5 health chip on each camera
-	ShowFPS      bool `yaml:"show_fps,omitempty"`
-	ShowBitrate  bool `yaml:"show_bitrate,omitempty"`
-	ShowDrops    bool `yaml:"show_drops,omitempty"`
-	ShowCPUUsage bool `yaml:"show_cpu,omitempty"` // overlay "CPU: xx%"
+	ActiveBorder      string `yaml:"active_border,omitempty"`       // outline the active camera: "frameless" (default), "always", "never"
+	ActiveBorderColor string `yaml:"active_border_color,omitempty"` // outline color, e.g. "#0096ff"
+	HealthChip        bool   `yaml:"health_chip,omitempty"`         // show 0–5 health chip on each camera
+	ShowFPS           bool   `yaml:"show_fps,omitempty"`
+	ShowBitrate       bool   `yaml:"show_bitrate,omitempty"`
+	ShowDrops         bool   `yaml:"show_drops,omitempty"`
+	ShowCPUUsage      bool   `yaml:"show_cpu,omitempty"`         // overlay "CPU: xx%"
+	ShowAudioMeter    bool   `yaml:"show_audio_meter,omitempty"` // audio level bar above the stats pill (also for muted cameras)
+	ShowStreamInfo    bool   `yaml:"show_stream_info,omitempty"` // overlay "H264 1920x1080 yuvj420p" of the decoded stream
+	// no-signal placeholder: a camera without any picture for a few seconds
+	NoSignalColor string `yaml:"no_signal_color,omitempty"` // background, e.g. "#303060"; "" = black
+	NoSignalText  string `yaml:"no_signal_text,omitempty"`  // e.g. "No Signal"; "" = none
+	NoSignalImage string `yaml:"no_signal_image,omitempty"` // image file drawn centered, scaled to fit; "" = none
+	// context menu → Open in external player
+	ExternalPlayer string `yaml:"external_player,omitempty"` // VLC/mpv/ffplay executable (macOS: also an .app); "" = system handler for the URL
+	// at app start and wake, camera n connects n×start_stagger_ms later; 0 = all at once
+	StartStaggerMs int `yaml:"start_stagger_ms,omitempty"`
+	// decode errors (cameras can override the first two)
+	DefaultErrDetect  string `yaml:"default_err_detect,omitempty"`        // "" = careful
+	DefaultErrConceal string `yaml:"default_error_concealment,omitempty"` // "" = FFmpeg default (guess_mvs+deblock)
+	HideCorruptFrames bool   `yaml:"hide_corrupt_frames,omitempty"`       // keep the last good frame instead of showing decode artifacts
+	// a dropped stream's last picture stays up, dimmed, this many seconds before going black; 0 = until it is back
+	StaleFrameSecs int `yaml:"stale_frame_timeout,omitempty"`
+	// closing the last open camera window quits the app instead of leaving it in the tray
+	QuitOnLastClose bool `yaml:"quit_on_last_close,omitempty"`
+	// tray warning when the recording disk has less than this many MB free; 0 = off
+	LowDiskMB   int  `yaml:"low_disk_mb,omitempty"`
+	LowDiskStop bool `yaml:"low_disk_stop,omitempty"` // also stop the recording
+	// system-wide key that hides/shows all camera windows (QKeySequence text, e.g. "Ctrl+Alt+H"); "" = none
+	PanicHideKey string `yaml:"panic_hide_key,omitempty"`
+	// formation rotation (tray → Formations → Start rotation): seconds each formation stays up; 0 = default (30)
+	FormationRotateSecs int `yaml:"formation_rotate_secs,omitempty"`
+	// login item (Windows Run key, macOS LaunchAgent, XDG autostart; autostart.go) and starting with the windows in the tray
+	StartAtLogin bool `yaml:"start_at_login,omitempty"`
+	StartHidden  bool `yaml:"start_hidden,omitempty"`
+	// tray → Snapshot all cameras also saves contact-sheet.jpg tiling every camera
+	SnapshotContactSheet bool `yaml:"snapshot_contact_sheet,omitempty"`
+	// smallest camera window (video area, px); 0 = default 200x140, never below 32
+	MinWindowW int `yaml:"min_window_w,omitempty"`
+	MinWindowH int `yaml:"min_window_h,omitempty"`
 }
 
 type CameraConfig struct {
-	ID          string `yaml:"id,omitempty"`       // camera uuid
-	Name        string `yaml:"name"`               // camera name
-	Disabled    bool   `yaml:"disabled,omitempty"` // if camera is disabled
-	URL         string `yaml:"url"`                // camera url, rtsp://...
-	RTSPTCP     bool   `yaml:"rtsp_tcp"`           // enable tcp for rtsp?
-	Caching     int    `yaml:"caching_ms"`         // network caching (ms)
-	X           int    `yaml:"x,omitempty"`        // camera window position X on screen
-	Y           int    `yaml:"y,omitempty"`        // camera window position Y on screen
-	Width       int    `yaml:"width"`              // camera window width
-	Height      int    `yaml:"height"`             // camera window height
-	AlwaysOnTop bool   `yaml:"always_on_top"`      // camera windows are always on top
-	Mute        bool   `yaml:"mute,omitempty"`     // mute camera
-	Stretch     bool   `yaml:"stretch,omitempty"`  // when true, fill the widget and allow stretching (no aspect lock)
+	ID               string `yaml:"id,omitempty"`                // camera uuid
+	Name             string `yaml:"name"`                        // camera name
+	Group            string `yaml:"group,omitempty"`             // optional tray submenu this camera is listed under
+	Disabled         bool   `yaml:"disabled,omitempty"`          // if camera is disabled
+	URL              string `yaml:"url"`                         // camera url, rtsp://...
+	SubURL           string `yaml:"sub_url,omitempty"`           // optional low-res substream for the windowed preview; url is used fullscreen
+	Username         string `yaml:"username,omitempty"`          // stream credentials, used instead of any user:pass@ in url
+	Password         string `yaml:"password,omitempty"`          // stored as plain text, like credentials in url
+	RTSPTCP          bool   `yaml:"rtsp_tcp"`                    // enable tcp for rtsp?
+	ReachCheck       bool   `yaml:"reach_check,omitempty"`       // quick TCP probe of host:port before opening the stream
+	Caching          int    `yaml:"caching_ms"`                  // network caching (ms), as VLC's: jitter buffer traded for latency; 0 = lowest latency
+	X                int    `yaml:"x,omitempty"`                 // camera window position X on screen
+	Y                int    `yaml:"y,omitempty"`                 // camera window position Y on screen
+	Width            int    `yaml:"width"`                       // camera window width
+	Height           int    `yaml:"height"`                      // camera window height
+	Fullscreen       bool   `yaml:"fullscreen,omitempty"`        // window was left fullscreen; restored on next start (x/y/width/height stay the windowed geometry)
+	FullscreenScreen string `yaml:"fullscreen_screen,omitempty"` // name of the monitor the camera was fullscreen on
+	AlwaysOnTop      bool   `yaml:"always_on_top"`               // camera windows are always on top
+	ClickThrough     bool   `yaml:"click_through,omitempty"`     // mouse input passes through to windows below (HUD overlay); off via the tray menu
+	Mute             bool   `yaml:"mute,omitempty"`              // mute camera
+	Stretch          bool   `yaml:"stretch,omitempty"`           // when true, fill the widget and allow stretching (no aspect lock)
+	Rotate           int    `yaml:"rotate,omitempty"`            // display rotation in degrees: 0, 90, 180, 270
+	FlipH            bool   `yaml:"flip_h,omitempty"`            // mirror the picture horizontally
+	FlipV            bool   `yaml:"flip_v,omitempty"`            // mirror the picture vertically
+	AspectRatio      string `yaml:"aspect_ratio,omitempty"`      // display aspect override: "auto" (default), "16:9", "4:3", ...
 
 	FFmpegParams string `yaml:"ffmpeg_params,omitempty"` // ffmpeg parameters
 
@@ -89,7 +171,58 @@ type CameraConfig struct {
 	Probesize int64  `yaml:"probesize,omitempty"`  // probesize param (bytes)
 	AnalyzeUS int64  `yaml:"analyze_us,omitempty"` // analyze (microseconds)
 	Threads   int    `yaml:"threads,omitempty"`    // threads count per stream, 0=auto
-	HwAccel   string `yaml:"hwaccel,omitempty"`    // "none","videotoolbox","vaapi","nvdec" (not wired here)
+	HwAccel   string `yaml:"hwaccel,omitempty"`    // "none","videotoolbox","vaapi","nvdec"; falls back to software if the device cannot be opened
+	// stall watchdog: seconds without a decoded frame before reconnecting; 0 = default (10s), -1 = disabled
+	StallTimeout int `yaml:"stall_timeout,omitempty"`
+	MaxFPS       int `yaml:"max_fps,omitempty"` // preview fps cap (decoded frames above it are dropped); 0 = global default
+
+	SnapshotEvery   int    `yaml:"snapshot_every,omitempty"`    // save a JPEG every N seconds into Snapshots/<camera>/; 0 = off
+	RecordContainer string `yaml:"record_container,omitempty"`  // "mp4" (default) or "mkv"
+	RecordAudioMode string `yaml:"record_audio_mode,omitempty"` // "aac" (default, re-encode) or "copy" (only when source is AAC)
+	RecordAudio     *bool  `yaml:"record_audio,omitempty"`      // unset = record unless muted; true = even while muted; false = video-only files (no encoder)
+	// digital zoom: named views recalled from the context menu (zoom.go)
+	ZoomPresets []ZoomPreset `yaml:"zoom_presets,omitempty"`
+	// ONVIF PTZ (ptz.go): overlay pad, arrow keys and camera presets
+	PTZ      bool   `yaml:"ptz,omitempty"`
+	ONVIFURL string `yaml:"onvif_url,omitempty"` // device service, e.g. http://host/onvif/device_service; username/password as for the stream
+	// continuous recording: on whenever the stream is connected, split into segments
+	AlwaysRecord     bool `yaml:"always_record,omitempty"`
+	RecordSegmentMin int  `yaml:"record_segment_min,omitempty"` // minutes per recording file, cut at a keyframe; 0 = 15 with always_record, else one file
+	// bitrate watch: expected max input rate in kbps; 0 = global default_max_kbps, -1 = off
+	MaxKbps int `yaml:"max_kbps,omitempty"`
+	// seconds to wait for the connection / a silent socket before giving up; 0 = default (5)
+	ConnectTimeout int `yaml:"connect_timeout,omitempty"`
+	// wait before connecting at app start / wake, after the global start_stagger_ms slot
+	StartDelayMs int `yaml:"start_delay_ms,omitempty"`
+	// decode errors; "" = the global default_err_detect / default_error_concealment
+	ErrDetect  string `yaml:"err_detect,omitempty"`        // ignore, crccheck, careful, compliant, aggressive
+	ErrConceal string `yaml:"error_concealment,omitempty"` // favor_inter, guess_mvs, deblock, off
+	// monitor (Qt screen name) fullscreen goes to; "" = the one the window is on. Leaving it restores the window where it was
+	FullscreenMonitor string `yaml:"fullscreen_monitor,omitempty"`
+	// instant replay: keep the last N seconds in memory for Save clip (clip.go); 0 = off
+	ClipSeconds int `yaml:"clip_seconds,omitempty"`
+	// rtsps:// only: accept any server certificate (self-signed cameras)
+	TLSInsecure bool `yaml:"tls_insecure,omitempty"`
+	// recording video: "copy" (default) keeps the camera's own stream; "h264" re-encodes it (smaller files, costs CPU)
+	RecordVideoMode string `yaml:"record_video_mode,omitempty"`
+	RecordCRF       int    `yaml:"record_crf,omitempty"`  // h264 quality 1..51, lower is better; 0 = default (23); used when record_kbps is 0
+	RecordKbps      int    `yaml:"record_kbps,omitempty"` // h264 target bitrate; 0 = constant quality (record_crf)
+	RecordSize      string `yaml:"record_size,omitempty"` // "WxH" cap for h264 recordings, aspect kept; "" = source size
+	// overlays for this camera only (context menu → Overlays); unset = the global setting of the same name
+	HealthChip     *bool `yaml:"health_chip,omitempty"`
+	ShowFPS        *bool `yaml:"show_fps,omitempty"`
+	ShowBitrate    *bool `yaml:"show_bitrate,omitempty"`
+	ShowDrops      *bool `yaml:"show_drops,omitempty"`
+	ShowCPUUsage   *bool `yaml:"show_cpu,omitempty"`
+	ShowAudioMeter *bool `yaml:"show_audio_meter,omitempty"`
+	ShowStreamInfo *bool `yaml:"show_stream_info,omitempty"`
+	// letterbox in fullscreen even when stretch is on (stretch then only applies to the window)
+	FullscreenKeepAspect bool `yaml:"fullscreen_keep_aspect,omitempty"`
+	// which video stream of the session to show (index as logged at connect); -1 = auto (the first).
+	// An index that isn't a video stream falls back to auto, so 0 behaves like auto unless stream #0 is video
+	VideoStreamIndex int `yaml:"video_stream_index,omitempty"`
+	// audio playback apart from mute (which silences both); see also record_audio
+	MutePlayback bool `yaml:"mute_playback,omitempty"` // silence the speakers only; recordings keep the audio
 }
 
 func initlog() {
@@ -104,19 +237,20 @@ func initlog() {
 		os.MkdirAll(dir, 0755)
 	}
 
-	// Open the log file
-	file, err := os.OpenFile(filepath.Join(dir, "debug.log"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+	// Open the log file (rotated by size, see logfile.go)
+	rl, err := openRotatingLog(filepath.Join(dir, "debug.log"))
 	if err != nil {
 		log.Fatal(err)
 		return
 	}
+	appLog = rl
 	// we always write to log file; if DEBUG=true we write to stdout too)
 	if debugging == "true" {
 		DEBUG = true
-		log.SetOutput(io.MultiWriter(file, os.Stdout))
-	} else {
-		log.SetOutput(io.MultiWriter(file))
+		appLogLevel.Store(int32(logDebug))
+		rl.setStdout(true)
 	}
+	log.SetOutput(rl)
 	log.SetFlags(log.LstdFlags | log.Lshortfile)
 }
 
@@ -177,7 +311,6 @@ func ensureCameraIDs(cs []CameraConfig) {
 // Returns error if writing to disk fails (update in memory still occurs).
 func UpdateCameraGeometry(key string, x, y, w, h int) error {
 	configMu.Lock()
-	defer configMu.Unlock()
 
 	// Update in-memory config
 	idx := -1
@@ -195,29 +328,28 @@ func UpdateCameraGeometry(key string, x, y, w, h int) error {
 		c.Width = w
 		c.Height = h
 	}
+	configMu.Unlock()
+	return SaveConfig() // the same single writer as every other save
+}
 
-	// atomic write: write to tmp then rename
-	tmp := env.settingsFile + ".tmp"
-	f, err := os.Create(tmp)
-	if err != nil {
-		return err
-	}
-	enc := yaml.NewEncoder(f)
-
-	if err := enc.Encode(&globalConfig); err != nil {
-		_ = f.Close()
-		_ = os.Remove(tmp)
-		return err
-	}
-	if err := f.Close(); err != nil {
-		_ = os.Remove(tmp)
-		return err
+// UpdateCamera applies fn to the camera matching key (ID, else Name or URL)
+// in globalConfig and saves the config. Missing cameras are ignored.
+func UpdateCamera(key string, fn func(c *CameraConfig)) error {
+	configMu.Lock()
+	found := false
+	for i := range globalConfig.Cameras {
+		c := &globalConfig.Cameras[i]
+		if (c.ID != "" && c.ID == key) || (c.ID == "" && c.Name == key) || (key == c.URL) {
+			fn(c)
+			found = true
+			break
+		}
 	}
-	if err := os.Rename(tmp, env.settingsFile); err != nil {
-		_ = os.Remove(tmp)
-		return err
+	configMu.Unlock()
+	if !found {
+		return nil
 	}
-	return nil
+	return SaveConfig()
 }
 
 // load app configuration
@@ -230,13 +362,71 @@ func loadConfig(path string) (AppConfig, error) {
 	if err := yaml.Unmarshal(b, &cfg); err != nil {
 		return cfg, err
 	}
+	// activate_in_win (bool) is legacy: migrate it to win_click
+	if cfg.ActiveOnWin {
+		if cfg.WinClick == "" {
+			cfg.WinClick = winClickRaiseAll
+		}
+		cfg.ActiveOnWin = false
+	}
+	// record_audio_mode: none (older builds) is record_audio: false
+	for i := range cfg.Cameras {
+		if c := &cfg.Cameras[i]; strings.EqualFold(c.RecordAudioMode, "none") {
+			off := false
+			c.RecordAudio, c.RecordAudioMode = &off, ""
+		}
+	}
 	return cfg, nil
 }
 
-// save app configuration
+// save app configuration: hands the write to configWriter and waits for it.
+// Everything changed in globalConfig before the call is on disk when it
+// returns nil.
 func SaveConfig() error {
+	configWriterOnce.Do(func() { go configWriter() })
+	done := make(chan error, 1)
+	saveReqs <- done
+	return <-done
+}
+
+var (
+	saveReqs         = make(chan chan error)
+	configWriterOnce sync.Once
+)
+
+// configWriter is the only goroutine that writes settings.yml, so saves
+// from the tray, dialogs and the geometry saver can't interleave. Requests
+// that queue up during a write are all answered by the next one, which
+// encodes the config as it is by then; a burst of geometry saves costs one
+// write, and a save can never put back older state over a newer edit.
+func configWriter() {
+	for first := range saveReqs {
+		waiting := []chan error{first}
+	drain:
+		for {
+			select {
+			case done := <-saveReqs:
+				waiting = append(waiting, done)
+			default:
+				break drain
+			}
+		}
+		err := writeConfigFile()
+		for _, done := range waiting {
+			done <- err
+		}
+	}
+}
+
+// writeConfigFile snapshots globalConfig and replaces settings.yml with it
+// atomically: write and fsync a temp file, then rename it over the old one.
+func writeConfigFile() error {
 	configMu.Lock()
-	defer configMu.Unlock()
+	b, err := yaml.Marshal(&globalConfig)
+	configMu.Unlock()
+	if err != nil {
+		return err
+	}
 
 	log.Printf("Saving config to %s\n", env.settingsFile)
 
@@ -245,9 +435,12 @@ func SaveConfig() error {
 	if err != nil {
 		return err
 	}
-	enc := yaml.NewEncoder(f)
-
-	if err := enc.Encode(&globalConfig); err != nil {
+	if _, err := f.Write(b); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil { // contents on disk before the rename
 		_ = f.Close()
 		_ = os.Remove(tmp)
 		return err
@@ -256,5 +449,9 @@ func SaveConfig() error {
 		_ = os.Remove(tmp)
 		return err
 	}
-	return os.Rename(tmp, env.settingsFile)
+	if err := os.Rename(tmp, env.settingsFile); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return nil
 }
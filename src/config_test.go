@@ -0,0 +1,135 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// Geometry saves, menu toggles and plain saves race each other in the app
+// (saveTimer, tray, dialogs). Run with -race: every edit must end up in the
+// file, and the file must always be a complete config.
+func TestConcurrentConfigEdits(t *testing.T) {
+	oldEnv, oldCfg := env, globalConfig
+	t.Cleanup(func() {
+		configMu.Lock()
+		env, globalConfig = oldEnv, oldCfg
+		configMu.Unlock()
+	})
+
+	const cams, edits = 4, 25
+	configMu.Lock()
+	env.settingsFile = filepath.Join(t.TempDir(), "settings.yml")
+	globalConfig = AppConfig{}
+	for i := 0; i < cams; i++ {
+		globalConfig.Cameras = append(globalConfig.Cameras, CameraConfig{
+			ID:   fmt.Sprintf("cam%d", i),
+			Name: fmt.Sprintf("Camera %d", i),
+			URL:  fmt.Sprintf("rtsp://10.0.0.%d/stream", i+1),
+		})
+	}
+	configMu.Unlock()
+
+	errs := make(chan error, cams*edits*3)
+	var wg sync.WaitGroup
+	for i := 0; i < cams; i++ {
+		id := fmt.Sprintf("cam%d", i)
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			for j := 1; j <= edits; j++ {
+				if err := UpdateCameraGeometry(id, j, j*2, 320+j, 240+j); err != nil {
+					errs <- err
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 1; j <= edits; j++ {
+				if err := UpdateCamera(id, func(c *CameraConfig) { c.SnapshotEvery = j }); err != nil {
+					errs <- err
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < edits; j++ {
+				if err := SaveConfig(); err != nil {
+					errs <- err
+				}
+				// the file on disk must parse at any time
+				if _, err := loadConfig(env.settingsFile); err != nil && !os.IsNotExist(err) {
+					errs <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	cfg, err := loadConfig(env.settingsFile)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if len(cfg.Cameras) != cams {
+		t.Fatalf("reloaded %d cameras, want %d", len(cfg.Cameras), cams)
+	}
+	for _, c := range cfg.Cameras {
+		if c.X != edits || c.Y != edits*2 || c.Width != 320+edits || c.Height != 240+edits {
+			t.Errorf("%s: geometry %d,%d %dx%d, want the last edit", c.ID, c.X, c.Y, c.Width, c.Height)
+		}
+		if c.SnapshotEvery != edits {
+			t.Errorf("%s: snapshot_every %d, want %d", c.ID, c.SnapshotEvery, edits)
+		}
+	}
+	if _, err := os.Stat(env.settingsFile + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temp file left behind: %v", err)
+	}
+}
+
+// record_audio_mode: none from older builds loads as record_audio: false.
+func TestLoadLegacyRecordAudioNone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.yml")
+	yml := "cameras:\n" +
+		"  - name: a\n    record_audio_mode: none\n" +
+		"  - name: b\n    record_audio_mode: copy\n"
+	if err := os.WriteFile(path, []byte(yml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := cfg.Cameras[0], cfg.Cameras[1]
+	if a.RecordAudio == nil || *a.RecordAudio || a.RecordAudioMode != "" {
+		t.Errorf("none: record_audio=%v mode=%q, want false and unset", a.RecordAudio, a.RecordAudioMode)
+	}
+	if b.RecordAudio != nil || b.RecordAudioMode != "copy" {
+		t.Errorf("copy: record_audio=%v mode=%q, want unset and copy", b.RecordAudio, b.RecordAudioMode)
+	}
+}
@@ -0,0 +1,221 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mappu/miqt/qt"
+	"gopkg.in/yaml.v2"
+)
+
+/*
+Settings export/import (tray → Settings → Export settings… / Import settings…)
+*/
+
+const settingsFilter = "Settings (*.yml *.yaml);;All files (*)"
+
+// exportConfigInteractive writes the current configuration to a user-chosen file.
+func exportConfigInteractive() {
+	def := filepath.Join(env.homeDir, appName+"-settings.yml")
+	path := qt.QFileDialog_GetSaveFileName4(nil, "Export settings", def, settingsFilter)
+	if path == "" {
+		return
+	}
+	configMu.Lock()
+	b, err := yaml.Marshal(&globalConfig)
+	configMu.Unlock()
+	if err == nil {
+		err = os.WriteFile(path, b, 0o644)
+	}
+	if err != nil {
+		log.Printf("export settings: %v", err)
+		qt.QMessageBox_Critical(nil, "Export settings", fmt.Sprintf("Failed to export settings:\n%v", err))
+		return
+	}
+	log.Printf("Settings exported to %s", path)
+}
+
+// importConfigInteractive reads a settings file, lets the user merge it into
+// or replace the current configuration, backs up the old file and rebuilds
+// all camera windows and the tray. Camera IDs are kept so formations still match.
+func importConfigInteractive() {
+	path := qt.QFileDialog_GetOpenFileName4(nil, "Import settings", env.homeDir, settingsFilter)
+	if path == "" {
+		return
+	}
+	in, err := readImportedConfig(path)
+	if err != nil {
+		log.Printf("import settings %s: %v", path, err)
+		qt.QMessageBox_Critical(nil, "Import settings", fmt.Sprintf("Cannot import %s:\n%v", path, err))
+		return
+	}
+
+	mb := qt.NewQMessageBox(nil)
+	mb.SetWindowTitle("Import settings")
+	mb.SetIcon(qt.QMessageBox__Question)
+	mb.SetText(fmt.Sprintf("%s contains %d camera(s) and %d formation(s).\n\n"+
+		"Merge: add them to your setup (same camera ID or formation name is overwritten).\n"+
+		"Replace: use the imported settings as they are.", filepath.Base(path), len(in.Cameras), len(in.Formations)))
+	mergeBtn := mb.AddButton2("Merge", qt.QMessageBox__AcceptRole)
+	replaceBtn := mb.AddButton2("Replace", qt.QMessageBox__DestructiveRole)
+	mb.AddButtonWithButton(qt.QMessageBox__Cancel)
+	mb.Exec()
+
+	clicked := mb.ClickedButton()
+	var next AppConfig
+	switch {
+	case clicked != nil && clicked.UnsafePointer() == mergeBtn.QAbstractButton.UnsafePointer():
+		configMu.Lock()
+		next = mergeConfigs(globalConfig, in)
+		configMu.Unlock()
+	case clicked != nil && clicked.UnsafePointer() == replaceBtn.QAbstractButton.UnsafePointer():
+		next = in
+	default:
+		return
+	}
+
+	if bak, err := backupSettingsFile(); err != nil {
+		log.Printf("import settings: backup failed: %v", err)
+		qt.QMessageBox_Critical(nil, "Import settings", fmt.Sprintf("Could not back up the current settings, nothing was changed:\n%v", err))
+		return
+	} else if bak != "" {
+		log.Printf("Previous settings backed up to %s", bak)
+	}
+	applyImportedConfig(next)
+	log.Printf("Settings imported from %s (%d cameras)", path, len(next.Cameras))
+}
+
+// readImportedConfig parses and sanity-checks a settings file.
+func readImportedConfig(path string) (AppConfig, error) {
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return cfg, err
+	}
+	if len(cfg.Cameras) == 0 && len(cfg.Formations) == 0 {
+		return cfg, errors.New("no cameras or formations found")
+	}
+	seen := map[string]bool{}
+	for i, c := range cfg.Cameras {
+		if c.URL == "" {
+			return cfg, fmt.Errorf("camera #%d (%q) has no URL", i+1, c.Name)
+		}
+		if c.ID != "" {
+			if seen[c.ID] {
+				return cfg, fmt.Errorf("duplicate camera id %q", c.ID)
+			}
+			seen[c.ID] = true
+		}
+	}
+	return cfg, nil
+}
+
+// mergeConfigs keeps cur's global options and merges in's cameras (by ID)
+// and formations (by name); matching entries are replaced, others appended.
+func mergeConfigs(cur, in AppConfig) AppConfig {
+	out := cur
+	out.Cameras = append([]CameraConfig(nil), cur.Cameras...)
+	for _, c := range in.Cameras {
+		if i := findCameraIndexByID(&out, c.ID); c.ID != "" && i >= 0 {
+			out.Cameras[i] = c
+			continue
+		}
+		out.Cameras = append(out.Cameras, c)
+	}
+	out.Formations = append([]Formation(nil), cur.Formations...)
+	for _, f := range in.Formations {
+		replaced := false
+		for i := range out.Formations {
+			if out.Formations[i].Name == f.Name {
+				out.Formations[i] = f
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			out.Formations = append(out.Formations, f)
+		}
+	}
+	return out
+}
+
+// backupSettingsFile copies settings.yml next to itself with a timestamp suffix.
+// Returns "" when there is nothing to back up yet.
+func backupSettingsFile() (string, error) {
+	b, err := os.ReadFile(env.settingsFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	bak := env.settingsFile + ".bak-" + time.Now().Format("20060102-150405")
+	return bak, os.WriteFile(bak, b, 0o644)
+}
+
+// applyImportedConfig swaps in a new configuration at runtime: closes all
+// camera windows, persists the config, reopens enabled cameras and rebuilds the tray.
+func applyImportedConfig(cfg AppConfig) {
+	for i, w := range wins {
+		if w == nil {
+			continue
+		}
+		w.SuppressOnClosedOnce()
+		w.Close()
+		wins[i] = nil
+	}
+
+	configMu.Lock()
+	globalConfig = cfg
+	ensureCameraIDs(globalConfig.Cameras) // only fills missing ones
+	configMu.Unlock()
+	if err := SaveConfig(); err != nil {
+		log.Printf("import settings: save: %v", err)
+	}
+
+	wins = make([]*CamWindow, len(globalConfig.Cameras))
+	for i := range globalConfig.Cameras {
+		if globalConfig.Cameras[i].Disabled {
+			continue
+		}
+		w, err := newCamWindow(globalConfig.Cameras[i], i)
+		if err != nil {
+			log.Printf("open cam %q: %v", safeCamTitle(globalConfig.Cameras[i]), err)
+			globalConfig.Cameras[i].Disabled = true
+			continue
+		}
+		wins[i] = w
+	}
+
+	if tray != nil {
+		tray.rebuild()
+		for i, w := range wins {
+			if w != nil {
+				tray.AttachWindowHooks(i, w)
+			}
+		}
+	}
+}
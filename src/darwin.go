@@ -24,15 +24,23 @@
 package main
 
 import (
+	"html"
 	"log"
+	"os"
+	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/prashantgupta24/mac-sleep-notifier/notifier"
 )
 
 /*
+#cgo LDFLAGS: -lobjc
 #include <stdint.h>
 #include <stdio.h>
+#include <mach/mach.h>
+#include <objc/runtime.h>
+#include <objc/message.h>
 
 #ifdef __cplusplus
 #include <csignal>
@@ -50,6 +58,28 @@ void Ignore(int sigNum) {
     sigaction(sigNum, &sa, NULL);
 }
 
+uint64_t residentBytes(void);
+
+uint64_t residentBytes(void) {
+    struct mach_task_basic_info info;
+    mach_msg_type_number_t count = MACH_TASK_BASIC_INFO_COUNT;
+    if (task_info(mach_task_self(), MACH_TASK_BASIC_INFO, (task_info_t)&info, &count) != KERN_SUCCESS) {
+        return 0;
+    }
+    return info.resident_size;
+}
+
+void setAccessoryPolicy(void);
+
+// [NSApp setActivationPolicy:NSApplicationActivationPolicyAccessory]
+void setAccessoryPolicy(void) {
+    id app = ((id (*)(id, SEL))objc_msgSend)((id)objc_getClass("NSApplication"), sel_registerName("sharedApplication"));
+    if (app == NULL) {
+        return;
+    }
+    ((signed char (*)(id, SEL, long))objc_msgSend)(app, sel_registerName("setActivationPolicy:"), 1);
+}
+
 */
 import "C"
 
@@ -61,6 +91,12 @@ func IgnoreSignum() {
 	Ignore(syscall.SIGURG)
 }
 
+// hideDockIcon turns the app into an accessory (agent) app: no Dock icon and
+// no app menu, the tray icon stays. Needs the QApplication (NSApp) to exist.
+func hideDockIcon() {
+	C.setAccessoryPolicy()
+}
+
 func HandleSleep(wins []*CamWindow) {
 	notifierCh := notifier.GetInstance().Start()
 	for {
@@ -68,9 +104,12 @@ func HandleSleep(wins []*CamWindow) {
 		case activity := <-notifierCh:
 			if activity.Type == notifier.Awake {
 				log.Println("machine awake")
+				slot := 0
 				for _, w := range wins {
 					if w != nil && !w.cfg.Disabled {
-						CallOnQtMain(w.OnResumeFromSleep)
+						n := slot
+						CallOnQtMain(func() { w.OnResumeFromSleep(n) })
+						slot++
 					}
 				}
 			} else {
@@ -81,3 +120,70 @@ func HandleSleep(wins []*CamWindow) {
 		}
 	}
 }
+
+// processCPUTime returns user+system CPU time consumed by the whole process.
+func processCPUTime() time.Duration {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0
+	}
+	return time.Duration(ru.Utime.Nano() + ru.Stime.Nano())
+}
+
+// processRSS returns the resident set size in bytes (Mach task info).
+func processRSS() uint64 {
+	return uint64(C.residentBytes())
+}
+
+// diskFree returns the bytes available to this user on the volume holding path.
+func diskFree(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return st.Bavail * uint64(st.Bsize), nil
+}
+
+// launchAgentLabel names the LaunchAgent; the app bundle's identifier.
+const launchAgentLabel = "net.e1z0.anotherrtsp"
+
+// setAutostart adds or removes the LaunchAgent
+// (~/Library/LaunchAgents/net.e1z0.anotherrtsp.plist) that starts the app at login.
+func setAutostart(on bool) error {
+	path := filepath.Join(env.homeDir, "Library", "LaunchAgents", launchAgentLabel+".plist")
+	if !on {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	exe, err := appExecutable()
+	if err != nil {
+		return err
+	}
+	plist := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>` + launchAgentLabel + `</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>` + html.EscapeString(exe) + `</string>
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+</dict>
+</plist>
+`
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(plist), 0644)
+}
+
+// listAudioOutputs: the Oto backend always plays through the system default
+// output here, so there is nothing to choose from.
+func listAudioOutputs() []audioOutput { return nil }
+
+func selectAudioOutput(id string) {}
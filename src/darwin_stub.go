@@ -24,8 +24,15 @@
 package main
 
 import (
+	"bufio"
 	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 )
 
 func Ignore(sigNum syscall.Signal) {
@@ -36,6 +43,129 @@ func IgnoreSignum() {
 
 }
 
+// hideDockIcon: nothing to do here, the camera windows leave the taskbar
+// through their window type instead (see taskbarHidden).
+func hideDockIcon() {}
+
 func HandleSleep(wins []*CamWindow) {
 	log.Printf("Dummy handle sleep function loaded...")
 }
+
+// processCPUTime returns user+system CPU time consumed by the whole process.
+func processCPUTime() time.Duration {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0
+	}
+	return time.Duration(ru.Utime.Nano() + ru.Stime.Nano())
+}
+
+// processRSS returns the resident set size in bytes. Uses /proc when present
+// (Linux), otherwise falls back to the peak RSS from getrusage.
+func processRSS() uint64 {
+	if b, err := os.ReadFile("/proc/self/statm"); err == nil {
+		if f := strings.Fields(string(b)); len(f) > 1 {
+			if pages, err := strconv.ParseUint(f[1], 10, 64); err == nil {
+				return pages * uint64(os.Getpagesize())
+			}
+		}
+	}
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return 0
+	}
+	return uint64(ru.Maxrss) * 1024 // KiB on Linux/BSD
+}
+
+// diskFree returns the bytes available to this user on the volume holding path.
+func diskFree(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil
+}
+
+// setAutostart adds or removes the XDG autostart entry
+// (~/.config/autostart/another-rtsp.desktop) that starts the app at login.
+func setAutostart(on bool) error {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		dir = filepath.Join(env.homeDir, ".config")
+	}
+	path := filepath.Join(dir, "autostart", appName+".desktop")
+	if !on {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	exe, err := appExecutable()
+	if err != nil {
+		return err
+	}
+	// Exec: quoted, with the characters the spec reserves inside quotes escaped
+	quoted := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", "$", `\$`).Replace(exe)
+	entry := "[Desktop Entry]\n" +
+		"Type=Application\n" +
+		"Name=QAnotherRTSP\n" +
+		"Exec=\"" + quoted + "\"\n" +
+		"Terminal=false\n" +
+		"X-GNOME-Autostart-enabled=true\n"
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(entry), 0644)
+}
+
+// listAudioOutputs enumerates playback devices: PulseAudio/PipeWire sinks
+// (what ALSA's "default" goes through on desktops), else the bare ALSA cards.
+// With a sound server running ALSA_CARD is ignored, so cards aren't offered.
+func listAudioOutputs() []audioOutput {
+	var outs []audioOutput
+	if b, err := exec.Command("pactl", "list", "sinks").Output(); err == nil {
+		var name string
+		sc := bufio.NewScanner(strings.NewReader(string(b)))
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			switch {
+			case strings.HasPrefix(line, "Name: "):
+				name = strings.TrimPrefix(line, "Name: ")
+			case strings.HasPrefix(line, "Description: ") && name != "":
+				outs = append(outs, audioOutput{ID: "pulse:" + name, Name: strings.TrimPrefix(line, "Description: ")})
+				name = ""
+			}
+		}
+	}
+	if len(outs) > 0 {
+		return outs
+	}
+	// " 0 [PCH            ]: HDA-Intel - HDA Intel PCH"
+	if b, err := os.ReadFile("/proc/asound/cards"); err == nil {
+		for _, line := range strings.Split(string(b), "\n") {
+			open, closing := strings.Index(line, "["), strings.Index(line, "]")
+			if open < 0 || closing < open {
+				continue
+			}
+			id := strings.TrimSpace(line[open+1 : closing])
+			desc := line[closing+1:]
+			if i := strings.Index(desc, " - "); i >= 0 {
+				desc = desc[i+3:]
+			}
+			outs = append(outs, audioOutput{ID: "alsa:" + id, Name: strings.TrimSpace(desc) + " (ALSA)"})
+		}
+	}
+	return outs
+}
+
+// selectAudioOutput routes the audio context to a device from listAudioOutputs.
+// Must run before InitGlobalAudio: the ALSA/Pulse client reads these at open,
+// so a new choice applies after a restart.
+func selectAudioOutput(id string) {
+	switch {
+	case strings.HasPrefix(id, "pulse:"):
+		_ = os.Setenv("PULSE_SINK", strings.TrimPrefix(id, "pulse:"))
+	case strings.HasPrefix(id, "alsa:"):
+		_ = os.Setenv("ALSA_CARD", strings.TrimPrefix(id, "alsa:"))
+	}
+}
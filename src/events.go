@@ -0,0 +1,251 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mappu/miqt/qt"
+)
+
+/*
+Connection event history: every camera's connect/disconnect/reconnect is
+kept in a ring buffer (tray → Options → Events…), and optionally appended to
+events.csv in the config directory so it survives restarts.
+*/
+
+const maxConnEvents = 1000
+
+// connNotifyThrottle: a flapping camera raises at most one "connection lost"
+// balloon (and its "restored") per this interval; the event log keeps all.
+const connNotifyThrottle = 5 * time.Minute
+
+type connEvent struct {
+	At     time.Time
+	Camera string
+	Event  string // connected, disconnected, reconnected, unreachable, unsupported codec
+	Detail string
+}
+
+var connEvents struct {
+	mu   sync.Mutex
+	list []connEvent // oldest first, at most maxConnEvents
+}
+
+// recordConnEvent adds an event to the history (and the CSV when enabled).
+// Safe from any goroutine.
+func recordConnEvent(camera, event, detail string) {
+	e := connEvent{At: time.Now(), Camera: camera, Event: event, Detail: detail}
+	connEvents.mu.Lock()
+	connEvents.list = append(connEvents.list, e)
+	if n := len(connEvents.list); n > maxConnEvents {
+		connEvents.list = append(connEvents.list[:0:0], connEvents.list[n-maxConnEvents:]...)
+	}
+	connEvents.mu.Unlock()
+
+	if globalConfig.EventsCSV {
+		if err := appendEventCSV(e); err != nil {
+			log.Printf("events.csv: %v", err)
+		}
+	}
+}
+
+// connEventsSnapshot returns a copy of the history, oldest first.
+func connEventsSnapshot() []connEvent {
+	connEvents.mu.Lock()
+	defer connEvents.mu.Unlock()
+	return append([]connEvent(nil), connEvents.list...)
+}
+
+func clearConnEvents() {
+	connEvents.mu.Lock()
+	connEvents.list = nil
+	connEvents.mu.Unlock()
+}
+
+func eventsCSVPath() string { return filepath.Join(env.configDir, "events.csv") }
+
+// appendEventCSV writes one row, with a header when the file is new.
+func appendEventCSV(e connEvent) error {
+	f, err := os.OpenFile(eventsCSVPath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	cw := csv.NewWriter(f)
+	if st, err := f.Stat(); err == nil && st.Size() == 0 {
+		_ = cw.Write([]string{"time", "camera", "event", "detail"})
+	}
+	_ = cw.Write([]string{e.At.Format(time.RFC3339), e.Camera, e.Event, e.Detail})
+	cw.Flush()
+	return cw.Error()
+}
+
+// dropSummary counts disconnects per camera within the last d, busiest
+// first: "Cam3 8×, Cam1 1×".
+func dropSummary(list []connEvent, d time.Duration) string {
+	since := time.Now().Add(-d)
+	counts := map[string]int{}
+	for _, e := range list {
+		if e.At.After(since) && e.Event == "disconnected" {
+			counts[e.Camera]++
+		}
+	}
+	names := make([]string, 0, len(counts))
+	for n := range counts {
+		names = append(names, n)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, len(names))
+	for i, n := range names {
+		parts[i] = fmt.Sprintf("%s %d×", n, counts[n])
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+var eventsDlg *qt.QDialog
+
+// ShowEventsDialog opens (or raises) the connection events window. It
+// refreshes itself while open. Main thread only.
+func ShowEventsDialog() {
+	if eventsDlg != nil {
+		eventsDlg.Raise()
+		eventsDlg.ActivateWindow()
+		return
+	}
+	d := qt.NewQDialog(nil)
+	d.SetWindowTitle("Connection Events")
+	d.SetAttribute2(qt.WA_DeleteOnClose, true)
+	d.Resize(640, 420)
+	eventsDlg = d
+
+	camCb := qt.NewQComboBox(nil)
+	summary := qt.NewQLabel(nil)
+	summary.SetWordWrap(true)
+
+	table := qt.NewQTableWidget(nil)
+	table.SetColumnCount(4)
+	table.SetHorizontalHeaderLabels([]string{"Time", "Camera", "Event", "Detail"})
+	table.SetEditTriggers(qt.QAbstractItemView__NoEditTriggers)
+	table.SetSelectionBehavior(qt.QAbstractItemView__SelectRows)
+	table.VerticalHeader().Hide()
+	table.HorizontalHeader().SetStretchLastSection(true)
+
+	shown := -1 // events in the table, to skip no-op refreshes
+	refresh := func(force bool) {
+		list := connEventsSnapshot()
+		if !force && len(list) == shown {
+			return
+		}
+		shown = len(list)
+		summary.SetText("Disconnects in the last 24 h: " + dropSummary(list, 24*time.Hour))
+
+		// camera filter: keep the choice, offer every camera seen
+		cur := camCb.CurrentText()
+		seen := map[string]bool{}
+		camCb.BlockSignals(true)
+		camCb.Clear()
+		camCb.AddItem("All cameras")
+		for _, e := range list {
+			if !seen[e.Camera] {
+				seen[e.Camera] = true
+				camCb.AddItem(e.Camera)
+			}
+		}
+		if i := camCb.FindText(cur); i >= 0 {
+			camCb.SetCurrentIndex(i)
+		}
+		camCb.BlockSignals(false)
+		filter := ""
+		if camCb.CurrentIndex() > 0 {
+			filter = camCb.CurrentText()
+		}
+
+		table.SetRowCount(0)
+		for i := len(list) - 1; i >= 0; i-- { // newest first
+			e := list[i]
+			if filter != "" && e.Camera != filter {
+				continue
+			}
+			row := table.RowCount()
+			table.InsertRow(row)
+			for col, txt := range []string{e.At.Format("2006-01-02 15:04:05"), e.Camera, e.Event, e.Detail} {
+				table.SetItem(row, col, qt.NewQTableWidgetItem2(txt))
+			}
+		}
+		table.ResizeColumnsToContents()
+		table.HorizontalHeader().SetStretchLastSection(true)
+	}
+	camCb.OnCurrentIndexChanged(func(int) { refresh(true) })
+
+	btnClear := qt.NewQPushButton3("Clear")
+	btnClear.OnClicked(func() {
+		clearConnEvents()
+		refresh(true)
+	})
+	btnCSV := qt.NewQPushButton3("Open CSV")
+	btnCSV.SetToolTip("Enable \"Log connection events to events.csv\" in Settings to keep a file across restarts")
+	btnCSV.SetEnabled(globalConfig.EventsCSV)
+	btnCSV.OnClicked(func() { openFileOrDir(eventsCSVPath()) })
+	btnClose := qt.NewQPushButton3("Close")
+	btnClose.OnClicked(func() { d.Close() })
+
+	top := qt.NewQHBoxLayout(nil)
+	top.AddWidget(camCb.QWidget)
+	top.AddStretch()
+	btns := qt.NewQHBoxLayout(nil)
+	btns.AddWidget(btnClear.QWidget)
+	btns.AddWidget(btnCSV.QWidget)
+	btns.AddStretch()
+	btns.AddWidget(btnClose.QWidget)
+
+	root := qt.NewQVBoxLayout(nil)
+	root.AddLayout(top.QLayout)
+	root.AddWidget(summary.QWidget)
+	root.AddWidget(table.QWidget)
+	root.AddLayout(btns.QLayout)
+	d.SetLayout(root.QLayout)
+
+	timer := qt.NewQTimer2(d.QObject)
+	timer.OnTimeout(func() { refresh(false) })
+	timer.Start(2000)
+	d.OnFinished(func(int) { eventsDlg = nil })
+	d.OnDestroyed(func() { eventsDlg = nil })
+
+	refresh(true)
+	d.Show()
+}
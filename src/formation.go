@@ -1,7 +1,11 @@
 package main
 
 import (
+	"fmt"
 	"log"
+	"math"
+	"slices"
+	"strings"
 
 	"github.com/mappu/miqt/qt"
 )
@@ -19,6 +23,70 @@ type FormationItem struct {
 	Width    int    `yaml:"width"`
 	Height   int    `yaml:"height"`
 	Visible  bool   `yaml:"visible,omitempty"` // keeps whether the window was open
+	// monitor (Qt screen name) x/y are relative to; "" = absolute desktop coordinates (older formations)
+	Screen string `yaml:"screen,omitempty"`
+	// optional display overrides; nil means "use the camera's own config"
+	Rotate *int  `yaml:"rotate,omitempty"`
+	FlipH  *bool `yaml:"flip_h,omitempty"`
+	FlipV  *bool `yaml:"flip_v,omitempty"`
+}
+
+// displayTransform resolves the rotation/flip for a camera, letting the
+// formation item override the camera's own settings when specified.
+func (it FormationItem) displayTransform(c CameraConfig) (rotate int, flipH, flipV bool) {
+	rotate, flipH, flipV = c.Rotate, c.FlipH, c.FlipV
+	if it.Rotate != nil {
+		rotate = *it.Rotate
+	}
+	if it.FlipH != nil {
+		flipH = *it.FlipH
+	}
+	if it.FlipV != nil {
+		flipV = *it.FlipV
+	}
+	return normalizeRotation(rotate), flipH, flipV
+}
+
+// formationItem captures a camera window for a formation. The position is
+// stored relative to the monitor under the window's center, so the layout
+// survives monitors being rearranged (docking/undocking a laptop).
+func formationItem(id string, w *CamWindow) FormationItem {
+	g := w.win.Geometry()
+	x, y := w.windowPos()
+	it := FormationItem{
+		CameraID: id,
+		X:        x, Y: y, Width: g.Width(), Height: g.Height(),
+		Visible: true,
+	}
+	if scr := qt.QGuiApplication_ScreenAt(qt.NewQPoint2(x+g.Width()/2, y+g.Height()/2)); scr != nil {
+		sg := scr.Geometry()
+		it.Screen, it.X, it.Y = scr.Name(), x-sg.X(), y-sg.Y()
+	}
+	return it
+}
+
+// placement resolves where an item goes now: relative to its monitor wherever
+// that is in the current arrangement, or on the primary monitor (kept inside
+// it) when that one isn't connected, reported by missing.
+func (it FormationItem) placement() (x, y int, missing bool) {
+	if it.Screen == "" {
+		return it.X, it.Y, false
+	}
+	scr := screenByName(it.Screen)
+	if scr == nil {
+		missing = true
+		if scr = qt.QGuiApplication_PrimaryScreen(); scr == nil {
+			return it.X, it.Y, true
+		}
+	}
+	sg := scr.Geometry()
+	x, y = sg.X()+it.X, sg.Y()+it.Y
+	if missing {
+		ag := scr.AvailableGeometry()
+		x = max(ag.X(), min(x, ag.X()+ag.Width()-it.Width))
+		y = max(ag.Y(), min(y, ag.Y()+ag.Height()-it.Height))
+	}
+	return x, y, missing
 }
 
 func (t *TrayController) installFormationsMenu(root *qt.QMenu) {
@@ -45,6 +113,18 @@ func (t *TrayController) rebuildFormationsList() {
 		t.formSaveAct.OnTriggered(func() { t.saveFormationInteractive() })
 	}
 	addAct(t.formMenu, t.formSaveAct)
+	if t.rotAct == nil {
+		t.rotAct = qt.NewQAction2("Start rotation")
+		t.rotAct.OnTriggered(func() {
+			if t.rotTimer != nil {
+				t.stopRotation()
+			} else {
+				t.startRotation()
+			}
+		})
+	}
+	t.rotAct.SetEnabled(len(t.cfg.Formations) > 1 || t.rotTimer != nil)
+	addAct(t.formMenu, t.rotAct)
 	addSep(t.formMenu)
 
 	if len(t.cfg.Formations) == 0 {
@@ -65,6 +145,7 @@ func (t *TrayController) rebuildFormationsList() {
 		// apply formation
 		applyA := qt.NewQAction2("Apply")
 		applyA.OnTriggered(func() {
+			t.stopRotation() // a manual choice ends the patrol
 			t.applyFormation(f)
 			t.cfg.LastFormation = f.Name
 			_ = SaveConfig()
@@ -78,13 +159,16 @@ func (t *TrayController) rebuildFormationsList() {
 			// no rebuild here; list didn’t change
 		})
 
+		renA := qt.NewQAction2("Rename…")
+		renA.OnTriggered(func() { t.renameFormationInteractive(f.Name) })
+
 		delA := qt.NewQAction2("Delete")
 		delA.OnTriggered(func() {
 			t.deleteFormationByName(f.Name)
 			t.rebuildFormationsList() // list changed -> rebuild contents only
 		})
 
-		sub.AddActions([]*qt.QAction{applyA, saveA, delA})
+		sub.AddActions([]*qt.QAction{applyA, saveA, renA, delA})
 
 		addAct(t.formMenu, sub.MenuAction())
 		t.formSubmenus[f.Name] = sub
@@ -124,6 +208,7 @@ func (t *TrayController) applyFormation(f Formation) {
 	}
 
 	// 2) Open/position all required windows
+	var missing []string // monitors the formation was saved on that aren't connected
 	for _, it := range f.Items {
 		idx := findCameraIndexByID(t.cfg, it.CameraID)
 		if idx < 0 {
@@ -145,7 +230,12 @@ func (t *TrayController) applyFormation(f Formation) {
 		// Place window; avoid spamming geometry saver while we’re placing.
 		if w := (*t.wins)[idx]; w != nil && w.win != nil {
 			w.suppressSave = true
-			w.win.SetGeometry(it.X, it.Y, it.Width, it.Height)
+			x, y, gone := it.placement()
+			if gone && !slices.Contains(missing, it.Screen) {
+				missing = append(missing, it.Screen)
+			}
+			w.win.SetGeometry(x, y, it.Width, it.Height)
+			w.rescueOffscreen() // absolute position from an older formation, monitor gone
 			// turn saving back on next tick
 			tm := qt.NewQTimer()
 			tm.SetSingleShot(true)
@@ -156,6 +246,10 @@ func (t *TrayController) applyFormation(f Formation) {
 				tm.DeleteLater()
 			})
 			tm.Start(0)
+			// formation may override rotation/flip; otherwise revert to camera config
+			if w.view != nil {
+				w.view.SetTransform(it.displayTransform(t.cfg.Cameras[idx]))
+			}
 			w.win.Show() // ensure visible
 			// keep tray checkbox in sync
 			if idx < len(t.actions) && t.actions[idx] != nil {
@@ -166,10 +260,85 @@ func (t *TrayController) applyFormation(f Formation) {
 		}
 	}
 
+	if len(missing) > 0 {
+		msg := fmt.Sprintf("Monitor %s is not connected; its windows were placed on the primary monitor.", strings.Join(missing, ", "))
+		log.Printf("formation %q: %s", f.Name, msg)
+		if t.tray != nil && t.tray.IsVisible() {
+			t.tray.ShowMessage4(f.Name, msg, qt.QSystemTrayIcon__Warning)
+		}
+	}
+
 	t.cfg.LastFormation = f.Name
 	_ = SaveConfig()
 }
 
+// defaultRotateSecs is how long each formation stays up while rotating.
+const defaultRotateSecs = 30
+
+func formationRotateSecs() int {
+	if s := globalConfig.FormationRotateSecs; s > 0 {
+		return s
+	}
+	return defaultRotateSecs
+}
+
+// startRotation applies the formations in turn, each for the configured dwell
+// time, beginning with the one after the active formation (video-wall patrol).
+func (t *TrayController) startRotation() {
+	if t.rotTimer != nil || len(t.cfg.Formations) < 2 {
+		return
+	}
+	t.rotNext = 0
+	for i, f := range t.cfg.Formations {
+		if f.Name == t.cfg.LastFormation {
+			t.rotNext = i + 1
+		}
+	}
+	t.rotTimer = qt.NewQTimer()
+	t.rotTimer.OnTimeout(func() { t.rotateFormation() })
+	t.rotTimer.Start(formationRotateSecs() * 1000)
+	log.Printf("formation rotation started (%d s each)", formationRotateSecs())
+	t.rotateFormation()
+	t.syncRotationAct()
+}
+
+// rotateFormation applies the next formation of the rotation.
+func (t *TrayController) rotateFormation() {
+	n := len(t.cfg.Formations)
+	if n < 2 {
+		t.stopRotation() // formations were deleted meanwhile
+		return
+	}
+	f := t.cfg.Formations[t.rotNext%n]
+	t.rotNext = t.rotNext%n + 1
+	t.applyFormation(f)
+	t.refreshFormationChecks(f.Name)
+	t.rotTimer.SetInterval(formationRotateSecs() * 1000) // settings may have changed
+}
+
+func (t *TrayController) stopRotation() {
+	if t.rotTimer == nil {
+		return
+	}
+	t.rotTimer.Stop()
+	t.rotTimer.DeleteLater()
+	t.rotTimer = nil
+	log.Printf("formation rotation stopped")
+	t.syncRotationAct()
+}
+
+func (t *TrayController) syncRotationAct() {
+	if t.rotAct == nil {
+		return
+	}
+	if t.rotTimer != nil {
+		t.rotAct.SetText("Stop rotation")
+	} else {
+		t.rotAct.SetText("Start rotation")
+	}
+	t.rotAct.SetEnabled(len(t.cfg.Formations) > 1 || t.rotTimer != nil)
+}
+
 func (t *TrayController) refreshFormationChecks(active string) {
 	if t.formSubmenus == nil {
 		return
@@ -201,18 +370,14 @@ func (t *TrayController) saveFormationInteractive() {
 		if id == "" {
 			id = t.cfg.Cameras[i].Name
 		}
-		g := w.win.Geometry()
-		items = append(items, FormationItem{
-			CameraID: id,
-			X:        g.X(), Y: g.Y(), Width: g.Width(), Height: g.Height(),
-			Visible: true,
-		})
+		items = append(items, formationItem(id, w))
 	}
 
 	// upsert by name
 	replaced := false
 	for i := range t.cfg.Formations {
 		if t.cfg.Formations[i].Name == name {
+			keepDisplayOverrides(items, t.cfg.Formations[i].Items)
 			t.cfg.Formations[i].Items = items
 			replaced = true
 			break
@@ -249,17 +414,13 @@ func (t *TrayController) saveFormationFromWindows(name string) {
 		if id == "" {
 			id = t.cfg.Cameras[i].Name
 		}
-		g := w.win.Geometry()
-		items = append(items, FormationItem{
-			CameraID: id,
-			X:        g.X(), Y: g.Y(), Width: g.Width(), Height: g.Height(),
-			Visible: true,
-		})
+		items = append(items, formationItem(id, w))
 	}
 
 	// overwrite existing by name
 	for i := range t.cfg.Formations {
 		if t.cfg.Formations[i].Name == name {
+			keepDisplayOverrides(items, t.cfg.Formations[i].Items)
 			t.cfg.Formations[i].Items = items
 			_ = SaveConfig()
 			return
@@ -270,6 +431,37 @@ func (t *TrayController) saveFormationFromWindows(name string) {
 	_ = SaveConfig()
 }
 
+// renameFormationInteractive asks for a new name and renames the formation,
+// keeping it the active one if it was. Names stay unique.
+func (t *TrayController) renameFormationInteractive(name string) {
+	newName, ok := promptTextValue("Rename Formation", "New name for this formation:", name)
+	if !ok || newName == "" || newName == name {
+		return
+	}
+	idx := -1
+	for i := range t.cfg.Formations {
+		switch t.cfg.Formations[i].Name {
+		case newName:
+			qt.QMessageBox_Warning(nil, "Rename Formation", fmt.Sprintf("A formation named %q already exists.", newName))
+			return
+		case name:
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return
+	}
+	t.cfg.Formations[idx].Name = newName
+	if t.cfg.LastFormation == name {
+		t.cfg.LastFormation = newName
+	}
+	_ = SaveConfig()
+
+	if t.tray != nil && t.tray.ContextMenu() != nil {
+		t.installFormationsMenu(t.tray.ContextMenu())
+	}
+}
+
 func (t *TrayController) deleteFormationByName(name string) {
 	if name == "" {
 		return
@@ -290,3 +482,117 @@ func (t *TrayController) deleteFormationByName(name string) {
 		t.installFormationsMenu(t.tray.ContextMenu())
 	}
 }
+
+// gridFormationName is the formation "Arrange in grid" (re)writes, so the
+// tidy layout can be applied again later like any other.
+const gridFormationName = "Grid (auto)"
+
+// gridShape picks columns and rows for n windows on an areaW x areaH area
+// so that 16:9 pictures come out as large as possible.
+func gridShape(n, areaW, areaH int) (cols, rows int) {
+	best := -1.0
+	for c := 1; c <= n; c++ {
+		r := (n + c - 1) / c
+		cw, ch := float64(areaW)/float64(c), float64(areaH)/float64(r)
+		if pic := math.Min(cw, ch*16/9); pic > best { // width of a 16:9 picture in the cell
+			best, cols, rows = pic, c, r
+		}
+	}
+	return cols, rows
+}
+
+// arrangeInGrid lays all visible, non-fullscreen camera windows out in an
+// even grid over the available area of the named monitor (primary when ""
+// or gone), in camera list order, and keeps the result as gridFormationName.
+func (t *TrayController) arrangeInGrid(screen string) {
+	t.stopRotation()
+	scr := qt.QGuiApplication_PrimaryScreen()
+	for _, s := range qt.QGuiApplication_Screens() {
+		if s.Name() == screen {
+			scr = s
+		}
+	}
+	if scr == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ensureWinsLen()
+
+	var ws []*CamWindow
+	var ids []string
+	for i, w := range *t.wins {
+		if w == nil || w.win == nil || w.closing || w.isFullscreen || !w.win.IsVisible() {
+			continue
+		}
+		id := t.cfg.Cameras[i].ID
+		if id == "" {
+			id = t.cfg.Cameras[i].Name
+		}
+		ws = append(ws, w)
+		ids = append(ids, id)
+	}
+	if len(ws) == 0 {
+		return
+	}
+
+	sg := scr.AvailableGeometry() // excludes taskbar/dock
+	full := scr.Geometry()        // formation items are relative to this
+	cols, rows := gridShape(len(ws), sg.Width(), sg.Height())
+	cellW, cellH := sg.Width()/cols, sg.Height()/rows
+	log.Printf("arranging %d windows in a %dx%d grid on %s", len(ws), cols, rows, scr.Name())
+
+	items := make([]FormationItem, 0, len(ws))
+	for i, w := range ws {
+		// title bar and borders lie outside Geometry(): fit the whole frame
+		// into the cell (all zero for frameless windows)
+		fg, g := w.win.FrameGeometry(), w.win.Geometry()
+		left, top := g.X()-fg.X(), g.Y()-fg.Y()
+		right, bottom := fg.Width()-g.Width()-left, fg.Height()-g.Height()-top
+		x := sg.X() + (i%cols)*cellW + left
+		y := sg.Y() + (i/cols)*cellH + top
+		width, height := max(cellW-left-right, 32), max(cellH-top-bottom, 32)
+		w.win.SetGeometry(x, y, width, height)
+		items = append(items, FormationItem{
+			CameraID: ids[i],
+			X:        x - full.X(), Y: y - full.Y(), Width: width, Height: height,
+			Visible: true,
+			Screen:  scr.Name(),
+		})
+	}
+
+	replaced := false
+	for i := range t.cfg.Formations {
+		if t.cfg.Formations[i].Name == gridFormationName {
+			t.cfg.Formations[i].Items = items
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		t.cfg.Formations = append(t.cfg.Formations, Formation{Name: gridFormationName, Items: items})
+	}
+	t.cfg.LastFormation = gridFormationName
+	_ = SaveConfig()
+
+	if t.tray != nil && t.tray.ContextMenu() != nil {
+		t.installFormationsMenu(t.tray.ContextMenu())
+	}
+}
+
+// keepDisplayOverrides carries rotate/flip overrides from the old items over to
+// freshly captured ones, so re-saving geometry doesn't drop them.
+func keepDisplayOverrides(items, old []FormationItem) {
+	prev := make(map[string]FormationItem, len(old))
+	for _, it := range old {
+		prev[it.CameraID] = it
+	}
+	for i := range items {
+		if p, ok := prev[items[i].CameraID]; ok {
+			items[i].Rotate = p.Rotate
+			items[i].FlipH = p.FlipH
+			items[i].FlipV = p.FlipV
+		}
+	}
+}
@@ -0,0 +1,118 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import "slices"
+
+/*
+Explicit glue: "Glue to neighbors" (camera context menu) records a window
+and every window touching it, transitively, as a group in the config
+(glue_groups). Dragging any member in borderless mode then moves the whole
+group, whatever the exact pixel adjacency; without one the group is worked
+out from touching edges on each drag (see buildGroup).
+*/
+
+// glueGroupOf returns a copy of the glue group containing key, nil if none.
+func glueGroupOf(key string) []string {
+	configMu.Lock()
+	defer configMu.Unlock()
+	for _, g := range globalConfig.GlueGroups {
+		if slices.Contains(g, key) {
+			return slices.Clone(g)
+		}
+	}
+	return nil
+}
+
+func (w *CamWindow) isGlued() bool { return glueGroupOf(w.idKey) != nil }
+
+// glueMembers returns w followed by the other open, visible members of its
+// glue group; nil when w isn't glued.
+func (w *CamWindow) glueMembers() []*CamWindow {
+	ids := glueGroupOf(w.idKey)
+	if ids == nil {
+		return nil
+	}
+	out := []*CamWindow{w}
+	for _, o := range wins {
+		if o == nil || o == w || o.win == nil || o.isFullscreen || !o.win.IsVisible() {
+			continue
+		}
+		if slices.Contains(ids, o.idKey) {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+// GlueToNeighbors glues w to the windows touching it (within the snap
+// distance), merging any glue groups they already belong to.
+func (w *CamWindow) GlueToNeighbors() {
+	cluster := touchingCluster(w, scalePx(w.win.QPaintDevice, snapDistance()))
+	if len(cluster) < 2 {
+		w.logf("glue: no neighboring windows")
+		return
+	}
+	merged := make([]string, 0, len(cluster))
+	for _, cw := range cluster {
+		merged = append(merged, cw.idKey)
+	}
+
+	configMu.Lock()
+	groups := globalConfig.GlueGroups[:0]
+	for _, g := range globalConfig.GlueGroups {
+		if slices.ContainsFunc(g, func(id string) bool { return slices.Contains(merged, id) }) {
+			for _, id := range g {
+				if !slices.Contains(merged, id) {
+					merged = append(merged, id)
+				}
+			}
+			continue
+		}
+		groups = append(groups, g)
+	}
+	globalConfig.GlueGroups = append(groups, merged)
+	configMu.Unlock()
+
+	if err := SaveConfig(); err != nil {
+		w.logf("save config failed: %v", err)
+	}
+	w.logf("glued %d windows together", len(merged))
+}
+
+// Unglue takes w out of its glue group; a group left with one window is dropped.
+func (w *CamWindow) Unglue() {
+	configMu.Lock()
+	groups := globalConfig.GlueGroups[:0]
+	for _, g := range globalConfig.GlueGroups {
+		g = slices.DeleteFunc(g, func(id string) bool { return id == w.idKey })
+		if len(g) >= 2 {
+			groups = append(groups, g)
+		}
+	}
+	globalConfig.GlueGroups = groups
+	configMu.Unlock()
+
+	if err := SaveConfig(); err != nil {
+		w.logf("save config failed: %v", err)
+	}
+	w.logf("unglued")
+}
@@ -0,0 +1,421 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/asticode/go-astiav"
+	"github.com/mappu/miqt/qt"
+)
+
+/*
+Grid recording: every open camera's latest preview frame is tiled into one
+canvas at a fixed rate and encoded to H.264 in its own file under
+AnotherRTSP-Recordings/grid/. It works from the same BGRA buffers the
+windows paint from, so it costs one encoder and no extra decoding; what the
+file shows is what the preview had (including the Max FPS cap).
+*/
+
+// gridRec is the running grid recorder; touched on the Qt main thread only.
+var gridRec *gridRecorder
+
+type gridRecorder struct {
+	stop chan struct{}
+	done chan struct{}
+	path string
+}
+
+// gridRecordSize parses GridRecordSize ("WxH"); defaults to 1280x720.
+// Sizes are rounded down to even numbers for 4:2:0 encoders.
+func gridRecordSize() (w, h int) {
+	w, h = 1280, 720
+	if a, b, ok := strings.Cut(strings.ToLower(globalConfig.GridRecordSize), "x"); ok {
+		pw, err1 := strconv.Atoi(strings.TrimSpace(a))
+		ph, err2 := strconv.Atoi(strings.TrimSpace(b))
+		if err1 == nil && err2 == nil && pw >= 160 && ph >= 120 {
+			w, h = pw, ph
+		}
+	}
+	return w &^ 1, h &^ 1
+}
+
+// gridRecordFPS is the grid recording frame rate (default 5).
+func gridRecordFPS() int {
+	if f := globalConfig.GridRecordFPS; f > 0 && f <= 60 {
+		return f
+	}
+	return 5
+}
+
+func isGridRecording() bool { return gridRec != nil }
+
+// toggleGridRecording starts or stops the grid recorder. Main thread only.
+func toggleGridRecording() {
+	if gridRec != nil {
+		stopGridRecording()
+		return
+	}
+	if err := startGridRecording(); err != nil {
+		log.Printf("grid recording: %v", err)
+		qt.QMessageBox_Warning(nil, "Record grid", "Could not start grid recording:\n"+err.Error())
+	}
+}
+
+func startGridRecording() error {
+	root, err := recordingsRoot()
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(root, "grid")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, time.Now().Format("2006-01-02_15-04-05")+".mp4")
+
+	w, h := gridRecordSize()
+	enc, err := newGridEncoder(path, w, h, gridRecordFPS())
+	if err != nil {
+		return err
+	}
+	r := &gridRecorder{stop: make(chan struct{}), done: make(chan struct{}), path: path}
+	gridRec = r
+	writeRecordingMarker(path)
+	log.Printf("grid recording started -> %s (%dx%d @ %d fps)", path, w, h, gridRecordFPS())
+	go r.run(enc)
+	return nil
+}
+
+// stopGridRecording stops the recorder and waits for the file to be
+// finalized. Main thread only; safe when not recording.
+func stopGridRecording() {
+	r := gridRec
+	if r == nil {
+		return
+	}
+	gridRec = nil
+	close(r.stop)
+	<-r.done // run() never waits on the main thread after stop
+	log.Printf("grid recording stopped -> %s", r.path)
+}
+
+func (r *gridRecorder) run(enc *gridEncoder) {
+	defer close(r.done)
+	defer func() {
+		enc.close()
+		clearRecordingMarker(r.path)
+	}()
+
+	canvas := make([]byte, enc.w*enc.h*4)
+	tick := time.NewTicker(time.Second / time.Duration(enc.fps))
+	defer tick.Stop()
+
+	for pts := int64(0); ; pts++ {
+		select {
+		case <-r.stop:
+			return
+		case <-tick.C:
+		}
+
+		var bufs []*frameBuf
+		stopped := false
+		mainthreadOrStop(r.stop, func() {
+			for _, w := range wins {
+				if w != nil && !w.closing && w.win != nil && w.win.IsVisible() {
+					bufs = append(bufs, &w.buf)
+				}
+			}
+		}, &stopped)
+		if stopped {
+			return
+		}
+
+		composeGrid(canvas, enc.w, enc.h, bufs)
+		if err := enc.encode(canvas, pts); err != nil {
+			log.Printf("grid recording: %v", err)
+			return
+		}
+	}
+}
+
+// mainthreadOrStop runs fn on the Qt main thread unless stop closes first
+// (stopGridRecording blocks the main thread while waiting for us).
+func mainthreadOrStop(stop <-chan struct{}, fn func(), stopped *bool) {
+	ran := make(chan struct{})
+	go CallOnQtMain(func() {
+		select {
+		case <-stop:
+		default:
+			fn()
+		}
+		close(ran)
+	})
+	select {
+	case <-ran:
+	case <-stop:
+		*stopped = true
+	}
+}
+
+// composeGrid tiles the frames into a near-square grid on a black canvas,
+// keeping each frame's aspect ratio (nearest-neighbour scaling).
+func composeGrid(canvas []byte, cw, ch int, bufs []*frameBuf) {
+	clear(canvas)
+	n := len(bufs)
+	if n == 0 {
+		return
+	}
+	cols := int(math.Ceil(math.Sqrt(float64(n))))
+	rows := (n + cols - 1) / cols
+	tw, th := cw/cols, ch/rows
+
+	for i, fb := range bufs {
+		x0, y0 := (i%cols)*tw, (i/cols)*th
+		fb.mu.RLock()
+		sw, sh, src := fb.w, fb.h, fb.b
+		if sw > 0 && sh > 0 && len(src) >= sw*sh*4 {
+			// fit inside the tile, centered
+			dw, dh := tw, tw*sh/sw
+			if dh > th {
+				dw, dh = th*sw/sh, th
+			}
+			ox, oy := x0+(tw-dw)/2, y0+(th-dh)/2
+			for y := 0; y < dh; y++ {
+				srow := (y * sh / dh) * sw * 4
+				drow := ((oy+y)*cw + ox) * 4
+				for x := 0; x < dw; x++ {
+					s := srow + (x*sw/dw)*4
+					copy(canvas[drow+x*4:drow+x*4+4], src[s:s+4])
+				}
+			}
+		}
+		fb.mu.RUnlock()
+	}
+}
+
+// gridEncoder converts BGRA canvases to the encoder's pixel format and
+// muxes H.264 into a fragmented MP4 (playable even if the app dies).
+type gridEncoder struct {
+	w, h, fps int
+	oc        *astiav.FormatContext
+	pb        *astiav.IOContext
+	ctx       *astiav.CodecContext
+	st        *astiav.Stream
+	ssc       *astiav.SoftwareScaleContext
+	src, dst  *astiav.Frame
+	pkt       *astiav.Packet
+}
+
+// h264Codec prefers libx264, then any H.264 encoder (hardware ones
+// included), then MPEG-4 Part 2 as a last resort. Also used by re-encoded
+// camera recordings (recencode.go).
+func h264Codec() *astiav.Codec {
+	if c := astiav.FindEncoderByName("libx264"); c != nil {
+		return c
+	}
+	if c := astiav.FindEncoder(astiav.CodecIDH264); c != nil {
+		return c
+	}
+	return astiav.FindEncoder(astiav.CodecIDMpeg4)
+}
+
+// encoderPixelFormat is yuv420p when the codec takes it, else its first format.
+func encoderPixelFormat(codec *astiav.Codec) astiav.PixelFormat {
+	pix := astiav.PixelFormatYuv420P
+	if pfs := codec.PixelFormats(); len(pfs) > 0 {
+		pix = pfs[0]
+		for _, pf := range pfs {
+			if pf == astiav.PixelFormatYuv420P {
+				pix = pf
+				break
+			}
+		}
+	}
+	return pix
+}
+
+func newGridEncoder(path string, w, h, fps int) (e *gridEncoder, err error) {
+	e = &gridEncoder{w: w, h: h, fps: fps}
+	defer func() {
+		if err != nil {
+			e.free()
+			e = nil
+		}
+	}()
+
+	codec := h264Codec()
+	if codec == nil {
+		return nil, errors.New("no H.264 or MPEG-4 encoder in this FFmpeg build")
+	}
+	pix := encoderPixelFormat(codec)
+
+	if e.oc, err = astiav.AllocOutputFormatContext(nil, "mp4", path); err != nil || e.oc == nil {
+		return e, fmt.Errorf("AllocOutputFormatContext: %w", err)
+	}
+	if e.pb, err = astiav.OpenIOContext(path, astiav.NewIOContextFlags(astiav.IOContextFlagWrite), nil, nil); err != nil {
+		return e, fmt.Errorf("OpenIOContext: %w", err)
+	}
+	e.oc.SetPb(e.pb)
+
+	if e.ctx = astiav.AllocCodecContext(codec); e.ctx == nil {
+		return e, errors.New("AllocCodecContext failed")
+	}
+	e.ctx.SetWidth(w)
+	e.ctx.SetHeight(h)
+	e.ctx.SetPixelFormat(pix)
+	e.ctx.SetTimeBase(astiav.NewRational(1, fps))
+	e.ctx.SetFramerate(astiav.NewRational(fps, 1))
+	e.ctx.SetGopSize(fps * 2) // a keyframe (and MP4 fragment) every 2 s
+	e.ctx.SetMaxBFrames(0)
+	if e.oc.OutputFormat().Flags().Has(astiav.IOFormatFlagGlobalheader) {
+		e.ctx.SetFlags(e.ctx.Flags().Add(astiav.CodecContextFlagGlobalHeader))
+	}
+	encOpts := astiav.NewDictionary()
+	defer encOpts.Free()
+	if codec.Name() == "libx264" {
+		_ = encOpts.Set("preset", "veryfast", 0)
+		_ = encOpts.Set("crf", "26", 0)
+	} else {
+		e.ctx.SetBitRate(int64(w*h) * int64(fps) / 8) // ~0.125 bit/pixel
+	}
+	if err = e.ctx.Open(codec, encOpts); err != nil {
+		return e, fmt.Errorf("open %s encoder: %w", codec.Name(), err)
+	}
+
+	if e.st = e.oc.NewStream(codec); e.st == nil {
+		return e, errors.New("NewStream failed")
+	}
+	if err = e.ctx.ToCodecParameters(e.st.CodecParameters()); err != nil {
+		return e, fmt.Errorf("ToCodecParameters: %w", err)
+	}
+	e.st.SetTimeBase(e.ctx.TimeBase())
+
+	muxOpts := astiav.NewDictionary()
+	defer muxOpts.Free()
+	_ = muxOpts.Set("movflags", "frag_keyframe+empty_moov+default_base_moof", 0)
+	if err = e.oc.WriteHeader(muxOpts); err != nil {
+		return e, fmt.Errorf("WriteHeader: %w", err)
+	}
+
+	e.src = astiav.AllocFrame()
+	e.src.SetWidth(w)
+	e.src.SetHeight(h)
+	e.src.SetPixelFormat(astiav.PixelFormatBgra)
+	if err = e.src.AllocBuffer(1); err != nil {
+		return e, fmt.Errorf("alloc canvas frame: %w", err)
+	}
+	e.dst = astiav.AllocFrame()
+	e.dst.SetWidth(w)
+	e.dst.SetHeight(h)
+	e.dst.SetPixelFormat(pix)
+	if err = e.dst.AllocBuffer(0); err != nil {
+		return e, fmt.Errorf("alloc encoder frame: %w", err)
+	}
+	if e.ssc, err = astiav.CreateSoftwareScaleContext(w, h, astiav.PixelFormatBgra, w, h, pix,
+		astiav.NewSoftwareScaleContextFlags(astiav.SoftwareScaleContextFlagBilinear)); err != nil {
+		return e, fmt.Errorf("CreateSoftwareScaleContext: %w", err)
+	}
+	e.pkt = astiav.AllocPacket()
+	return e, nil
+}
+
+func (e *gridEncoder) encode(canvas []byte, pts int64) error {
+	if err := e.src.Data().SetBytes(canvas, 1); err != nil {
+		return fmt.Errorf("canvas copy: %w", err)
+	}
+	// the encoder may still hold the previous frame
+	if err := e.dst.MakeWritable(); err != nil {
+		return fmt.Errorf("MakeWritable: %w", err)
+	}
+	if err := e.ssc.ScaleFrame(e.src, e.dst); err != nil {
+		return fmt.Errorf("ScaleFrame: %w", err)
+	}
+	e.dst.SetPts(pts)
+	if err := e.ctx.SendFrame(e.dst); err != nil {
+		return fmt.Errorf("SendFrame: %w", err)
+	}
+	return e.drain()
+}
+
+// drain writes out every packet the encoder has ready.
+func (e *gridEncoder) drain() error {
+	for {
+		if err := e.ctx.ReceivePacket(e.pkt); err != nil {
+			if errors.Is(err, astiav.ErrEagain) || errors.Is(err, astiav.ErrEof) {
+				return nil
+			}
+			return fmt.Errorf("ReceivePacket: %w", err)
+		}
+		e.pkt.SetStreamIndex(e.st.Index())
+		e.pkt.RescaleTs(e.ctx.TimeBase(), e.st.TimeBase())
+		err := e.oc.WriteInterleavedFrame(e.pkt)
+		e.pkt.Unref()
+		if err != nil {
+			return fmt.Errorf("WriteInterleavedFrame: %w", err)
+		}
+	}
+}
+
+// close flushes the encoder, writes the trailer and frees everything.
+func (e *gridEncoder) close() {
+	if err := e.ctx.SendFrame(nil); err == nil {
+		if err := e.drain(); err != nil {
+			log.Printf("grid recording: flush: %v", err)
+		}
+	}
+	if err := e.oc.WriteTrailer(); err != nil {
+		log.Printf("grid recording: WriteTrailer: %v", err)
+	}
+	e.free()
+}
+
+func (e *gridEncoder) free() {
+	if e.pkt != nil {
+		e.pkt.Free()
+	}
+	if e.ssc != nil {
+		e.ssc.Free()
+	}
+	if e.src != nil {
+		e.src.Free()
+	}
+	if e.dst != nil {
+		e.dst.Free()
+	}
+	if e.ctx != nil {
+		e.ctx.Free()
+	}
+	if e.pb != nil {
+		_ = e.pb.Close()
+		e.pb.Free()
+	}
+	if e.oc != nil {
+		e.oc.Free()
+	}
+}
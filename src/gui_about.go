@@ -104,6 +104,25 @@ func ShowAboutDialog(parent *qt.QWidget, info AboutInfo) {
 	sysInfo.SetHtml(aboutHTML(info))
 	root.AddWidget(sysInfo.QWidget)
 
+	// Live process usage (whole app, all cameras)
+	usageLbl := qt.NewQLabel(nil)
+	usageLbl.SetTextInteractionFlags(qt.TextSelectableByMouse)
+	root.AddWidget(usageLbl.QWidget)
+	var sampler procSampler
+	updateUsage := func() {
+		st := sampler.Sample()
+		fps, open, decoding := cameraTotals()
+		usageLbl.SetText(fmt.Sprintf("Process: CPU %.0f%% (%d cores)  |  RSS %s  |  Go heap %s\n"+
+			"Cameras: %d open, %d decoding  |  %.1f fps total  |  %d goroutines",
+			st.CPUPct, runtime.NumCPU(), formatBytes(st.RSS), formatBytes(st.GoHeap),
+			open, decoding, fps, st.Goroutines))
+	}
+	updateUsage()
+	usageTimer := qt.NewQTimer2(d.QObject) // parented: dies with the dialog
+	usageTimer.SetInterval(1000)
+	usageTimer.OnTimeout(updateUsage)
+	usageTimer.Start2()
+
 	// Buttons
 	btnRow := qt.NewQHBoxLayout(nil)
 	btnRow.AddStretch()
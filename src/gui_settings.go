@@ -23,6 +23,12 @@ package main
 import (
 	"fmt"
 	"log"
+	"reflect"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/mappu/miqt/qt"
 )
@@ -40,24 +46,79 @@ type SettingsDialog struct {
 	list            *qt.QListWidget
 	btnAdd, btnEdit *qt.QPushButton
 	btnRemove       *qt.QPushButton
+	btnEnable       *qt.QPushButton // bulk: enable the selected cameras
+	btnDisable      *qt.QPushButton // bulk: disable the selected cameras
 	// Footer
 	btnCancel, btnSave *qt.QPushButton
 	noWinTitlesCh      *qt.QCheckBox
 	snapCh             *qt.QCheckBox
+	snapDistSpin       *qt.QSpinBox
+	minWinWSpin        *qt.QSpinBox
+	minWinHSpin        *qt.QSpinBox
 	alwaysOnTopAllCh   *qt.QCheckBox
 	activateOnTrayCh   *qt.QCheckBox
-	activateOnWinCh    *qt.QCheckBox
+	winClickCb         *qt.QComboBox
+	closeToTrayCh      *qt.QCheckBox
+	quitOnLastCh       *qt.QCheckBox
+	trayClickCb        *qt.QComboBox
+	hidePausesCh       *qt.QCheckBox
+	notifyConnCh       *qt.QCheckBox
+	eventsCSVCh        *qt.QCheckBox
+	xwaylandCh         *qt.QCheckBox
+	hideDockCh         *qt.QCheckBox
+	startLoginCh       *qt.QCheckBox
+	startHiddenCh      *qt.QCheckBox
+	activeBorderCb     *qt.QComboBox
+	activeBorderBtn    *qt.QPushButton
+	activeBorderColor  string
 	// overlays
 	healthChipCh *qt.QCheckBox
 	fpsCh        *qt.QCheckBox
 	bitrateCh    *qt.QCheckBox
 	dropsCh      *qt.QCheckBox
 	cpuCh        *qt.QCheckBox
+	audioMeterCh *qt.QCheckBox
+	streamInfoCh *qt.QCheckBox
+	keyEdits     map[string]*qt.QKeySequenceEdit // action ID -> editor (Keys tab)
+	panicKeyEdit *qt.QKeySequenceEdit            // global hide/show all key (Keys tab)
+	// no-signal placeholder
+	noSignalColor    string
+	noSignalColorBtn *qt.QPushButton
+	noSignalTextEd   *qt.QLineEdit
+	noSignalImageEd  *qt.QLineEdit
+	staleFrameSpin   *qt.QSpinBox
+	playerEd         *qt.QLineEdit
 	// advanced
 	limitGuiCh         *qt.QCheckBox
 	guiRefreshSlider   *qt.QSlider
 	guiRefreshValueLbl *qt.QLabel
 	repaintOnNewCh     *qt.QCheckBox
+	audioUnderrunCb    *qt.QComboBox
+	audioBufferSpin    *qt.QSpinBox
+	audioDeviceCb      *qt.QComboBox
+	audioDeviceIDs     []string // parallel to audioDeviceCb items
+	perCamLogsCh       *qt.QCheckBox
+	logMaxSpin         *qt.QSpinBox
+	logKeepSpin        *qt.QSpinBox
+	logFormatCb        *qt.QComboBox
+	defMaxFPSSpin      *qt.QSpinBox
+	defMaxKbpsSpin     *qt.QSpinBox
+	threadsCb          *qt.QComboBox // FFmpeg default / auto / fixed
+	threadsSpin        *qt.QSpinBox
+	maxDecodeCb        *qt.QComboBox
+	restartAfterSpin   *qt.QSpinBox
+	staggerSpin        *qt.QSpinBox
+	lowDiskSpin        *qt.QSpinBox
+	rotateSpin         *qt.QSpinBox
+	lowDiskStopCh      *qt.QCheckBox
+	errDetectCb        *qt.QComboBox
+	errConcealCb       *qt.QComboBox
+	hideCorruptCh      *qt.QCheckBox
+	gridSizeCb         *qt.QComboBox
+	recNameEdit        *qt.QLineEdit
+	gridFPSSpin        *qt.QSpinBox
+	snapQualitySpin    *qt.QSpinBox
+	contactSheetCh     *qt.QCheckBox
 	// Cameras
 	cams []CameraConfig
 }
@@ -83,15 +144,20 @@ func newSettingsDialog(parent *qt.QWidget) *SettingsDialog {
 	// ===== Cameras tab =====
 	d.camPage = qt.NewQWidget(parent)
 	d.list = qt.NewQListWidget(parent)
+	d.list.SetSelectionMode(qt.QAbstractItemView__ExtendedSelection) // Ctrl/Shift-click for bulk actions
 	d.btnAdd = qt.NewQPushButton5("Add", nil)
 	d.btnEdit = qt.NewQPushButton5("Edit", nil)
 	d.btnRemove = qt.NewQPushButton5("Remove", nil)
+	d.btnEnable = qt.NewQPushButton5("Enable selected", nil)
+	d.btnDisable = qt.NewQPushButton5("Disable selected", nil)
 
 	row := qt.NewQHBoxLayout(nil)
 	row.AddWidget(d.btnAdd.QWidget)
 	row.AddWidget(d.btnEdit.QWidget)
 	row.AddWidget(d.btnRemove.QWidget)
 	row.AddStretch()
+	row.AddWidget(d.btnEnable.QWidget)
+	row.AddWidget(d.btnDisable.QWidget)
 
 	// Put list and row into the Cameras page layout so it stretches with the dialog
 	camLayout := qt.NewQVBoxLayout(nil)
@@ -110,6 +176,32 @@ func newSettingsDialog(parent *qt.QWidget) *SettingsDialog {
 	d.snapCh = qt.NewQCheckBox4("Enable window snapping (glue/stack)", nil)
 	d.snapCh.SetChecked(globalConfig.SnapEnabled)
 	settingsForm.AddRow3("", d.snapCh.QWidget)
+	d.snapDistSpin = qt.NewQSpinBox(nil)
+	d.snapDistSpin.SetRange(1, 100)
+	d.snapDistSpin.SetSuffix(" px")
+	d.snapDistSpin.SetValue(snapDistance())
+	d.snapDistSpin.SetToolTip("How close (in pixels at 100% scaling) an edge, center or gap has to be before the window snaps to it")
+	d.snapDistSpin.SetEnabled(d.snapCh.IsChecked())
+	d.snapCh.OnToggled(func(on bool) { d.snapDistSpin.SetEnabled(on) })
+	settingsForm.AddRow3("Snap distance:", d.snapDistSpin.QWidget)
+	// dense walls want smaller thumbnails than the default minimum
+	mw, mh := minWindowSize()
+	d.minWinWSpin = qt.NewQSpinBox(nil)
+	d.minWinWSpin.SetRange(minWindowFloor, 4000)
+	d.minWinWSpin.SetSuffix(" px")
+	d.minWinWSpin.SetValue(mw)
+	d.minWinHSpin = qt.NewQSpinBox(nil)
+	d.minWinHSpin.SetRange(minWindowFloor, 4000)
+	d.minWinHSpin.SetSuffix(" px")
+	d.minWinHSpin.SetValue(mh)
+	d.minWinWSpin.SetToolTip(fmt.Sprintf("Camera windows can't be resized below this (video area). Default %dx%d", defaultMinWindowW, defaultMinWindowH))
+	d.minWinHSpin.SetToolTip(d.minWinWSpin.ToolTip())
+	minWinRow := qt.NewQHBoxLayout(nil)
+	minWinRow.AddWidget(d.minWinWSpin.QWidget)
+	minWinRow.AddWidget(qt.NewQLabel3("×").QWidget)
+	minWinRow.AddWidget(d.minWinHSpin.QWidget)
+	minWinRow.AddStretch()
+	settingsForm.AddRow4("Minimum window size:", minWinRow.QLayout)
 	// camera windows always on top
 	d.alwaysOnTopAllCh = qt.NewQCheckBox4("All camera windows always on top", nil)
 	d.alwaysOnTopAllCh.SetChecked(globalConfig.AlwaysOnTopAll)
@@ -118,10 +210,110 @@ func newSettingsDialog(parent *qt.QWidget) *SettingsDialog {
 	d.activateOnTrayCh = qt.NewQCheckBox4("Activate camera windows on tray click", nil)
 	d.activateOnTrayCh.SetChecked(globalConfig.ActiveOnTray)
 	settingsForm.AddRow3("", d.activateOnTrayCh.QWidget)
-	// activate all camera windows on one window click
-	d.activateOnWinCh = qt.NewQCheckBox4("Activate all cameras on one camera click", nil)
-	d.activateOnWinCh.SetChecked(globalConfig.ActiveOnWin)
-	settingsForm.AddRow3("", d.activateOnWinCh.QWidget)
+	// what the tray click does: raise, or hide/show all ("panic hide")
+	d.trayClickCb = qt.NewQComboBox(nil)
+	d.trayClickCb.AddItem("Raise all windows")
+	d.trayClickCb.AddItem("Show/hide all windows")
+	if globalConfig.TrayClick == "toggle" {
+		d.trayClickCb.SetCurrentIndex(1)
+	}
+	d.hidePausesCh = qt.NewQCheckBox4("Pause cameras while hidden", nil)
+	d.hidePausesCh.SetChecked(globalConfig.HidePauses)
+	d.hidePausesCh.SetToolTip("Stop decoding the windows hidden by the tray click; they reconnect when shown again")
+	trayClickRow := qt.NewQHBoxLayout(nil)
+	trayClickRow.AddWidget(d.trayClickCb.QWidget)
+	trayClickRow.AddWidget(d.hidePausesCh.QWidget)
+	settingsForm.AddRow4("Tray click:", trayClickRow.QLayout)
+	syncTrayClick := func() {
+		on := d.activateOnTrayCh.IsChecked()
+		d.trayClickCb.SetEnabled(on)
+		d.hidePausesCh.SetEnabled(on && d.trayClickCb.CurrentIndex() == 1)
+	}
+	d.activateOnTrayCh.OnToggled(func(bool) { syncTrayClick() })
+	d.trayClickCb.OnCurrentIndexChanged(func(int) { syncTrayClick() })
+	syncTrayClick()
+	// what a click on a camera window raises
+	d.winClickCb = qt.NewQComboBox(nil)
+	d.winClickCb.AddItem("Do nothing else")
+	d.winClickCb.AddItem("Raise all camera windows")
+	d.winClickCb.AddItem("Raise only the clicked window")
+	switch globalConfig.WinClick {
+	case winClickRaiseAll:
+		d.winClickCb.SetCurrentIndex(1)
+	case winClickRaiseClicked:
+		d.winClickCb.SetCurrentIndex(2)
+	}
+	settingsForm.AddRow3("Camera click:", d.winClickCb.QWidget)
+	// closing a camera window hides it to tray instead of disabling the camera
+	d.closeToTrayCh = qt.NewQCheckBox4("Closing a camera window hides it to tray", nil)
+	d.closeToTrayCh.SetChecked(globalConfig.CloseToTray)
+	settingsForm.AddRow3("", d.closeToTrayCh.QWidget)
+	// ...or quit once nothing is left on screen
+	d.quitOnLastCh = qt.NewQCheckBox4("Quit when the last camera window is closed", nil)
+	d.quitOnLastCh.SetChecked(globalConfig.QuitOnLastClose)
+	d.quitOnLastCh.SetToolTip("Off: the app keeps running in the tray (tray → Show all cameras brings the windows back). The closed cameras open again on the next start.")
+	settingsForm.AddRow3("", d.quitOnLastCh.QWidget)
+	// tray balloon when a stream drops / comes back
+	d.notifyConnCh = qt.NewQCheckBox4("Notify when a camera disconnects or reconnects", nil)
+	d.notifyConnCh.SetChecked(globalConfig.NotifyConnLoss)
+	settingsForm.AddRow3("", d.notifyConnCh.QWidget)
+	d.eventsCSVCh = qt.NewQCheckBox4("Log connection events to events.csv", nil)
+	d.eventsCSVCh.SetChecked(globalConfig.EventsCSV)
+	d.eventsCSVCh.SetToolTip("Append every connect/disconnect to events.csv in the config folder (tray → Options → Events… shows the recent ones either way)")
+	settingsForm.AddRow3("", d.eventsCSVCh.QWidget)
+	if runtime.GOOS == "linux" {
+		d.xwaylandCh = qt.NewQCheckBox4("Use XWayland on Wayland sessions (restart)", nil)
+		d.xwaylandCh.SetChecked(globalConfig.PreferXWayland)
+		d.xwaylandCh.SetToolTip("Native Wayland doesn't let the app place its windows, so saved positions and formations can't be applied. XWayland can.")
+		settingsForm.AddRow3("", d.xwaylandCh.QWidget)
+	}
+	if runtime.GOOS == "darwin" {
+		d.hideDockCh = qt.NewQCheckBox4("Hide Dock icon, run from the tray only (restart)", nil)
+	} else {
+		d.hideDockCh = qt.NewQCheckBox4("Hide camera windows from the taskbar (restart)", nil)
+	}
+	d.hideDockCh.SetChecked(globalConfig.HideDockIcon)
+	d.hideDockCh.SetToolTip("The tray icon stays the way to reach the app")
+	settingsForm.AddRow3("", d.hideDockCh.QWidget)
+	d.startLoginCh = qt.NewQCheckBox4("Start at login", nil)
+	d.startLoginCh.SetChecked(globalConfig.StartAtLogin)
+	d.startLoginCh.SetToolTip("Start the app, and with it the cameras, when you log in (Windows Run key, macOS LaunchAgent, Linux autostart entry)")
+	settingsForm.AddRow3("", d.startLoginCh.QWidget)
+	d.startHiddenCh = qt.NewQCheckBox4("Start with the camera windows hidden in the tray", nil)
+	d.startHiddenCh.SetChecked(globalConfig.StartHidden)
+	d.startHiddenCh.SetToolTip("Cameras connect (and record) as usual, unless Pause cameras while hidden is checked; click the tray icon to show the windows")
+	settingsForm.AddRow3("", d.startHiddenCh.QWidget)
+
+	// outline of the active camera window (SPACE record target)
+	d.activeBorderCb = qt.NewQComboBox(nil)
+	d.activeBorderCb.AddItem("Borderless windows only")
+	d.activeBorderCb.AddItem("Always")
+	d.activeBorderCb.AddItem("Never")
+	switch globalConfig.ActiveBorder {
+	case "always":
+		d.activeBorderCb.SetCurrentIndex(1)
+	case "never":
+		d.activeBorderCb.SetCurrentIndex(2)
+	}
+	d.activeBorderColor = globalConfig.ActiveBorderColor
+	if d.activeBorderColor == "" {
+		d.activeBorderColor = "#0096ff"
+	}
+	d.activeBorderBtn = qt.NewQPushButton3("")
+	d.activeBorderBtn.SetToolTip("Border color")
+	d.activeBorderBtn.SetFixedWidth(40)
+	d.activeBorderBtn.SetStyleSheet("background: " + d.activeBorderColor + ";")
+	d.activeBorderBtn.OnClicked(func() {
+		c := qt.QColorDialog_GetColor3(qt.NewQColor6(d.activeBorderColor), d.dlg.QWidget, "Active window border")
+		if c.IsValid() {
+			d.activeBorderColor = c.Name()
+			d.activeBorderBtn.SetStyleSheet("background: " + d.activeBorderColor + ";")
+		}
+	})
+	borderRow := qt.NewQHBoxLayout(nil)
+	borderRow.AddWidget(d.activeBorderCb.QWidget)
+	borderRow.AddWidget(d.activeBorderBtn.QWidget)
+	settingsForm.AddRow4("Active window border:", borderRow.QLayout)
 
 	// --- Overlays ---
 	d.healthChipCh = qt.NewQCheckBox4("Show health chip (0–5)", nil)
@@ -144,6 +336,66 @@ func newSettingsDialog(parent *qt.QWidget) *SettingsDialog {
 	d.cpuCh.SetChecked(globalConfig.ShowCPUUsage)
 	settingsForm.AddRow3("", d.cpuCh.QWidget)
 
+	d.streamInfoCh = qt.NewQCheckBox4("Overlay stream info (codec, resolution, pixel format)", nil)
+	d.streamInfoCh.SetChecked(globalConfig.ShowStreamInfo)
+	settingsForm.AddRow3("", d.streamInfoCh.QWidget)
+
+	d.audioMeterCh = qt.NewQCheckBox4("Overlay audio level meter", nil)
+	d.audioMeterCh.SetChecked(globalConfig.ShowAudioMeter)
+	settingsForm.AddRow3("", d.audioMeterCh.QWidget)
+
+	// what a camera without any picture shows (after a few seconds)
+	d.noSignalColor = globalConfig.NoSignalColor
+	if d.noSignalColor == "" {
+		d.noSignalColor = "#000000"
+	}
+	d.noSignalColorBtn = qt.NewQPushButton3("")
+	d.noSignalColorBtn.SetToolTip("Background color")
+	d.noSignalColorBtn.SetFixedWidth(40)
+	d.noSignalColorBtn.SetStyleSheet("background: " + d.noSignalColor + ";")
+	d.noSignalColorBtn.OnClicked(func() {
+		c := qt.QColorDialog_GetColor3(qt.NewQColor6(d.noSignalColor), d.dlg.QWidget, "No signal background")
+		if c.IsValid() {
+			d.noSignalColor = c.Name()
+			d.noSignalColorBtn.SetStyleSheet("background: " + d.noSignalColor + ";")
+		}
+	})
+	d.noSignalTextEd = qt.NewQLineEdit(nil)
+	d.noSignalTextEd.SetPlaceholderText("Text, e.g. No Signal")
+	d.noSignalTextEd.SetText(globalConfig.NoSignalText)
+	noSignalRow := qt.NewQHBoxLayout(nil)
+	noSignalRow.AddWidget(d.noSignalColorBtn.QWidget)
+	noSignalRow.AddWidget(d.noSignalTextEd.QWidget)
+	settingsForm.AddRow4("No signal:", noSignalRow.QLayout)
+	d.noSignalImageEd = qt.NewQLineEdit(nil)
+	d.noSignalImageEd.SetPlaceholderText("Optional image, drawn centered")
+	d.noSignalImageEd.SetText(globalConfig.NoSignalImage)
+	noSignalBrowse := qt.NewQPushButton3("…")
+	noSignalBrowse.SetFixedWidth(40)
+	noSignalBrowse.OnClicked(func() {
+		path := qt.QFileDialog_GetOpenFileName4(d.dlg.QWidget, "No signal image", env.homeDir, "Images (*.png *.jpg *.jpeg *.bmp)")
+		if path != "" {
+			d.noSignalImageEd.SetText(path)
+		}
+	})
+	noSignalImageRow := qt.NewQHBoxLayout(nil)
+	noSignalImageRow.AddWidget(d.noSignalImageEd.QWidget)
+	noSignalImageRow.AddWidget(noSignalBrowse.QWidget)
+	settingsForm.AddRow4("No signal image:", noSignalImageRow.QLayout)
+	d.staleFrameSpin = qt.NewQSpinBox(nil)
+	d.staleFrameSpin.SetRange(0, 3600)
+	d.staleFrameSpin.SetSuffix(" s")
+	d.staleFrameSpin.SetSpecialValueText("Until reconnected")
+	d.staleFrameSpin.SetValue(globalConfig.StaleFrameSecs)
+	d.staleFrameSpin.SetToolTip("When a stream drops, its last picture stays up (dimmed) while it reconnects; after this long the window goes black")
+	settingsForm.AddRow3("Keep last picture:", d.staleFrameSpin.QWidget)
+	d.rotateSpin = qt.NewQSpinBox(nil)
+	d.rotateSpin.SetRange(5, 3600)
+	d.rotateSpin.SetSuffix(" s each")
+	d.rotateSpin.SetValue(formationRotateSecs())
+	d.rotateSpin.SetToolTip("Tray → Formations → Start rotation applies the formations one after another, each for this long")
+	settingsForm.AddRow3("Formation rotation:", d.rotateSpin.QWidget)
+
 	settingsPage.SetLayout(settingsForm.QLayout)
 
 	// ===== Advanced tab (scaffold) =====
@@ -186,12 +438,283 @@ func newSettingsDialog(parent *qt.QWidget) *SettingsDialog {
 	}
 	d.limitGuiCh.OnToggled(func(bool) { enableRefreshControls() })
 	enableRefreshControls()
+
+	// audio output device (Oto can only pick one at startup)
+	d.audioDeviceCb = qt.NewQComboBox(nil)
+	d.audioDeviceCb.AddItem("System default")
+	d.audioDeviceIDs = []string{""}
+	found := globalConfig.AudioDevice == ""
+	for _, o := range listAudioOutputs() {
+		d.audioDeviceCb.AddItem(o.Name)
+		d.audioDeviceIDs = append(d.audioDeviceIDs, o.ID)
+		found = found || o.ID == globalConfig.AudioDevice
+	}
+	if !found {
+		// keep a configured but currently unplugged device selectable
+		d.audioDeviceCb.AddItem(globalConfig.AudioDevice + " (not present)")
+		d.audioDeviceIDs = append(d.audioDeviceIDs, globalConfig.AudioDevice)
+	}
+	if len(d.audioDeviceIDs) == 1 {
+		d.audioDeviceCb.SetEnabled(false)
+		d.audioDeviceCb.SetToolTip("Output selection is not available on this platform; the system default output is used")
+	} else {
+		d.audioDeviceCb.SetToolTip("Takes effect after restarting the app")
+	}
+	for i, id := range d.audioDeviceIDs {
+		if id == globalConfig.AudioDevice {
+			d.audioDeviceCb.SetCurrentIndex(i)
+		}
+	}
+	advancedForm.AddRow3("Audio output (restart):", d.audioDeviceCb.QWidget)
+
+	// audio underrun handling
+	d.audioUnderrunCb = qt.NewQComboBox(nil)
+	d.audioUnderrunCb.AddItem("silence")
+	d.audioUnderrunCb.AddItem("skip")
+	d.audioUnderrunCb.SetToolTip("silence: fill gaps with silence to keep timing (no clicks); skip: wait for audio data")
+	if globalConfig.AudioUnderrun == "skip" {
+		d.audioUnderrunCb.SetCurrentIndex(1)
+	}
+	advancedForm.AddRow3("Audio underrun:", d.audioUnderrunCb.QWidget)
+
+	// audio output buffer (latency)
+	d.audioBufferSpin = qt.NewQSpinBox(nil)
+	d.audioBufferSpin.SetRange(0, 2000)
+	d.audioBufferSpin.SetSingleStep(20)
+	d.audioBufferSpin.SetSuffix(" ms")
+	d.audioBufferSpin.SetSpecialValueText("Driver default")
+	d.audioBufferSpin.SetValue(globalConfig.AudioBufferMs)
+	d.audioBufferSpin.SetToolTip("Larger buffers smooth out stuttering audio at the cost of latency. Takes effect after restarting the app")
+	advancedForm.AddRow3("Audio buffer:", d.audioBufferSpin.QWidget)
+
+	// preview fps cap for cameras that don't set their own
+	d.defMaxFPSSpin = qt.NewQSpinBox(nil)
+	d.defMaxFPSSpin.SetRange(0, 120)
+	d.defMaxFPSSpin.SetSuffix(" fps")
+	d.defMaxFPSSpin.SetSpecialValueText("Unlimited")
+	d.defMaxFPSSpin.SetValue(globalConfig.DefaultMaxFPS)
+	d.defMaxFPSSpin.SetToolTip("Drop decoded frames above this rate to save CPU; recordings keep the full rate. Per-camera Max FPS overrides it.")
+	advancedForm.AddRow3("Default max FPS:", d.defMaxFPSSpin.QWidget)
+	d.defMaxKbpsSpin = qt.NewQSpinBox(nil)
+	d.defMaxKbpsSpin.SetRange(0, 1000000)
+	d.defMaxKbpsSpin.SetSingleStep(500)
+	d.defMaxKbpsSpin.SetSuffix(" kbps")
+	d.defMaxKbpsSpin.SetSpecialValueText("Off")
+	d.defMaxKbpsSpin.SetValue(globalConfig.DefaultMaxKbps)
+	d.defMaxKbpsSpin.SetToolTip(fmt.Sprintf("Show a warning on a camera whose input stays above this rate for %d s (e.g. one flooding the network). Per-camera Max bitrate overrides it.", bitrateSustain))
+	advancedForm.AddRow3("Bitrate warning above:", d.defMaxKbpsSpin.QWidget)
+
+	// decoder threads for cameras that don't set their own
+	d.threadsCb = qt.NewQComboBox(nil)
+	d.threadsCb.AddItem("FFmpeg default")
+	d.threadsCb.AddItem("Auto (cores ÷ cameras)")
+	d.threadsCb.AddItem("Fixed")
+	d.threadsCb.SetToolTip("FFmpeg default uses every core for each camera, which oversubscribes the CPU with many cameras. Auto splits the cores between the enabled cameras. Applies on the next (re)connect")
+	d.threadsSpin = qt.NewQSpinBox(nil)
+	d.threadsSpin.SetRange(1, 64)
+	d.threadsSpin.SetSuffix(" threads")
+	d.threadsSpin.SetValue(max(1, globalConfig.DecodeThreads))
+	switch t := globalConfig.DecodeThreads; {
+	case t < 0:
+		d.threadsCb.SetCurrentIndex(1)
+	case t > 0:
+		d.threadsCb.SetCurrentIndex(2)
+	}
+	d.threadsSpin.SetEnabled(d.threadsCb.CurrentIndex() == 2)
+	d.threadsCb.OnCurrentIndexChanged(func(i int) { d.threadsSpin.SetEnabled(i == 2) })
+	threadsRow := qt.NewQHBoxLayout(nil)
+	threadsRow.AddWidget(d.threadsCb.QWidget)
+	threadsRow.AddWidget(d.threadsSpin.QWidget)
+	advancedForm.AddRow4("Decode threads:", threadsRow.QLayout)
+
+	// preview resolution cap (bounds memory with many high-res cameras)
+	d.maxDecodeCb = qt.NewQComboBox(nil)
+	d.maxDecodeCb.SetEditable(true)
+	for _, sz := range []string{"Full resolution", "2560x1440", "1920x1080", "1280x720", "640x360"} {
+		d.maxDecodeCb.AddItem(sz)
+	}
+	if mw, mh := maxDecodeSize(); mw > 0 {
+		d.maxDecodeCb.SetCurrentText(fmt.Sprintf("%dx%d", mw, mh))
+	}
+	d.maxDecodeCb.SetToolTip("Downscale larger streams (WxH) before they are kept for display, so many 4K cameras don't exhaust memory. Previews get softer; recordings keep the full resolution. Applies on the next (re)connect")
+	advancedForm.AddRow3("Max preview resolution:", d.maxDecodeCb.QWidget)
+
+	// reconnect watchdog escalation
+	d.restartAfterSpin = qt.NewQSpinBox(nil)
+	d.restartAfterSpin.SetRange(0, 100)
+	d.restartAfterSpin.SetSuffix(" failures")
+	d.restartAfterSpin.SetSpecialValueText("Never")
+	d.restartAfterSpin.SetValue(restartAfter())
+	d.restartAfterSpin.SetToolTip("After this many failed reconnects in a row, reset the camera's stream completely instead of retrying with the same state")
+	advancedForm.AddRow3("Full reset after:", d.restartAfterSpin.QWidget)
+
+	// staggered start: not every stream requested from the NVR at once
+	d.staggerSpin = qt.NewQSpinBox(nil)
+	d.staggerSpin.SetRange(0, 10000)
+	d.staggerSpin.SetSingleStep(100)
+	d.staggerSpin.SetSuffix(" ms")
+	d.staggerSpin.SetSpecialValueText("All at once")
+	d.staggerSpin.SetValue(globalConfig.StartStaggerMs)
+	d.staggerSpin.SetToolTip("At app start and after waking from sleep, each camera connects this long after the one before it")
+	advancedForm.AddRow3("Stagger camera starts:", d.staggerSpin.QWidget)
+
+	// decode errors of noisy cameras; per camera in its dialog too
+	d.errDetectCb = choiceCombo("Default (careful)", errDetectLevels, globalConfig.DefaultErrDetect)
+	d.errDetectCb.SetToolTip("err_detect: how strictly the decoder checks the stream. Stricter levels report more errors (and drops) on noisy cameras; ignore shows whatever decodes.")
+	d.errConcealCb = choiceCombo("Default (guess_mvs+deblock)", concealModes, globalConfig.DefaultErrConceal)
+	d.errConcealCb.SetToolTip("Error concealment: how damaged areas of a picture are filled in")
+	errRow := qt.NewQHBoxLayout(nil)
+	errRow.AddWidget(d.errDetectCb.QWidget)
+	errRow.AddWidget(d.errConcealCb.QWidget)
+	advancedForm.AddRow4("Decode errors:", errRow.QLayout)
+	d.hideCorruptCh = qt.NewQCheckBox4("Keep the last good frame instead of showing corrupt ones", nil)
+	d.hideCorruptCh.SetChecked(globalConfig.HideCorruptFrames)
+	d.hideCorruptCh.SetToolTip("After a decode error the picture freezes until the next keyframe instead of showing smeared or gray artifacts. Applies on the next reconnect.")
+	advancedForm.AddRow3("", d.hideCorruptCh.QWidget)
+
+	// recording file names
+	d.recNameEdit = qt.NewQLineEdit(nil)
+	d.recNameEdit.SetPlaceholderText(defaultRecordNameTemplate)
+	d.recNameEdit.SetText(globalConfig.RecordNameTemplate)
+	d.recNameEdit.SetToolTip("File name for new recordings, without extension. Tokens: {camera} {id} {date} {time} {seq}. Empty = " + defaultRecordNameTemplate)
+	advancedForm.AddRow3("Recording file name:", d.recNameEdit.QWidget)
+	// the recording pill shows the free space; warn before it runs out
+	d.lowDiskSpin = qt.NewQSpinBox(nil)
+	d.lowDiskSpin.SetRange(0, 1000000)
+	d.lowDiskSpin.SetSingleStep(512)
+	d.lowDiskSpin.SetSuffix(" MB")
+	d.lowDiskSpin.SetSpecialValueText("Off")
+	d.lowDiskSpin.SetValue(globalConfig.LowDiskMB)
+	d.lowDiskSpin.SetToolTip("Show a tray warning when the disk a camera records to has less free space than this")
+	d.lowDiskStopCh = qt.NewQCheckBox4("Stop recording", nil)
+	d.lowDiskStopCh.SetChecked(globalConfig.LowDiskStop)
+	lowDiskRow := qt.NewQHBoxLayout(nil)
+	lowDiskRow.AddWidget(d.lowDiskSpin.QWidget)
+	lowDiskRow.AddWidget(d.lowDiskStopCh.QWidget)
+	advancedForm.AddRow4("Low disk warning below:", lowDiskRow.QLayout)
+	syncLowDisk := func() { d.lowDiskStopCh.SetEnabled(d.lowDiskSpin.Value() > 0) }
+	d.lowDiskSpin.OnValueChanged(func(int) { syncLowDisk() })
+	syncLowDisk()
+
+	d.snapQualitySpin = qt.NewQSpinBox(nil)
+	d.snapQualitySpin.SetRange(1, 100)
+	d.snapQualitySpin.SetValue(snapshotQuality())
+	d.snapQualitySpin.SetToolTip("JPEG quality of snapshots (S key and periodic snapshots)")
+	advancedForm.AddRow3("Snapshot JPEG quality:", d.snapQualitySpin.QWidget)
+	d.contactSheetCh = qt.NewQCheckBox4("Snapshot all cameras: also save a contact sheet", nil)
+	d.contactSheetCh.SetChecked(globalConfig.SnapshotContactSheet)
+	d.contactSheetCh.SetToolTip("Tray → Snapshot all cameras adds contact-sheet.jpg with every camera tiled in one picture")
+	advancedForm.AddRow3("", d.contactSheetCh.QWidget)
+
+	// grid recording output (tray → Record grid)
+	d.gridSizeCb = qt.NewQComboBox(nil)
+	d.gridSizeCb.SetEditable(true)
+	for _, sz := range []string{"640x360", "1280x720", "1920x1080", "2560x1440", "3840x2160"} {
+		d.gridSizeCb.AddItem(sz)
+	}
+	gw, gh := gridRecordSize()
+	d.gridSizeCb.SetCurrentText(fmt.Sprintf("%dx%d", gw, gh))
+	d.gridSizeCb.SetToolTip("Size of the composite video (WxH); cameras are tiled and letterboxed inside it")
+	d.gridFPSSpin = qt.NewQSpinBox(nil)
+	d.gridFPSSpin.SetRange(1, 60)
+	d.gridFPSSpin.SetSuffix(" fps")
+	d.gridFPSSpin.SetValue(gridRecordFPS())
+	gridRow := qt.NewQHBoxLayout(nil)
+	gridRow.AddWidget(d.gridSizeCb.QWidget)
+	gridRow.AddWidget(d.gridFPSSpin.QWidget)
+	advancedForm.AddRow4("Grid recording:", gridRow.QLayout)
+
+	// camera context menu → Open in external player
+	d.playerEd = qt.NewQLineEdit(nil)
+	d.playerEd.SetPlaceholderText("System default for rtsp:// links")
+	d.playerEd.SetText(globalConfig.ExternalPlayer)
+	d.playerEd.SetToolTip("VLC, mpv, ffplay… The stream URL is passed as the only argument")
+	playerBrowse := qt.NewQPushButton3("…")
+	playerBrowse.SetFixedWidth(40)
+	playerBrowse.OnClicked(func() {
+		path := qt.QFileDialog_GetOpenFileName3(d.dlg.QWidget, "External player", env.homeDir)
+		if path != "" {
+			d.playerEd.SetText(path)
+		}
+	})
+	playerRow := qt.NewQHBoxLayout(nil)
+	playerRow.AddWidget(d.playerEd.QWidget)
+	playerRow.AddWidget(playerBrowse.QWidget)
+	advancedForm.AddRow4("External player:", playerRow.QLayout)
+
+	// per-camera log files (in addition to debug.log)
+	d.perCamLogsCh = qt.NewQCheckBox4("Write per-camera log files (config dir → logs/)", nil)
+	d.perCamLogsCh.SetChecked(globalConfig.PerCameraLogs)
+	advancedForm.AddRow3("", d.perCamLogsCh.QWidget)
+	// debug.log rotation and format
+	d.logMaxSpin = qt.NewQSpinBox(nil)
+	d.logMaxSpin.SetRange(0, 1024)
+	d.logMaxSpin.SetSuffix(" MB")
+	d.logMaxSpin.SetSpecialValueText(fmt.Sprintf("Default (%d MB)", defaultLogMaxMB))
+	d.logMaxSpin.SetValue(globalConfig.LogMaxMB)
+	d.logMaxSpin.SetToolTip("Start a new debug.log when it reaches this size")
+	d.logKeepSpin = qt.NewQSpinBox(nil)
+	d.logKeepSpin.SetRange(0, 100)
+	d.logKeepSpin.SetPrefix("keep ")
+	d.logKeepSpin.SetSpecialValueText(fmt.Sprintf("keep %d (default)", defaultLogKeep))
+	d.logKeepSpin.SetValue(globalConfig.LogKeep)
+	d.logKeepSpin.SetToolTip("How many old files (debug.log.1, .2, …) are kept")
+	d.logFormatCb = qt.NewQComboBox(nil)
+	d.logFormatCb.AddItem("Text")
+	d.logFormatCb.AddItem("JSON")
+	d.logFormatCb.SetToolTip("JSON writes one object per line: time, level, camera, source, msg")
+	if strings.EqualFold(globalConfig.LogFormat, "json") {
+		d.logFormatCb.SetCurrentIndex(1)
+	}
+	logRow := qt.NewQHBoxLayout(nil)
+	logRow.AddWidget(d.logMaxSpin.QWidget)
+	logRow.AddWidget(d.logKeepSpin.QWidget)
+	logRow.AddWidget(d.logFormatCb.QWidget)
+	advancedForm.AddRow4("Log file:", logRow.QLayout)
 	advancedPage.SetLayout(advancedForm.QLayout)
 
+	// ===== Keys tab =====
+	keysPage := qt.NewQWidget(nil)
+	keysForm := qt.NewQFormLayout(nil)
+	keysHelp := qt.NewQLabel3("Shortcuts in camera windows. Click a field and press the new key; Clear unbinds it. Double-click still toggles fullscreen.")
+	keysHelp.SetWordWrap(true)
+	keysForm.AddRowWithWidget(keysHelp.QWidget)
+	d.keyEdits = map[string]*qt.QKeySequenceEdit{}
+	for _, a := range keyActions {
+		a := a
+		ed := qt.NewQKeySequenceEdit(nil)
+		ed.SetKeySequence(qt.QKeySequence_FromString2(keyBinding(a), qt.QKeySequence__PortableText))
+		ed.SetToolTip("Default: " + a.Def)
+		btnClear := qt.NewQPushButton3("Clear")
+		btnClear.OnClicked(func() { ed.Clear() })
+		btnDef := qt.NewQPushButton3("Default (" + a.Def + ")")
+		btnDef.OnClicked(func() {
+			ed.SetKeySequence(qt.QKeySequence_FromString2(a.Def, qt.QKeySequence__PortableText))
+		})
+		row := qt.NewQHBoxLayout(nil)
+		row.AddWidget(ed.QWidget)
+		row.AddWidget(btnClear.QWidget)
+		row.AddWidget(btnDef.QWidget)
+		keysForm.AddRow4(a.Label+":", row.QLayout)
+		d.keyEdits[a.ID] = ed
+	}
+	// system-wide, not tied to a camera window
+	d.panicKeyEdit = qt.NewQKeySequenceEdit(nil)
+	d.panicKeyEdit.SetKeySequence(qt.QKeySequence_FromString2(globalConfig.PanicHideKey, qt.QKeySequence__PortableText))
+	d.panicKeyEdit.SetToolTip("Works from any app: hides every camera window, pressing it again brings them back. The cameras keep running meanwhile. Letters, digits, Space and F1–F24, usually with modifiers (e.g. Ctrl+Alt+H).")
+	btnPanicClear := qt.NewQPushButton3("Clear")
+	btnPanicClear.OnClicked(func() { d.panicKeyEdit.Clear() })
+	panicRow := qt.NewQHBoxLayout(nil)
+	panicRow.AddWidget(d.panicKeyEdit.QWidget)
+	panicRow.AddWidget(btnPanicClear.QWidget)
+	keysForm.AddRow4("Hide/show all (global):", panicRow.QLayout)
+	keysPage.SetLayout(keysForm.QLayout)
+
 	// Add tabs (Cameras, Settings, Advanced)
 	_ = d.tabs.AddTab(d.camPage, "Cameras")
 	_ = d.tabs.AddTab(settingsPage, "Settings")
 	_ = d.tabs.AddTab(advancedPage, "Advanced")
+	_ = d.tabs.AddTab(keysPage, "Keys")
 
 	// ===== Footer (Save / Cancel) =====
 	d.btnSave = qt.NewQPushButton5("Save", nil)
@@ -214,26 +737,51 @@ func newSettingsDialog(parent *qt.QWidget) *SettingsDialog {
 	d.btnAdd.OnClicked(func() { d.onAdd() })
 	d.btnEdit.OnClicked(func() { d.onEdit() })
 	d.btnRemove.OnClicked(func() { d.onRemove() })
+	d.btnEnable.OnClicked(func() { d.onSetEnabled(true) })
+	d.btnDisable.OnClicked(func() { d.onSetEnabled(false) })
 	d.btnSave.OnClicked(func() { d.onSave() })
 	d.btnCancel.OnClicked(func() { d.dlg.Reject() })
 
-	// Enable/disable edit/remove based on selection
+	// Enable/disable the buttons based on selection (Edit takes exactly one camera)
 	updateButtons := func() {
-		has := d.list.CurrentRow() >= 0
-		d.btnEdit.SetEnabled(has)
-		d.btnRemove.SetEnabled(has)
+		n := len(d.list.SelectedItems())
+		d.btnEdit.SetEnabled(n == 1)
+		d.btnRemove.SetEnabled(n > 0)
+		d.btnEnable.SetEnabled(n > 0)
+		d.btnDisable.SetEnabled(n > 0)
 	}
-	d.list.OnCurrentRowChanged(func(int) { updateButtons() })
+	d.list.OnItemSelectionChanged(updateButtons)
 	updateButtons()
 
 	// Double-click to edit
 	d.list.OnItemDoubleClicked(func(*qt.QListWidgetItem) { d.onEdit() })
+	// Keyboard: arrows move (list default), Enter edits, Delete removes.
+	// Handled here so Enter doesn't fall through to the dialog's default button.
+	d.list.OnKeyPressEvent(func(super func(event *qt.QKeyEvent), ev *qt.QKeyEvent) {
+		switch qt.Key(ev.Key()) {
+		case qt.Key_Return, qt.Key_Enter:
+			if len(d.list.SelectedItems()) == 1 {
+				d.onEdit()
+			}
+			ev.Accept()
+		case qt.Key_Delete, qt.Key_Backspace:
+			row := d.list.CurrentRow()
+			d.onRemove()
+			if n := d.list.Count(); n > 0 && d.list.CurrentRow() < 0 {
+				d.list.SetCurrentRow(min(row, n-1)) // keep a selection to go on with
+			}
+			ev.Accept()
+		default:
+			super(ev)
+		}
+	})
 
 	d.dlg.Resize(560, 420)
 	d.dlg.Show()
 	d.dlg.Raise()
 	d.dlg.ActivateWindow()
 	d.dlg.SetFocus()
+	d.list.SetFocus() // Cameras tab is first: arrows/Enter work right away
 	return d
 }
 
@@ -245,15 +793,39 @@ func (d *SettingsDialog) refreshList() {
 		if title == "" {
 			title = c.URL
 		}
+		if c.Disabled {
+			title += " (disabled)"
+		}
 		item := qt.NewQListWidgetItem7(title, d.list)
 		_ = item // keep reference alive per miqt semantics
 	}
 }
 
+// selectedRows returns the selected camera rows in ascending order.
+func (d *SettingsDialog) selectedRows() []int {
+	var rows []int
+	for _, it := range d.list.SelectedItems() {
+		if r := d.list.Row(it); r >= 0 && r < len(d.cams) {
+			rows = append(rows, r)
+		}
+	}
+	slices.Sort(rows)
+	return rows
+}
+
+// reselect selects rows again after refreshList.
+func (d *SettingsDialog) reselect(rows []int) {
+	for _, r := range rows {
+		if it := d.list.Item(r); it != nil {
+			it.SetSelected(true)
+		}
+	}
+}
+
 // --- Button handlers ---
 
 func (d *SettingsDialog) onAdd() {
-	var c CameraConfig
+	c := CameraConfig{VideoStreamIndex: -1}
 	if ok := editCameraDialog(d.dlg.QWidget, &c); ok {
 		// Working copy
 		d.cams = append(d.cams, c)
@@ -269,7 +841,7 @@ func (d *SettingsDialog) onAdd() {
 		if len(wins) < len(globalConfig.Cameras) {
 			wins = append(wins, make([]*CamWindow, len(globalConfig.Cameras)-len(wins))...)
 		}
-		if DEBUG {
+		if debugLogging() {
 			log.Printf("new idx: %d  total cams: %d  wins: %d  total in config: %d",
 				newIdx, len(d.cams), len(wins), len(globalConfig.Cameras))
 		}
@@ -319,7 +891,15 @@ func (d *SettingsDialog) onEdit() {
 				if w == nil {
 					continue
 				}
-				if w.cfg.ID == id {
+				if w.cfg.ID != id {
+					continue
+				}
+				// muting alone doesn't need a reconnect
+				same := w.cfg
+				same.Mute = edited.Mute
+				if reflect.DeepEqual(same, edited) {
+					w.applyMute(edited.Mute)
+				} else {
 					w.RestartWith(edited, "re-open")
 				}
 			}
@@ -344,50 +924,67 @@ func (d *SettingsDialog) onEdit() {
 }
 
 func (d *SettingsDialog) onRemove() {
-	row := d.list.CurrentRow()
-	if row < 0 || row >= len(d.cams) {
+	rows := d.selectedRows()
+	if len(rows) == 0 {
 		return
 	}
 
+	names := make([]string, 0, len(rows))
+	ids := map[string]bool{}
+	for _, r := range rows {
+		names = append(names, safeCamTitle(d.cams[r]))
+		ids[d.cams[r].ID] = true
+	}
+	const maxNames = 15
+	if len(names) > maxNames {
+		names = append(names[:maxNames], fmt.Sprintf("… and %d more", len(names)-maxNames))
+	}
 	mb := qt.NewQMessageBox(d.dlg.QWidget)
 	mb.SetWindowTitle("Confirm delete")
 	mb.SetIcon(qt.QMessageBox__Question)
-	name := d.cams[row].Name
-	id := d.cams[row].ID
-	if name == "" {
-		name = d.cams[row].URL
+	if len(rows) == 1 {
+		mb.SetText(fmt.Sprintf("Delete camera:\n\n%s\n\nAre you sure?", names[0]))
+	} else {
+		mb.SetText(fmt.Sprintf("Delete %d cameras:\n\n%s\n\nAre you sure?", len(rows), strings.Join(names, "\n")))
 	}
-	mb.SetText(fmt.Sprintf("Delete camera:\n\n%s\n\nAre you sure?", name))
 	mb.SetStandardButtons(qt.QMessageBox__Yes | qt.QMessageBox__No)
 	if mb.Exec() != int(qt.QMessageBox__Yes) {
 		return
 	}
 
-	// Remove
-	d.cams = append(d.cams[:row], d.cams[row+1:]...)
-	d.refreshList()
-	if row >= len(d.cams) {
-		row = len(d.cams) - 1
+	// close the corresponding camera windows
+	for _, w := range wins {
+		if w != nil && ids[w.cfg.ID] {
+			w.SuppressOnClosedOnce() // WindowWasClosed would disable whatever camera takes its index
+			w.win.Close()
+		}
 	}
-	d.list.SetCurrentRow(row)
 
-	// Remove from global config (in-memory)
+	// Remove from the working copy, the global config (in-memory) and the
+	// window slots; last row first so the remaining rows stay valid
 	configMu.Lock()
-	if row < len(globalConfig.Cameras) {
-		globalConfig.Cameras = append(globalConfig.Cameras[:row], globalConfig.Cameras[row+1:]...)
+	for i := len(rows) - 1; i >= 0; i-- {
+		r := rows[i]
+		d.cams = slices.Delete(d.cams, r, r+1)
+		if r < len(globalConfig.Cameras) {
+			globalConfig.Cameras = slices.Delete(globalConfig.Cameras, r, r+1)
+		}
+		if r < len(wins) {
+			wins = slices.Delete(wins, r, r+1)
+		}
 	}
 	configMu.Unlock()
-
-	// close the corresponding camera window
-	for _, w := range wins {
-		if w == nil {
-			continue
-		}
-		if w.cfg.ID == id {
-			w.win.Close()
+	for i, w := range wins {
+		if w != nil {
+			w.idx = i
 		}
 	}
 
+	d.refreshList()
+	if row := min(rows[0], len(d.cams)-1); row >= 0 {
+		d.list.SetCurrentRow(row)
+	}
+
 	// rebuild the tray and context menus
 	if tray != nil {
 		tray.mu.Lock()
@@ -409,6 +1006,26 @@ func (d *SettingsDialog) onRemove() {
 
 }
 
+// onSetEnabled enables or disables all selected cameras, opening or closing
+// their windows like the tray checkboxes do.
+func (d *SettingsDialog) onSetEnabled(on bool) {
+	rows := d.selectedRows()
+	if len(rows) == 0 || tray == nil {
+		return
+	}
+	tray.SetCamerasEnabled(rows, on)
+
+	configMu.Lock()
+	for _, r := range rows {
+		if r < len(globalConfig.Cameras) {
+			d.cams[r].Disabled = globalConfig.Cameras[r].Disabled
+		}
+	}
+	configMu.Unlock()
+	d.refreshList()
+	d.reselect(rows)
+}
+
 func (d *SettingsDialog) onSave() {
 	// Persist the working copy to global config + YAML
 	configMu.Lock()
@@ -460,19 +1077,125 @@ func (d *SettingsDialog) onSave() {
 	}
 	globalConfig.NoWindowsTitles = d.noWinTitlesCh.IsChecked()
 	globalConfig.SnapEnabled = d.snapCh.IsChecked()
+	globalConfig.SnapDistance = d.snapDistSpin.Value()
+	globalConfig.MinWindowW = d.minWinWSpin.Value()
+	globalConfig.MinWindowH = d.minWinHSpin.Value()
+	if globalConfig.MinWindowW == defaultMinWindowW && globalConfig.MinWindowH == defaultMinWindowH {
+		globalConfig.MinWindowW, globalConfig.MinWindowH = 0, 0 // keep the config clean
+	}
+	if globalConfig.SnapDistance == defaultSnapDistance {
+		globalConfig.SnapDistance = 0
+	}
 	globalConfig.AlwaysOnTopAll = d.alwaysOnTopAllCh.IsChecked()
 	globalConfig.ActiveOnTray = d.activateOnTrayCh.IsChecked()
-	globalConfig.ActiveOnWin = d.activateOnWinCh.IsChecked()
+	globalConfig.TrayClick = ""
+	if d.trayClickCb.CurrentIndex() == 1 {
+		globalConfig.TrayClick = "toggle"
+	}
+	globalConfig.HidePauses = d.hidePausesCh.IsChecked()
+	globalConfig.WinClick = [...]string{"", winClickRaiseAll, winClickRaiseClicked}[d.winClickCb.CurrentIndex()]
+	globalConfig.CloseToTray = d.closeToTrayCh.IsChecked()
+	globalConfig.QuitOnLastClose = d.quitOnLastCh.IsChecked()
+	globalConfig.NotifyConnLoss = d.notifyConnCh.IsChecked()
+	globalConfig.EventsCSV = d.eventsCSVCh.IsChecked()
+	if d.xwaylandCh != nil {
+		globalConfig.PreferXWayland = d.xwaylandCh.IsChecked()
+	}
+	globalConfig.HideDockIcon = d.hideDockCh.IsChecked()
+	globalConfig.StartHidden = d.startHiddenCh.IsChecked()
+	loginChanged := d.startLoginCh.IsChecked() != globalConfig.StartAtLogin
+	globalConfig.StartAtLogin = d.startLoginCh.IsChecked()
+	globalConfig.ActiveBorder = []string{"", "always", "never"}[d.activeBorderCb.CurrentIndex()]
+	globalConfig.ActiveBorderColor = d.activeBorderColor
 	globalConfig.HealthChip = d.healthChipCh.IsChecked()
 	globalConfig.ShowFPS = d.fpsCh.IsChecked()
 	globalConfig.ShowBitrate = d.bitrateCh.IsChecked()
 	globalConfig.ShowDrops = d.dropsCh.IsChecked()
 	globalConfig.ShowCPUUsage = d.cpuCh.IsChecked()
+	globalConfig.ShowAudioMeter = d.audioMeterCh.IsChecked()
+	globalConfig.ShowStreamInfo = d.streamInfoCh.IsChecked()
+	globalConfig.NoSignalColor = d.noSignalColor
+	if strings.EqualFold(d.noSignalColor, "#000000") {
+		globalConfig.NoSignalColor = ""
+	}
+	globalConfig.NoSignalText = strings.TrimSpace(d.noSignalTextEd.Text())
+	globalConfig.NoSignalImage = strings.TrimSpace(d.noSignalImageEd.Text())
+	globalConfig.StaleFrameSecs = d.staleFrameSpin.Value()
+	globalConfig.FormationRotateSecs = d.rotateSpin.Value()
+	globalConfig.ExternalPlayer = strings.TrimSpace(d.playerEd.Text())
+	// keep only the bindings that differ from the defaults ("" = unbound)
+	globalConfig.KeyBindings = nil
+	for _, a := range keyActions {
+		k := d.keyEdits[a.ID].KeySequence().ToStringWithFormat(qt.QKeySequence__PortableText)
+		if k != a.Def {
+			if globalConfig.KeyBindings == nil {
+				globalConfig.KeyBindings = map[string]string{}
+			}
+			globalConfig.KeyBindings[a.ID] = k
+		}
+	}
+	globalConfig.PanicHideKey = d.panicKeyEdit.KeySequence().ToStringWithFormat(qt.QKeySequence__PortableText)
+	applyGlobalHotkey()
 	globalConfig.LimitGuiRefresh = d.limitGuiCh.IsChecked()
 	globalConfig.GuiRefreshMs = d.guiRefreshSlider.Value()
 	globalConfig.RepaintOnNewFrame = d.repaintOnNewCh.IsChecked()
+	globalConfig.AudioUnderrun = d.audioUnderrunCb.CurrentText()
+	globalConfig.AudioBufferMs = d.audioBufferSpin.Value()
+	if i := d.audioDeviceCb.CurrentIndex(); i >= 0 && i < len(d.audioDeviceIDs) && d.audioDeviceCb.IsEnabled() {
+		globalConfig.AudioDevice = d.audioDeviceIDs[i]
+	}
+	globalConfig.PerCameraLogs = d.perCamLogsCh.IsChecked()
+	globalConfig.LogMaxMB = d.logMaxSpin.Value()
+	globalConfig.LogKeep = d.logKeepSpin.Value()
+	globalConfig.LogFormat = ""
+	if d.logFormatCb.CurrentIndex() == 1 {
+		globalConfig.LogFormat = "json"
+	}
+	applyLogSettings()
+	globalConfig.DefaultMaxFPS = d.defMaxFPSSpin.Value()
+	globalConfig.DefaultMaxKbps = d.defMaxKbpsSpin.Value()
+	switch d.threadsCb.CurrentIndex() {
+	case 1:
+		globalConfig.DecodeThreads = -1
+	case 2:
+		globalConfig.DecodeThreads = d.threadsSpin.Value()
+	default:
+		globalConfig.DecodeThreads = 0
+	}
+	globalConfig.RecordNameTemplate = strings.TrimSpace(d.recNameEdit.Text())
+	globalConfig.LowDiskMB = d.lowDiskSpin.Value()
+	globalConfig.LowDiskStop = d.lowDiskStopCh.IsChecked()
+	globalConfig.SnapshotQuality = d.snapQualitySpin.Value()
+	globalConfig.SnapshotContactSheet = d.contactSheetCh.IsChecked()
+	if globalConfig.SnapshotQuality == defaultSnapshotQuality {
+		globalConfig.SnapshotQuality = 0
+	}
+	if globalConfig.RecordNameTemplate == defaultRecordNameTemplate {
+		globalConfig.RecordNameTemplate = ""
+	}
+	globalConfig.GridRecordSize = strings.TrimSpace(d.gridSizeCb.CurrentText())
+	globalConfig.MaxDecodeRes = strings.TrimSpace(d.maxDecodeCb.CurrentText())
+	if mw, _ := maxDecodeSize(); mw == 0 {
+		globalConfig.MaxDecodeRes = "" // "Full resolution" or not a WxH: no cap
+	}
+	globalConfig.GridRecordFPS = d.gridFPSSpin.Value()
+	if n := d.restartAfterSpin.Value(); n > 0 {
+		globalConfig.RestartAfterFailures = n
+	} else {
+		globalConfig.RestartAfterFailures = -1 // "Never"
+	}
+	globalConfig.StartStaggerMs = d.staggerSpin.Value()
+	globalConfig.DefaultErrDetect = choiceValue(d.errDetectCb)
+	globalConfig.DefaultErrConceal = choiceValue(d.errConcealCb)
+	globalConfig.HideCorruptFrames = d.hideCorruptCh.IsChecked()
 	configMu.Unlock()
 
+	if loginChanged {
+		if err := applyAutostart(); err != nil {
+			qt.QMessageBox_Warning(d.dlg.QWidget, "Start at login", "Could not update the login item:\n"+err.Error())
+		}
+	}
+
 	// Apply immediately to open windows (frameless ↔ titled)
 	for i, w := range wins {
 		if w == nil || w.win == nil {
@@ -500,6 +1223,9 @@ func (d *SettingsDialog) onSave() {
 		w.win.Show()
 
 		w.ApplyGuiRefreshSettings()
+		if w.view != nil {
+			w.view.SetMinimumSize2(minWindowSize())
+		}
 	}
 
 	if err := SaveConfig(); err != nil {
@@ -518,6 +1244,21 @@ func (d *SettingsDialog) onSave() {
 
 // --- Add/Edit dialog ---
 
+// cameraGroups lists the distinct camera groups in config order (for the editor's dropdown).
+func cameraGroups() []string {
+	configMu.Lock()
+	defer configMu.Unlock()
+	var out []string
+	seen := map[string]bool{}
+	for _, c := range globalConfig.Cameras {
+		if g := strings.TrimSpace(c.Group); g != "" && !seen[g] {
+			seen[g] = true
+			out = append(out, g)
+		}
+	}
+	return out
+}
+
 func editCameraDialog(parent *qt.QWidget, c *CameraConfig) bool {
 	dlg := qt.NewQDialog(parent)
 	dlg.SetWindowTitle("Camera")
@@ -525,12 +1266,41 @@ func editCameraDialog(parent *qt.QWidget, c *CameraConfig) bool {
 	form := qt.NewQFormLayout(nil)
 
 	edName := qt.NewQLineEdit(nil)
+	edGroup := qt.NewQComboBox(nil)
+	edGroup.SetEditable(true)
+	edGroup.SetToolTip("Cameras with the same group are listed in a tray submenu; leave empty for the top level")
+	edGroup.AddItem("")
+	for _, g := range cameraGroups() {
+		edGroup.AddItem(g)
+	}
 	edURL := qt.NewQLineEdit(nil)
+	edURL.SetPlaceholderText("rtsp://…, http://…/index.m3u8 or a local file path")
+	edSubURL := qt.NewQLineEdit(nil)
+	edSubURL.SetPlaceholderText("optional, e.g. rtsp://…/stream2")
+	edSubURL.SetToolTip("Low-resolution stream shown while windowed. Fullscreen switches to the main URL above and back again.")
+	edUser := qt.NewQLineEdit(nil)
+	edUser.SetPlaceholderText("optional")
+	edUser.SetToolTip("Stream credentials; any special characters are fine (no percent-encoding needed). Leave empty to use the ones in the URL, if any.")
+	edPass := qt.NewQLineEdit(nil)
+	edPass.SetEchoMode(qt.QLineEdit__Password)
+	edPass.SetPlaceholderText("optional")
 	chRTSP := qt.NewQCheckBox4("Use RTSP over TCP", nil)
+	chReach := qt.NewQCheckBox4("Check host reachability before connecting", nil)
+	chTLSInsecure := qt.NewQCheckBox4("Skip TLS certificate check (rtsps://)", nil)
+	chTLSInsecure.SetToolTip("Accept a self-signed or otherwise untrusted camera certificate. The connection is still encrypted, but not protected against impersonation.")
+	chPTZ := qt.NewQCheckBox4("PTZ control (ONVIF)", nil)
+	chPTZ.SetToolTip("Pan/tilt/zoom buttons on hover, arrow keys and +/- when the window is focused, and camera presets in the context menu")
+	edONVIF := qt.NewQLineEdit(nil)
+	edONVIF.SetPlaceholderText("http://host/onvif/device_service")
+	edONVIF.SetToolTip("ONVIF device service address; the username and password above are used")
 	chTop := qt.NewQCheckBox4("Always on top", nil)
 	chMute := qt.NewQCheckBox4("Mute audio", nil)
+	chMutePlay := qt.NewQCheckBox4("Mute playback only (recordings keep the audio)", nil)
+	chMutePlay.SetToolTip("Silent live monitoring with full audio in the archive")
 	// NEW: Stretch & HwAccel
 	chStretch := qt.NewQCheckBox4("Stretch video to window", nil)
+	chKeepFS := qt.NewQCheckBox4("Keep aspect ratio in fullscreen (letterbox)", nil)
+	chKeepFS.SetToolTip("Stretch then only applies to the window; fullscreen shows the whole picture undistorted.")
 	cbHw := qt.NewQComboBox(nil)
 	// Populate combo
 	cbHw.AddItem("none")
@@ -538,6 +1308,159 @@ func editCameraDialog(parent *qt.QWidget, c *CameraConfig) bool {
 	cbHw.AddItem("vaapi")
 	cbHw.AddItem("nvdec")
 	edFF := qt.NewQLineEdit(nil)
+	cbRotate := qt.NewQComboBox(nil)
+	for _, deg := range []string{"0", "90", "180", "270"} {
+		cbRotate.AddItem(deg)
+	}
+	chFlipH := qt.NewQCheckBox4("Flip horizontally", nil)
+	chFlipV := qt.NewQCheckBox4("Flip vertically", nil)
+	cbAspect := qt.NewQComboBox(nil)
+	for _, ar := range []string{"auto", "16:9", "4:3", "5:4", "1:1", "21:9", "9:16"} {
+		cbAspect.AddItem(ar)
+	}
+	cbAspect.SetToolTip("Overrides the picture shape when the camera reports a wrong aspect ratio")
+	cbFsMonitor := qt.NewQComboBox(nil)
+	cbFsMonitor.AddItem("Current monitor")
+	for _, scr := range qt.QGuiApplication_Screens() {
+		cbFsMonitor.AddItem(scr.Name())
+	}
+	if c.FullscreenMonitor != "" {
+		if cbFsMonitor.FindText(c.FullscreenMonitor) < 0 {
+			cbFsMonitor.AddItem(c.FullscreenMonitor) // not attached now: keep the choice
+		}
+		cbFsMonitor.SetCurrentIndex(cbFsMonitor.FindText(c.FullscreenMonitor))
+	}
+	cbFsMonitor.SetToolTip("Monitor this camera goes fullscreen on; leaving fullscreen puts the window back where it was")
+	spConnect := qt.NewQSpinBox(nil)
+	spConnect.SetRange(0, 120)
+	spConnect.SetSuffix(" s")
+	spConnect.SetSpecialValueText(fmt.Sprintf("Default (%d s)", int(defaultConnectTimeout/time.Second)))
+	spConnect.SetToolTip("How long FFmpeg waits to connect and on a silent socket; raise it for cameras behind a slow VPN. The stall watchdog never fires sooner.")
+	spStartDelay := qt.NewQSpinBox(nil)
+	spStartDelay.SetRange(0, 60000)
+	spStartDelay.SetSingleStep(100)
+	spStartDelay.SetSuffix(" ms")
+	spStartDelay.SetSpecialValueText("None")
+	spStartDelay.SetToolTip("Wait this long before connecting at app start and after waking from sleep (on top of Settings → Advanced → Stagger camera starts)")
+	spStall := qt.NewQSpinBox(nil)
+	spStall.SetRange(0, 3600)
+	spStall.SetSuffix(" s")
+	spStall.SetSpecialValueText("Default (10 s)")
+	spStall.SetToolTip("Reconnect when no frame arrives for this long")
+	spMaxFPS := qt.NewQSpinBox(nil)
+	spMaxFPS.SetRange(0, 120)
+	spMaxFPS.SetSuffix(" fps")
+	spMaxFPS.SetSpecialValueText("Default")
+	spMaxFPS.SetToolTip("Preview frame-rate cap (frames above it are dropped before conversion); Default uses Settings → Advanced")
+	spMaxKbps := qt.NewQSpinBox(nil)
+	spMaxKbps.SetRange(0, 1000000)
+	spMaxKbps.SetSingleStep(500)
+	spMaxKbps.SetSuffix(" kbps")
+	spMaxKbps.SetSpecialValueText("Default")
+	spMaxKbps.SetToolTip("Warn when the stream stays above this input rate; Default uses Settings → Advanced")
+	chNoKbps := qt.NewQCheckBox4("No bitrate warning for this camera", nil)
+	chNoKbps.OnToggled(func(on bool) { spMaxKbps.SetEnabled(!on) })
+	spProbe := qt.NewQSpinBox(nil)
+	spProbe.SetRange(0, 1<<30)
+	spProbe.SetSingleStep(500000)
+	spProbe.SetSuffix(" bytes")
+	spProbe.SetSpecialValueText("Default (5 MB)")
+	spProbe.SetToolTip("How much data FFmpeg reads to detect the streams; lower starts faster, higher helps streams that are slow to show up")
+	spSnap := qt.NewQSpinBox(nil)
+	spSnap.SetRange(0, 86400)
+	spSnap.SetSuffix(" s")
+	spSnap.SetSpecialValueText("Off")
+	spSnap.SetToolTip("Save a JPEG every N seconds into AnotherRTSP-Recordings/Snapshots/<camera>/ (timelapse, thumbnails); independent of recording")
+	spSnap.SetValue(c.SnapshotEvery)
+	spAnalyze := qt.NewQSpinBox(nil)
+	spAnalyze.SetRange(0, 60000000)
+	spAnalyze.SetSingleStep(500000)
+	spAnalyze.SetSuffix(" µs")
+	spAnalyze.SetSpecialValueText("Default")
+	spAnalyze.SetToolTip("How long FFmpeg analyzes the stream before starting (microseconds); raise for high-latency cameras, lower for fast local startup")
+	spCaching := qt.NewQSpinBox(nil)
+	spCaching.SetRange(0, 10000)
+	spCaching.SetSingleStep(100)
+	spCaching.SetSuffix(" ms")
+	spCaching.SetSpecialValueText("Off (lowest latency)")
+	spCaching.SetValue(c.Caching)
+	spCaching.SetToolTip("Network caching, like VLC's (and the original AnotherRTSP's): how much of the stream is buffered to smooth out a jittery network. Higher values add that much delay. Typical: 300–1000 ms.")
+	spVideoStream := qt.NewQSpinBox(nil)
+	spVideoStream.SetRange(-1, 63)
+	spVideoStream.SetPrefix("#")
+	spVideoStream.SetSpecialValueText("Auto (first video stream)")
+	spVideoStream.SetValue(c.VideoStreamIndex)
+	spVideoStream.SetToolTip("For cameras/NVRs that send several video streams (e.g. main and sub) in one session: the stream index to show. The streams and their resolutions are listed in the log at every connect.")
+	chNoStall := qt.NewQCheckBox4("Disable stall watchdog (reconnect on read errors only)", nil)
+	chNoStall.SetToolTip("For low-fps or event-driven streams that legitimately go quiet")
+	chNoStall.OnToggled(func(on bool) { spStall.SetEnabled(!on) })
+	cbRecContainer := qt.NewQComboBox(nil)
+	cbRecContainer.AddItem("mp4")
+	cbRecContainer.AddItem("mkv")
+	cbRecAudio := qt.NewQComboBox(nil)
+	cbRecAudio.AddItem("aac")
+	cbRecAudio.AddItem("copy")
+	cbRecAudio.SetToolTip("copy keeps the source audio as-is (AAC sources only); otherwise audio is re-encoded to AAC")
+	cbRecWhen := qt.NewQComboBox(nil)
+	cbRecWhen.AddItem("Unless muted")
+	cbRecWhen.AddItem("Always (also while muted)")
+	cbRecWhen.AddItem("Never (video only)")
+	cbRecWhen.SetToolTip("Whether recordings get the audio. Unless muted is the default: Mute audio leaves it out.")
+	// re-encoded recordings: smaller archives of a huge main stream, at a CPU cost
+	cbRecVideo := qt.NewQComboBox(nil)
+	cbRecVideo.AddItem("copy")
+	cbRecVideo.AddItem("h264")
+	cbRecVideo.SetToolTip("copy records the camera's stream as-is (full quality, almost no CPU); h264 decodes and re-encodes it at the quality and size below")
+	spRecCRF := qt.NewQSpinBox(nil)
+	spRecCRF.SetRange(0, 51)
+	spRecCRF.SetPrefix("CRF ")
+	spRecCRF.SetSpecialValueText(fmt.Sprintf("CRF %d", defaultRecordCRF))
+	spRecCRF.SetValue(c.RecordCRF)
+	spRecCRF.SetToolTip("Constant quality: lower is better and bigger; 18 looks lossless, 28 is compact")
+	spRecKbps := qt.NewQSpinBox(nil)
+	spRecKbps.SetRange(0, 100000)
+	spRecKbps.SetSingleStep(250)
+	spRecKbps.SetSuffix(" kbps")
+	spRecKbps.SetSpecialValueText("Constant quality")
+	spRecKbps.SetValue(c.RecordKbps)
+	spRecKbps.SetToolTip("Target bitrate instead of constant quality; predictable file sizes")
+	cbRecSize := qt.NewQComboBox(nil)
+	cbRecSize.SetEditable(true)
+	cbRecSize.AddItem("Source size")
+	for _, sz := range []string{"640x360", "1280x720", "1920x1080"} {
+		cbRecSize.AddItem(sz)
+	}
+	cbRecSize.SetToolTip("Largest size of the recorded picture (WxH, aspect ratio kept); smaller sources are not enlarged")
+	if c.RecordSize != "" {
+		cbRecSize.SetCurrentText(c.RecordSize)
+	}
+	syncRecVideo := func() {
+		enc := cbRecVideo.CurrentText() == "h264"
+		spRecKbps.SetEnabled(enc)
+		spRecCRF.SetEnabled(enc && spRecKbps.Value() == 0)
+		cbRecSize.SetEnabled(enc)
+	}
+	cbRecVideo.OnCurrentIndexChanged(func(int) { syncRecVideo() })
+	spRecKbps.OnValueChanged(func(int) { syncRecVideo() })
+	cbErrDetect := choiceCombo("Default", errDetectLevels, c.ErrDetect)
+	cbErrDetect.SetToolTip("err_detect for this camera; Default uses Settings → Advanced → Decode errors")
+	cbErrConceal := choiceCombo("Default", concealModes, c.ErrConceal)
+	cbErrConceal.SetToolTip("Error concealment for this camera; Default uses Settings → Advanced → Decode errors")
+	spClip := qt.NewQSpinBox(nil)
+	spClip.SetRange(0, 600)
+	spClip.SetSuffix(" s")
+	spClip.SetSpecialValueText("Off")
+	spClip.SetValue(c.ClipSeconds)
+	spClip.SetToolTip("Keep the last N seconds of the stream in memory; Save clip (context menu or C) writes them to a file next to the recordings")
+	chAlwaysRec := qt.NewQCheckBox4("Always record (24/7)", nil)
+	chAlwaysRec.SetToolTip("Start recording as soon as the stream connects and again after every reconnect")
+	chAlwaysRec.SetChecked(c.AlwaysRecord)
+	spSegment := qt.NewQSpinBox(nil)
+	spSegment.SetRange(0, 1440)
+	spSegment.SetSuffix(" min")
+	spSegment.SetSpecialValueText("Default")
+	spSegment.SetToolTip(fmt.Sprintf("Start a new recording file after this many minutes (at the next keyframe). Default: %d min with Always record, otherwise one file per recording", defaultSegmentMin))
+	spSegment.SetValue(c.RecordSegmentMin)
 
 	hwaccel := c.HwAccel
 	if hwaccel == "" {
@@ -546,25 +1469,159 @@ func editCameraDialog(parent *qt.QWidget, c *CameraConfig) bool {
 
 	// initial values
 	edName.SetText(c.Name)
+	edGroup.SetEditText(c.Group)
 	edURL.SetText(c.URL)
+	edSubURL.SetText(c.SubURL)
+	edUser.SetText(c.Username)
+	edPass.SetText(c.Password)
 	chRTSP.SetChecked(c.RTSPTCP)
+	chReach.SetChecked(c.ReachCheck)
+	chTLSInsecure.SetChecked(c.TLSInsecure)
+	chPTZ.SetChecked(c.PTZ)
+	edONVIF.SetText(c.ONVIFURL)
+	edONVIF.SetEnabled(c.PTZ)
+	chPTZ.OnToggled(func(on bool) { edONVIF.SetEnabled(on) })
 	chTop.SetChecked(c.AlwaysOnTop)
 	chMute.SetChecked(c.Mute)
+	chMutePlay.SetChecked(c.MutePlayback)
 	chStretch.SetChecked(c.Stretch)
+	chKeepFS.SetChecked(c.FullscreenKeepAspect)
+	chKeepFS.SetEnabled(c.Stretch)
+	chStretch.OnToggled(func(on bool) { chKeepFS.SetEnabled(on) })
 	idx := cbHw.FindText2(hwaccel, qt.MatchFixedString)
 	if idx >= 0 {
 		cbHw.SetCurrentIndex(idx)
 	}
 	edFF.SetText(c.FFmpegParams) // may be empty
+	if idx := cbRotate.FindText(fmt.Sprintf("%d", normalizeRotation(c.Rotate))); idx >= 0 {
+		cbRotate.SetCurrentIndex(idx)
+	}
+	chFlipH.SetChecked(c.FlipH)
+	chFlipV.SetChecked(c.FlipV)
+	if c.AspectRatio != "" {
+		// keep hand-written ratios from the config selectable
+		if cbAspect.FindText(c.AspectRatio) < 0 {
+			cbAspect.AddItem(c.AspectRatio)
+		}
+		cbAspect.SetCurrentIndex(cbAspect.FindText(c.AspectRatio))
+	}
+	if c.StallTimeout > 0 {
+		spStall.SetValue(c.StallTimeout)
+	}
+	chNoStall.SetChecked(c.StallTimeout < 0)
+	spConnect.SetValue(c.ConnectTimeout)
+	spStartDelay.SetValue(c.StartDelayMs)
+	spMaxFPS.SetValue(c.MaxFPS)
+	if c.MaxKbps > 0 {
+		spMaxKbps.SetValue(c.MaxKbps)
+	}
+	chNoKbps.SetChecked(c.MaxKbps < 0)
+	spMaxKbps.SetEnabled(c.MaxKbps >= 0)
+	if c.Probesize <= 1<<30 {
+		spProbe.SetValue(int(c.Probesize))
+	}
+	if c.AnalyzeUS <= 60000000 {
+		spAnalyze.SetValue(int(c.AnalyzeUS))
+	}
+	spStall.SetEnabled(c.StallTimeout >= 0)
+	if idx := cbRecContainer.FindText2(c.RecordContainer, qt.MatchFixedString); idx >= 0 && c.RecordContainer != "" {
+		cbRecContainer.SetCurrentIndex(idx)
+	}
+	if idx := cbRecAudio.FindText2(c.RecordAudioMode, qt.MatchFixedString); idx >= 0 && c.RecordAudioMode != "" {
+		cbRecAudio.SetCurrentIndex(idx)
+	}
+	if r := c.RecordAudio; r != nil && *r {
+		cbRecWhen.SetCurrentIndex(1)
+	} else if r != nil {
+		cbRecWhen.SetCurrentIndex(2)
+	}
+	if idx := cbRecVideo.FindText2(c.RecordVideoMode, qt.MatchFixedString); idx >= 0 && c.RecordVideoMode != "" {
+		cbRecVideo.SetCurrentIndex(idx)
+	}
+	syncRecVideo()
 
 	form.AddRow3("Name:", edName.QWidget)
+	form.AddRow3("Group:", edGroup.QWidget)
 	form.AddRow3("URL:", edURL.QWidget)
+	form.AddRow3("Substream URL:", edSubURL.QWidget)
+	form.AddRow3("Username:", edUser.QWidget)
+	form.AddRow3("Password:", edPass.QWidget)
 	form.AddRow3("", chRTSP.QWidget)
+	form.AddRow3("", chReach.QWidget)
+	form.AddRow3("", chTLSInsecure.QWidget)
+	form.AddRow3("", chPTZ.QWidget)
+	form.AddRow3("ONVIF URL:", edONVIF.QWidget)
 	form.AddRow3("", chTop.QWidget)
 	form.AddRow3("", chMute.QWidget)
+	form.AddRow3("", chMutePlay.QWidget)
 	form.AddRow3("", chStretch.QWidget)
-	form.AddRow3("HW acceleration:", cbHw.QWidget)
+	form.AddRow3("", chKeepFS.QWidget)
+	form.AddRow3("Rotation (°):", cbRotate.QWidget)
+	form.AddRow3("", chFlipH.QWidget)
+	form.AddRow3("", chFlipV.QWidget)
+	form.AddRow3("Aspect ratio:", cbAspect.QWidget)
+	form.AddRow3("Fullscreen on:", cbFsMonitor.QWidget)
+	// HW acceleration + one-click benchmark on the live camera
+	btnBench := qt.NewQPushButton3("Benchmark")
+	btnBench.SetToolTip("Decode briefly in software and with each available hardware decoder, then pick the cheaper one")
+	hwRow := qt.NewQHBoxLayout(nil)
+	hwRow.AddWidget(cbHw.QWidget)
+	hwRow.AddWidget(btnBench.QWidget)
+	form.AddRow4("HW acceleration:", hwRow.QLayout)
+	form.AddRow3("Max FPS:", spMaxFPS.QWidget)
+	form.AddRow3("Max bitrate:", spMaxKbps.QWidget)
+	form.AddRow3("", chNoKbps.QWidget)
+	form.AddRow3("Probe size:", spProbe.QWidget)
+	form.AddRow3("Analyze duration:", spAnalyze.QWidget)
+	form.AddRow3("Network caching:", spCaching.QWidget)
+	form.AddRow3("Video stream:", spVideoStream.QWidget)
+	errRow := qt.NewQHBoxLayout(nil)
+	errRow.AddWidget(cbErrDetect.QWidget)
+	errRow.AddWidget(cbErrConceal.QWidget)
+	form.AddRow4("Decode errors:", errRow.QLayout)
+	form.AddRow3("Connect timeout:", spConnect.QWidget)
+	form.AddRow3("Start delay:", spStartDelay.QWidget)
+	form.AddRow3("Stall timeout:", spStall.QWidget)
+	form.AddRow3("", chNoStall.QWidget)
+	form.AddRow3("Recording container:", cbRecContainer.QWidget)
+	form.AddRow3("Recording audio:", cbRecAudio.QWidget)
+	form.AddRow3("Record audio:", cbRecWhen.QWidget)
+	form.AddRow3("Recording video:", cbRecVideo.QWidget)
+	recEncRow := qt.NewQHBoxLayout(nil)
+	recEncRow.AddWidget(spRecCRF.QWidget)
+	recEncRow.AddWidget(spRecKbps.QWidget)
+	recEncRow.AddWidget(cbRecSize.QWidget)
+	form.AddRow4("Re-encode to:", recEncRow.QLayout)
+	form.AddRow3("", chAlwaysRec.QWidget)
+	form.AddRow3("Split recordings every:", spSegment.QWidget)
+	form.AddRow3("Instant replay:", spClip.QWidget)
+	form.AddRow3("Snapshot every:", spSnap.QWidget)
 	form.AddRow3("FFmpeg params:", edFF.QWidget)
+	lblFF := qt.NewQLabel(nil)
+	lblFF.SetStyleSheet("color: #c0392b;")
+	lblFF.SetWordWrap(true)
+	form.AddRow3("", lblFF.QWidget)
+	// static check on every edit; FFmpeg's own verdict from the running
+	// stream is added while the text still matches what it was opened with
+	checkFF := func(text string) {
+		problems := parseFFmpegParams(text).Problems
+		for _, w := range wins {
+			if w == nil || c.ID == "" || w.cfg.ID != c.ID || w.cfg.FFmpegParams != text {
+				continue
+			}
+			if p := w.paramIssues.Load(); p != nil {
+				for _, msg := range *p {
+					if !slices.Contains(problems, msg) {
+						problems = append(problems, msg)
+					}
+				}
+			}
+		}
+		lblFF.SetText(strings.Join(problems, "\n"))
+		lblFF.SetVisible(len(problems) > 0)
+	}
+	edFF.OnTextChanged(checkFF)
+	checkFF(edFF.Text())
 
 	// Make text inputs + combo expand
 	setExpand := func(w *qt.QWidget) {
@@ -574,6 +1631,8 @@ func editCameraDialog(parent *qt.QWidget, c *CameraConfig) bool {
 	}
 	setExpand(edName.QWidget)
 	setExpand(edURL.QWidget)
+	setExpand(edSubURL.QWidget)
+	setExpand(edONVIF.QWidget)
 	setExpand(edFF.QWidget)
 	setExpand(cbHw.QWidget)
 
@@ -601,17 +1660,136 @@ func editCameraDialog(parent *qt.QWidget, c *CameraConfig) bool {
 
 	btnOk.OnClicked(func() {
 		c.Name = edName.Text()
-		c.URL = SanitizeString(edURL.Text())
+		c.Group = strings.TrimSpace(edGroup.CurrentText())
+		c.URL = cleanStreamURL(edURL.Text())
+		c.SubURL = cleanStreamURL(edSubURL.Text())
+		c.Username = strings.TrimSpace(edUser.Text())
+		c.Password = edPass.Text() // as typed: spaces may be part of it
 		c.RTSPTCP = chRTSP.IsChecked()
+		c.ReachCheck = chReach.IsChecked()
+		c.TLSInsecure = chTLSInsecure.IsChecked()
+		c.PTZ = chPTZ.IsChecked()
+		c.ONVIFURL = SanitizeString(edONVIF.Text())
 		c.AlwaysOnTop = chTop.IsChecked()
 		c.Mute = chMute.IsChecked()
+		c.MutePlayback = chMutePlay.IsChecked()
 		c.Stretch = chStretch.IsChecked()
+		c.FullscreenKeepAspect = chKeepFS.IsChecked()
 		c.HwAccel = cbHw.CurrentText()
 		c.FFmpegParams = edFF.Text()
+		c.Rotate, _ = strconv.Atoi(cbRotate.CurrentText())
+		c.FlipH = chFlipH.IsChecked()
+		c.FlipV = chFlipV.IsChecked()
+		c.AspectRatio = cbAspect.CurrentText()
+		if c.AspectRatio == "auto" {
+			c.AspectRatio = ""
+		}
+		c.MaxFPS = spMaxFPS.Value()
+		c.MaxKbps = spMaxKbps.Value()
+		if chNoKbps.IsChecked() {
+			c.MaxKbps = -1
+		}
+		c.Probesize = int64(spProbe.Value())
+		c.AnalyzeUS = int64(spAnalyze.Value())
+		c.Caching = spCaching.Value()
+		c.VideoStreamIndex = spVideoStream.Value()
+		c.ConnectTimeout = spConnect.Value()
+		c.StartDelayMs = spStartDelay.Value()
+		c.StallTimeout = spStall.Value()
+		if chNoStall.IsChecked() {
+			c.StallTimeout = -1
+		}
+		c.SnapshotEvery = spSnap.Value()
+		c.RecordContainer = cbRecContainer.CurrentText()
+		c.RecordAudioMode = cbRecAudio.CurrentText()
+		switch cbRecWhen.CurrentIndex() {
+		case 1, 2:
+			on := cbRecWhen.CurrentIndex() == 1
+			c.RecordAudio = &on
+		default:
+			c.RecordAudio = nil
+		}
+		c.RecordVideoMode = cbRecVideo.CurrentText()
+		c.RecordCRF = spRecCRF.Value()
+		c.RecordKbps = spRecKbps.Value()
+		c.RecordSize = strings.TrimSpace(cbRecSize.CurrentText())
+		if mw, _ := parseSizeCap(c.RecordSize); mw == 0 {
+			c.RecordSize = "" // "Source size" or not a WxH
+		}
+		c.ErrDetect = choiceValue(cbErrDetect)
+		c.FullscreenMonitor = choiceValue(cbFsMonitor)
+		c.ClipSeconds = spClip.Value()
+		c.ErrConceal = choiceValue(cbErrConceal)
+		c.AlwaysRecord = chAlwaysRec.IsChecked()
+		c.RecordSegmentMin = spSegment.Value()
 		dlg.Accept()
 	})
 	btnCancel.OnClicked(func() { dlg.Reject() })
 
+	benchCancel := make(chan struct{})
+	btnBench.OnClicked(func() {
+		var live *CamWindow
+		for _, w := range wins {
+			if w != nil && c.ID != "" && w.cfg.ID == c.ID && !w.closing {
+				live = w
+			}
+		}
+		if live == nil {
+			qt.QMessageBox_Information(dlg.QWidget, "Benchmark", "Open (enable) this camera first; the benchmark measures its live stream.")
+			return
+		}
+		modes := []string{"none"}
+		for i := 0; i < cbHw.Count(); i++ {
+			if m := cbHw.ItemText(i); m != "none" {
+				modes = append(modes, m)
+			}
+		}
+		btnBench.SetEnabled(false)
+		btnOk.SetEnabled(false)
+		btnBench.SetText("Benchmarking…")
+		go func() {
+			res := live.benchmarkDecoders(modes, benchCancel)
+			pick := recommendDecoder(res)
+			CallOnQtMain(func() {
+				select {
+				case <-benchCancel:
+					return // dialog closed meanwhile: its widgets are gone
+				default:
+				}
+				btnBench.SetText("Benchmark")
+				btnBench.SetEnabled(true)
+				btnOk.SetEnabled(valid())
+				if idx := cbHw.FindText(pick); idx >= 0 {
+					cbHw.SetCurrentIndex(idx)
+				}
+				qt.QMessageBox_Information(dlg.QWidget, "Benchmark", formatBenchResults(res, pick)+"\n\nThe recommendation is selected; press OK to keep it.")
+			})
+		}()
+	})
+
 	dlg.Resize(560, 0)
-	return dlg.Exec() == int(qt.QDialog__Accepted)
+	ok := dlg.Exec() == int(qt.QDialog__Accepted)
+	close(benchCancel) // stops a running benchmark
+	return ok
+}
+
+// choiceCombo is a combo box of choices headed by a "default" entry (the
+// empty value), with cur selected; choiceValue reads it back.
+func choiceCombo(def string, choices []string, cur string) *qt.QComboBox {
+	cb := qt.NewQComboBox(nil)
+	cb.AddItem(def)
+	for _, c := range choices {
+		cb.AddItem(c)
+	}
+	if i := slices.Index(choices, cur); i >= 0 {
+		cb.SetCurrentIndex(i + 1)
+	}
+	return cb
+}
+
+func choiceValue(cb *qt.QComboBox) string {
+	if cb.CurrentIndex() <= 0 {
+		return ""
+	}
+	return cb.CurrentText()
 }
@@ -22,15 +22,20 @@ package main
 
 import (
 	"crypto/rand"
+	"crypto/tls"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/url"
 	"os"
 	"os/exec"
 	"runtime"
 	"sort"
 	"strings"
 	"sync/atomic"
+	"time"
 	"unicode"
 
 	astiav "github.com/asticode/go-astiav"
@@ -67,6 +72,32 @@ func max(a, b int) int {
 	return b
 }
 
+// normalizeRotation snaps any angle to the nearest supported quarter turn (0/90/180/270).
+func normalizeRotation(deg int) int {
+	deg = ((deg % 360) + 360) % 360
+	return ((deg + 45) / 90 % 4) * 90
+}
+
+// parseAspectRatio turns "16:9" / "4:3" / "1.85" into width/height.
+// "auto", "" and anything unparsable return 0 (use the source's own size).
+func parseAspectRatio(s string) float64 {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if s == "" || s == "auto" {
+		return 0
+	}
+	var num, den float64
+	if n, _ := fmt.Sscanf(s, "%g:%g", &num, &den); n == 2 {
+		if num > 0 && den > 0 {
+			return num / den
+		}
+		return 0
+	}
+	if n, _ := fmt.Sscanf(s, "%g", &num); n == 1 && num > 0 {
+		return num
+	}
+	return 0
+}
+
 // generate ID for camera
 func genID() string {
 	var b [8]byte
@@ -84,6 +115,166 @@ func findCameraIndexByID(cfg *AppConfig, id string) int {
 	return -1
 }
 
+// streamHostPort extracts host:port from a stream URL, filling in the
+// scheme's default port when the URL has none.
+func streamHostPort(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("no host in %q", u.Redacted())
+	}
+	port := u.Port()
+	if port == "" {
+		switch strings.ToLower(u.Scheme) {
+		case "rtsp":
+			port = "554"
+		case "rtsps":
+			port = "322"
+		case "http":
+			port = "80"
+		case "https":
+			port = "443"
+		default:
+			return "", fmt.Errorf("no default port for scheme %q", u.Scheme)
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
+
+// streamURLWithAuth puts separately configured credentials into the stream
+// URL, percent-encoded, so passwords with '@', ':' or '/' survive. FFmpeg
+// takes them from there and answers Basic or Digest challenges itself.
+// Without a username the URL is used as is (credentials may be embedded).
+func streamURLWithAuth(raw, user, pass string) string {
+	if user == "" {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	if pass != "" {
+		u.User = url.UserPassword(user, pass)
+	} else {
+		u.User = url.User(user)
+	}
+	return u.String()
+}
+
+// redactedStreamURL is the stream URL with any password masked, for sharing
+// in bug reports. Anything that doesn't parse is withheld instead of risking
+// a leaked credential.
+func redactedStreamURL(raw, user string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "(unparsable URL)"
+	}
+	if user != "" && u.Host != "" {
+		u.User = url.UserPassword(user, "xxxxx") // separately configured credentials
+	}
+	return u.Redacted()
+}
+
+// streamKind is how a camera URL gets opened: RTSP cameras get the
+// low-latency RTSP options, HTTP (MJPEG, HLS) and local files get their own.
+type streamKind int
+
+const (
+	streamRTSP streamKind = iota
+	streamHTTP
+	streamFile
+	streamOther // rtmp://, udp://, srt://...: passed through untouched
+)
+
+// streamKindOf detects the kind from the URL scheme; anything without a
+// scheme is a local path.
+func streamKindOf(raw string) streamKind {
+	scheme, _, ok := strings.Cut(raw, "://")
+	if !ok {
+		return streamFile
+	}
+	switch strings.ToLower(scheme) {
+	case "rtsp", "rtsps":
+		return streamRTSP
+	case "http", "https":
+		return streamHTTP
+	case "file":
+		return streamFile
+	}
+	return streamOther
+}
+
+// isHLS reports whether an HTTP URL points at an HLS playlist.
+func isHLS(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && strings.HasSuffix(strings.ToLower(u.Path), ".m3u8")
+}
+
+// localPath turns a file:// URL into a plain path FFmpeg can open on every
+// platform; other input is returned as is.
+func localPath(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || !strings.EqualFold(u.Scheme, "file") {
+		return raw
+	}
+	p := u.Path
+	if u.Host != "" && u.Host != "localhost" {
+		p = "//" + u.Host + p // UNC share
+	}
+	// file:///C:/clips/a.mp4 -> C:/clips/a.mp4
+	if len(p) > 2 && p[0] == '/' && p[2] == ':' {
+		p = p[1:]
+	}
+	return p
+}
+
+// checkReachable does a quick TCP connect to the camera so an offline host
+// fails fast instead of waiting for FFmpeg's socket timeout.
+func checkReachable(raw string, timeout time.Duration) error {
+	hp, err := streamHostPort(raw)
+	if err != nil {
+		return nil // can't tell; let FFmpeg try
+	}
+	c, err := net.DialTimeout("tcp", hp, timeout)
+	if err != nil {
+		return fmt.Errorf("unreachable %s: %w", hp, err)
+	}
+	_ = c.Close()
+	return nil
+}
+
+// isRTSPS reports whether the stream is RTSP over TLS.
+func isRTSPS(raw string) bool {
+	scheme, _, _ := strings.Cut(raw, "://")
+	return strings.EqualFold(scheme, "rtsps")
+}
+
+// checkTLS does the TLS handshake with an rtsps:// camera before FFmpeg does.
+// FFmpeg doesn't verify the server certificate (its RTSP demuxer passes no
+// TLS options down), so a wrong or self-signed certificate is caught here,
+// with a readable error instead of a bare handshake failure. insecure skips
+// the certificate check and only makes sure TLS works at all.
+func checkTLS(raw string, timeout time.Duration, insecure bool) error {
+	hp, err := streamHostPort(raw)
+	if err != nil {
+		return nil // can't tell; let FFmpeg try
+	}
+	host, _, _ := net.SplitHostPort(hp)
+	d := &net.Dialer{Timeout: timeout}
+	c, err := tls.DialWithDialer(d, "tcp", hp, &tls.Config{ServerName: host, InsecureSkipVerify: insecure})
+	if err != nil {
+		var cv *tls.CertificateVerificationError
+		if errors.As(err, &cv) {
+			return fmt.Errorf("TLS certificate of %s not trusted (self-signed? see Skip TLS certificate check): %w", hp, cv.Err)
+		}
+		return fmt.Errorf("TLS handshake with %s failed: %w", hp, err)
+	}
+	_ = c.Close()
+	return nil
+}
+
 // DictPairs returns key=value ffmpeg settings pairs for logging.
 func DictPairs(d *astiav.Dictionary) []string {
 	if d == nil {
@@ -110,22 +301,36 @@ func JoinDict(d *astiav.Dictionary) string {
 }
 
 // --- FFmpeg params parsing ---------------------------------------------------
-// parseFFmpegParams splits a camera's FFmpeg params string into two maps:
-// -fOPTION=value -> fopts[OPTION]=value
-// -cOPTION=value -> copts[OPTION]=value
-func parseFFmpegParams(s string) (fopts map[string]string, copts map[string]string) {
-	fopts = make(map[string]string)
-	copts = make(map[string]string)
+
+// ffmpegParams is a camera's parsed FFmpeg params field.
+type ffmpegParams struct {
+	Format   map[string]string // -fOPTION=value: input/demuxer options
+	Codec    map[string]string // -cOPTION=value: decoder options
+	Scale    map[string]string // -sOPTION=value: swscale options (only "flags")
+	Problems []string          // malformed/unsupported tokens, shown in the camera editor
+}
+
+// parseFFmpegParams splits a camera's FFmpeg params string by prefix:
+// -fOPTION=value -> Format, -cOPTION=value -> Codec, -sOPTION=value -> Scale.
+// Tokens that don't fit are skipped and reported in Problems.
+func parseFFmpegParams(s string) ffmpegParams {
+	p := ffmpegParams{
+		Format: make(map[string]string),
+		Codec:  make(map[string]string),
+		Scale:  make(map[string]string),
+	}
 
 	for _, tok := range strings.Fields(s) { // ignores extra whitespace
 		if len(tok) < 3 || tok[0] != '-' {
+			p.Problems = append(p.Problems, fmt.Sprintf("%s: expected -fKEY=value, -cKEY=value or -sKEY=value", tok))
 			continue
 		}
-		prefix := tok[1] // 'f' or 'c'
+		prefix := tok[1] // 'f', 'c' or 's'
 		rest := tok[2:]  // OPTION=value
 		eq := strings.IndexByte(rest, '=')
 		if eq <= 0 || eq == len(rest)-1 {
-			continue // need both key and value
+			p.Problems = append(p.Problems, fmt.Sprintf("%s: needs both KEY and value (KEY=value)", tok))
+			continue
 		}
 		key := rest[:eq]
 		val := rest[eq+1:]
@@ -140,32 +345,82 @@ func parseFFmpegParams(s string) (fopts map[string]string, copts map[string]stri
 
 		switch prefix {
 		case 'f':
-			fopts[key] = val
+			p.Format[key] = val
 		case 'c':
-			copts[key] = val
+			p.Codec[key] = val
+		case 's':
+			if key != "flags" {
+				p.Problems = append(p.Problems, fmt.Sprintf("%s: only -sflags=... is supported for the scaler", tok))
+				continue
+			}
+			if _, err := swsFlags(val); err != nil {
+				p.Problems = append(p.Problems, fmt.Sprintf("%s: %v", tok, err))
+				continue
+			}
+			p.Scale[key] = val
+		default:
+			p.Problems = append(p.Problems, fmt.Sprintf("%s: unknown prefix -%c (use -f, -c or -s)", tok, prefix))
+		}
+	}
+	return p
+}
+
+// swsScaleFlags maps -sflags names to swscale algorithm flags.
+var swsScaleFlags = map[string]astiav.SoftwareScaleContextFlag{
+	"fast_bilinear": astiav.SoftwareScaleContextFlagFastBilinear,
+	"bilinear":      astiav.SoftwareScaleContextFlagBilinear,
+	"bicubic":       astiav.SoftwareScaleContextFlagBicubic,
+	"x":             astiav.SoftwareScaleContextFlagX,
+	"point":         astiav.SoftwareScaleContextFlagPoint,
+	"area":          astiav.SoftwareScaleContextFlagArea,
+	"bicublin":      astiav.SoftwareScaleContextFlagBicublin,
+	"gauss":         astiav.SoftwareScaleContextFlagGauss,
+	"sinc":          astiav.SoftwareScaleContextFlagSinc,
+	"lanczos":       astiav.SoftwareScaleContextFlagLanczos,
+	"spline":        astiav.SoftwareScaleContextFlagSpline,
+}
+
+// swsFlags parses "bicubic" / "+lanczos" / "area+point" into scaler flags.
+func swsFlags(v string) (astiav.SoftwareScaleContextFlags, error) {
+	var fs []astiav.SoftwareScaleContextFlag
+	for _, name := range strings.FieldsFunc(v, func(r rune) bool { return r == '+' || r == '|' }) {
+		f, ok := swsScaleFlags[strings.ToLower(name)]
+		if !ok {
+			return 0, fmt.Errorf("unknown scaler flag %q", name)
+		}
+		fs = append(fs, f)
+	}
+	return astiav.NewSoftwareScaleContextFlags(fs...), nil
+}
+
+// unusedParams returns the user's keys FFmpeg left unconsumed in d after an
+// open call (i.e. options it did not recognize), as "-<prefix>key" tokens.
+func unusedParams(d *astiav.Dictionary, user map[string]string, prefix string) []string {
+	var out []string
+	for _, kv := range DictPairs(d) {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, ok := user[key]; ok {
+			out = append(out, "-"+prefix+key)
 		}
 	}
-	return
+	return out
 }
 
-// Apply only -f…=… tokens to the input/format dictionary (rd).
-func applyFmtParams(params string, rd *astiav.Dictionary) {
-	if params == "" || rd == nil {
+func applyFmtParams(p ffmpegParams, rd *astiav.Dictionary) {
+	if rd == nil {
 		return
 	}
-	fopts, _ := parseFFmpegParams(params)
-	for k, v := range fopts {
+	for k, v := range p.Format {
 		rd.Set(k, v, 0)
 	}
 }
 
 // Apply only -c…=… tokens to the decoder dictionary (vopts).
-func applyDecParams(params string, vopts *astiav.Dictionary) {
-	if params == "" || vopts == nil {
+func applyDecParams(p ffmpegParams, vopts *astiav.Dictionary) {
+	if vopts == nil {
 		return
 	}
-	_, copts := parseFFmpegParams(params)
-	for k, v := range copts {
+	for k, v := range p.Codec {
 		vopts.Set(k, v, 0)
 	}
 }
@@ -178,18 +433,46 @@ func CallOnQtMain(fn func()) {
 // open file (default association) or folder (supports windows, linux, mac)
 func openFileOrDir(file string) {
 	log.Printf("Opening external: %s\n", file)
-	var cmd *exec.Cmd
+	if cmd := systemOpenCmd(file); cmd != nil {
+		_ = cmd.Start()
+	}
+}
+
+// systemOpenCmd is the command that opens a file, folder or URL with the
+// system's default handler; nil on platforms without one.
+func systemOpenCmd(target string) *exec.Cmd {
 	switch runtime.GOOS {
 	case "darwin":
-		cmd = exec.Command("open", file)
+		return exec.Command("open", target)
 	case "linux":
-		cmd = exec.Command("xdg-open", file)
+		return exec.Command("xdg-open", target)
 	case "windows":
-		cmd = exec.Command("explorer", file)
+		return exec.Command("explorer", target)
+	}
+	return nil
+}
+
+// openExternalPlayer opens a stream URL in the configured external player
+// (VLC, mpv, ffplay…) or, without one, in whatever the system has registered
+// for the URL's scheme. The URL may carry credentials: it is not logged here.
+func openExternalPlayer(streamURL string) {
+	player := strings.TrimSpace(globalConfig.ExternalPlayer)
+	var cmd *exec.Cmd
+	switch {
+	case player == "":
+		if cmd = systemOpenCmd(streamURL); cmd == nil {
+			return
+		}
+	case runtime.GOOS == "darwin" && strings.HasSuffix(strings.TrimSuffix(player, "/"), ".app"):
+		cmd = exec.Command("open", "-a", player, streamURL) // app bundle, e.g. /Applications/VLC.app
 	default:
+		cmd = exec.Command(player, streamURL)
+	}
+	if err := cmd.Start(); err != nil {
+		log.Printf("external player %q: %v", nz(player, cmd.Path), err)
 		return
 	}
-	_ = cmd.Start()
+	go cmd.Wait() // reap it whenever it exits
 }
 
 // restart the application
@@ -199,6 +482,10 @@ func doRestart() {
 		return
 	}
 	args := os.Args[1:]
+	// finish recordings before the old process goes away
+	appQuitting.Store(true)
+	stopGridRecording()
+	shutdownCameras(wins)
 	cmd := exec.Command(exe, args...)
 	cmd.Start()
 	os.Exit(0)
@@ -225,6 +512,16 @@ func showAndFocus(win *qt.QMainWindow) {
 	})
 }
 
+// cleanStreamURL strips stray whitespace from a pasted URL; local paths
+// only lose the surrounding blanks since file names may contain spaces.
+func cleanStreamURL(s string) string {
+	s = strings.TrimSpace(s)
+	if streamKindOf(s) == streamFile {
+		return s
+	}
+	return SanitizeString(s)
+}
+
 // SanitizeString removes all Unicode whitespace characters from s
 // (spaces, tabs, newlines, carriage returns, non-breaking spaces, etc.).
 func SanitizeString(s string) string {
@@ -256,9 +553,15 @@ func newQuitFilter() *quitFilter {
 }
 
 func promptText(title, label string) (string, bool) {
+	return promptTextValue(title, label, "")
+}
+
+// promptTextValue is promptText with the field prefilled (and selected).
+func promptTextValue(title, label, value string) (string, bool) {
 	d := qt.NewQDialog(nil)
 	d.SetWindowTitle(title)
-	in := qt.NewQLineEdit(nil)
+	in := qt.NewQLineEdit3(value)
+	in.SelectAll()
 	in.SetMinimumWidth(280)
 
 	lbl := qt.NewQLabel6(label, nil, 0)
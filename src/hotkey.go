@@ -0,0 +1,89 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"errors"
+	"log"
+
+	"github.com/mappu/miqt/qt"
+	"github.com/mappu/miqt/qt/mainthread"
+)
+
+/*
+Global "panic hide" hotkey: hides every camera window from anywhere, even
+when another app has the focus, and brings back exactly those windows on the
+next press. The cameras keep decoding meanwhile, so they are back instantly.
+The key is stored as QKeySequence portable text (panic_hide_key) and bound
+through the platform's own API (registerGlobalHotkey in hotkey_windows.go,
+hotkey_darwin.go and hotkey_x11.go). The old key is released even when the
+new one can't be bound. Letters, digits, Space and F1–F24 with any
+modifiers can be bound.
+*/
+
+var registeredHotkey int // key|modifiers combo currently bound, 0 = none
+
+var errGlobalHotkeyGone = errors.New("hotkey listener has stopped")
+
+// applyGlobalHotkey binds panic_hide_key, replacing the previous key. Main
+// thread; called at start and after Settings are saved.
+func applyGlobalHotkey() {
+	combo := keyCombo(globalConfig.PanicHideKey)
+	if combo == registeredHotkey {
+		return
+	}
+	if err := registerGlobalHotkey(combo); err != nil {
+		log.Printf("global hotkey %q: %v", globalConfig.PanicHideKey, err)
+		registeredHotkey = 0
+		return
+	}
+	registeredHotkey = combo
+	if combo != 0 {
+		log.Printf("global hotkey %s hides/shows all camera windows", globalConfig.PanicHideKey)
+	}
+}
+
+// globalHotkeyPressed is called by the platform code, on any thread.
+func globalHotkeyPressed() {
+	mainthread.Start(func() {
+		if tray != nil {
+			tray.toggleHideAll(false)
+		}
+	})
+}
+
+// hotkeyKey splits a combo for the platform key tables: ch is an upper-case
+// letter, a digit or ' ', fn a function key number (1..24); both are zero for
+// keys that can't be bound globally.
+func hotkeyKey(combo int) (ch byte, fn int, mods qt.KeyboardModifier) {
+	mask := int(qt.ShiftModifier | qt.ControlModifier | qt.AltModifier | qt.MetaModifier | qt.KeypadModifier)
+	mods = qt.KeyboardModifier(combo & mask)
+	key := combo &^ mask
+	switch {
+	case key >= int(qt.Key_A) && key <= int(qt.Key_Z),
+		key >= int(qt.Key_0) && key <= int(qt.Key_9),
+		key == int(qt.Key_Space):
+		ch = byte(key)
+	case key >= int(qt.Key_F1) && key <= int(qt.Key_F24):
+		fn = key - int(qt.Key_F1) + 1
+	}
+	return ch, fn, mods
+}
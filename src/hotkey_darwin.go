@@ -0,0 +1,164 @@
+//go:build darwin
+// +build darwin
+
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+/*
+#cgo LDFLAGS: -framework Carbon
+#include <Carbon/Carbon.h>
+#include <unistd.h>
+
+static int hkFd = -1;              // write end of the pipe the Go side reads
+static EventHotKeyRef hkRef = NULL;
+static EventHandlerRef hkHandler = NULL;
+
+static OSStatus hkPressed(EventHandlerCallRef next, EventRef ev, void *data) {
+	char b = 1;
+	if (hkFd >= 0) {
+		(void)write(hkFd, &b, 1);
+	}
+	return noErr;
+}
+
+// keyCode 0xFFFF unbinds; returns an OSStatus
+static int hkRegister(int fd, UInt32 keyCode, UInt32 mods) {
+	hkFd = fd;
+	if (hkHandler == NULL) {
+		EventTypeSpec spec = {kEventClassKeyboard, kEventHotKeyPressed};
+		OSStatus st = InstallApplicationEventHandler(NewEventHandlerUPP(hkPressed), 1, &spec, NULL, &hkHandler);
+		if (st != noErr) {
+			return (int)st;
+		}
+	}
+	if (hkRef != NULL) {
+		UnregisterEventHotKey(hkRef);
+		hkRef = NULL;
+	}
+	if (keyCode == 0xFFFF) {
+		return 0;
+	}
+	EventHotKeyID id = {0x51415254, 1}; // 'QART'
+	return (int)RegisterEventHotKey(keyCode, mods, id, GetApplicationEventTarget(), 0, &hkRef);
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/mappu/miqt/qt"
+)
+
+// Global hotkey (hotkey.go) via Carbon's RegisterEventHotKey, which needs no
+// accessibility permission. The handler runs in the app's event loop and
+// only writes a byte to a pipe, read here, so C never calls back into Go.
+
+var (
+	hotkeyOnce sync.Once
+	hotkeyPipe *os.File // write end handed to the handler
+	hotkeyErr  error
+)
+
+// macKeyCodes are the ANSI virtual key codes (kVK_ANSI_*, kVK_Space).
+var macKeyCodes = map[byte]C.UInt32{
+	'A': 0x00, 'S': 0x01, 'D': 0x02, 'F': 0x03, 'H': 0x04, 'G': 0x05, 'Z': 0x06, 'X': 0x07,
+	'C': 0x08, 'V': 0x09, 'B': 0x0B, 'Q': 0x0C, 'W': 0x0D, 'E': 0x0E, 'R': 0x0F, 'Y': 0x10,
+	'T': 0x11, '1': 0x12, '2': 0x13, '3': 0x14, '4': 0x15, '6': 0x16, '5': 0x17, '9': 0x19,
+	'7': 0x1A, '8': 0x1C, '0': 0x1D, 'O': 0x1F, 'U': 0x20, 'I': 0x22, 'P': 0x23, 'L': 0x25,
+	'J': 0x26, 'K': 0x28, 'N': 0x2D, 'M': 0x2E, ' ': 0x31,
+}
+
+// macFKeyCodes are kVK_F1..kVK_F20.
+var macFKeyCodes = []C.UInt32{
+	0x7A, 0x78, 0x63, 0x76, 0x60, 0x61, 0x62, 0x64, 0x65, 0x6D,
+	0x67, 0x6F, 0x69, 0x6B, 0x71, 0x6A, 0x40, 0x4F, 0x50, 0x5A,
+}
+
+// macHotkey maps a Qt combo to a Carbon key code and modifiers. Qt's Ctrl is
+// the Command key on macOS and Meta the Control key.
+func macHotkey(combo int) (code, mods C.UInt32, err error) {
+	ch, fn, qm := hotkeyKey(combo)
+	var ok bool
+	switch {
+	case ch != 0:
+		code, ok = macKeyCodes[ch]
+	case fn > 0 && fn <= len(macFKeyCodes):
+		code, ok = macFKeyCodes[fn-1], true
+	}
+	if !ok {
+		return 0, 0, errors.New("key can't be used as a global hotkey")
+	}
+	if qm&qt.ControlModifier != 0 {
+		mods |= C.cmdKey
+	}
+	if qm&qt.AltModifier != 0 {
+		mods |= C.optionKey
+	}
+	if qm&qt.ShiftModifier != 0 {
+		mods |= C.shiftKey
+	}
+	if qm&qt.MetaModifier != 0 {
+		mods |= C.controlKey
+	}
+	return code, mods, nil
+}
+
+// registerGlobalHotkey binds combo (Qt key|modifiers; 0 unbinds) system-wide.
+// Main thread only.
+func registerGlobalHotkey(combo int) error {
+	hotkeyOnce.Do(func() {
+		r, w, err := os.Pipe()
+		if err != nil {
+			hotkeyErr = err
+			return
+		}
+		hotkeyPipe = w
+		go func() {
+			buf := make([]byte, 1)
+			for {
+				if _, err := r.Read(buf); err != nil {
+					return
+				}
+				globalHotkeyPressed()
+			}
+		}()
+	})
+	if hotkeyErr != nil {
+		return hotkeyErr
+	}
+	code, mods := C.UInt32(0xFFFF), C.UInt32(0)
+	if combo != 0 {
+		var err error
+		if code, mods, err = macHotkey(combo); err != nil {
+			C.hkRegister(C.int(hotkeyPipe.Fd()), 0xFFFF, 0) // release the old key first
+			return err
+		}
+	}
+	if st := C.hkRegister(C.int(hotkeyPipe.Fd()), code, mods); st != 0 {
+		return fmt.Errorf("RegisterEventHotKey: OSStatus %d (taken by another app?)", int(st))
+	}
+	return nil
+}
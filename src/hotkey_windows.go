@@ -0,0 +1,166 @@
+//go:build windows
+// +build windows
+
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"sync"
+	"unsafe"
+
+	"github.com/mappu/miqt/qt"
+	"golang.org/x/sys/windows"
+)
+
+// Global hotkey (hotkey.go) via RegisterHotKey: the key belongs to a thread
+// of its own, which gets WM_HOTKEY in its message queue. Changing the key is
+// handed to that thread as well, since only it can unregister it.
+
+var (
+	procRegisterHotKey     = user32.NewProc("RegisterHotKey")
+	procUnregisterHotKey   = user32.NewProc("UnregisterHotKey")
+	procPostThreadMessageW = user32.NewProc("PostThreadMessageW")
+	procPeekMessageW       = user32.NewProc("PeekMessageW")
+)
+
+const (
+	WM_HOTKEY    = 0x0312
+	WM_APP       = 0x8000
+	MOD_ALT      = 0x0001
+	MOD_CONTROL  = 0x0002
+	MOD_SHIFT    = 0x0004
+	MOD_WIN      = 0x0008
+	MOD_NOREPEAT = 0x4000
+)
+
+type hotkeyReq struct {
+	combo int
+	done  chan error
+}
+
+var (
+	hotkeyOnce sync.Once
+	hotkeyTID  uint32 // thread running hotkeyMsgLoop
+	hotkeyReqs = make(chan hotkeyReq, 1)
+	hotkeyGone = make(chan struct{}) // closed when the loop ends
+)
+
+// registerGlobalHotkey binds combo (Qt key|modifiers; 0 unbinds) system-wide.
+func registerGlobalHotkey(combo int) error {
+	hotkeyOnce.Do(func() {
+		ready := make(chan struct{})
+		go hotkeyMsgLoop(ready)
+		<-ready
+	})
+	req := hotkeyReq{combo: combo, done: make(chan error, 1)}
+	select {
+	case hotkeyReqs <- req:
+	case <-hotkeyGone:
+		return errGlobalHotkeyGone
+	}
+	if r, _, err := procPostThreadMessageW.Call(uintptr(hotkeyTID), WM_APP, 0, 0); r == 0 {
+		<-hotkeyReqs
+		return fmt.Errorf("PostThreadMessage: %v", err)
+	}
+	select {
+	case err := <-req.done:
+		return err
+	case <-hotkeyGone:
+		return errGlobalHotkeyGone
+	}
+}
+
+// winHotkey maps a Qt combo to RegisterHotKey modifiers and virtual key.
+func winHotkey(combo int) (mods, vk uintptr, err error) {
+	ch, fn, qm := hotkeyKey(combo)
+	switch {
+	case ch != 0:
+		vk = uintptr(ch) // VK_A..VK_Z, VK_0..VK_9 and VK_SPACE match ASCII
+	case fn != 0:
+		vk = uintptr(0x6F + fn) // VK_F1 = 0x70
+	default:
+		return 0, 0, errors.New("key can't be used as a global hotkey")
+	}
+	if qm&qt.ControlModifier != 0 {
+		mods |= MOD_CONTROL
+	}
+	if qm&qt.AltModifier != 0 {
+		mods |= MOD_ALT
+	}
+	if qm&qt.ShiftModifier != 0 {
+		mods |= MOD_SHIFT
+	}
+	if qm&qt.MetaModifier != 0 {
+		mods |= MOD_WIN
+	}
+	return mods, vk, nil
+}
+
+func hotkeyMsgLoop(ready chan struct{}) {
+	runtime.LockOSThread() // the hotkey and the message queue belong to this thread
+
+	var m msg
+	// create the thread's message queue before anyone posts to it
+	procPeekMessageW.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0, 0)
+	hotkeyTID = windows.GetCurrentThreadId()
+	close(ready)
+	defer close(hotkeyGone)
+
+	const id = 1
+	bound := false
+	for {
+		r, _, _ := procGetMessageW.Call(uintptr(unsafe.Pointer(&m)), 0, 0, 0)
+		if int32(r) <= 0 {
+			log.Printf("global hotkey: message loop ended")
+			return
+		}
+		switch m.Message {
+		case WM_HOTKEY:
+			globalHotkeyPressed()
+		case WM_APP:
+			req := <-hotkeyReqs
+			if bound {
+				procUnregisterHotKey.Call(0, id)
+				bound = false
+			}
+			if req.combo == 0 {
+				req.done <- nil
+				continue
+			}
+			mods, vk, err := winHotkey(req.combo)
+			if err != nil {
+				req.done <- err
+				continue
+			}
+			if r, _, err := procRegisterHotKey.Call(0, id, mods|MOD_NOREPEAT, vk); r == 0 {
+				req.done <- fmt.Errorf("RegisterHotKey: %v (taken by another app?)", err)
+				continue
+			}
+			bound = true
+			req.done <- nil
+		}
+	}
+}
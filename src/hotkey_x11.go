@@ -0,0 +1,295 @@
+//go:build !darwin && !windows
+// +build !darwin,!windows
+
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+/*
+#cgo LDFLAGS: -ldl
+#include <dlfcn.h>
+#include <errno.h>
+#include <poll.h>
+
+// libX11 is loaded at run time: no build dependency, and a session without
+// it (pure Wayland, headless) just has no global hotkey.
+
+typedef void *(*XOpenDisplayFn)(const char *);
+typedef unsigned long (*XDefaultRootWindowFn)(void *);
+typedef unsigned char (*XKeysymToKeycodeFn)(void *, unsigned long);
+typedef int (*XGrabKeyFn)(void *, int, unsigned int, unsigned long, int, int, int);
+typedef int (*XUngrabKeyFn)(void *, int, unsigned int, unsigned long);
+typedef int (*XSyncFn)(void *, int);
+typedef int (*XPendingFn)(void *);
+typedef int (*XNextEventFn)(void *, void *);
+typedef int (*XConnectionNumberFn)(void *);
+typedef int (*XErrorHandlerFn)(void *, void *);
+typedef XErrorHandlerFn (*XSetErrorHandlerFn)(XErrorHandlerFn);
+
+static void *hkDpy;
+static unsigned long hkRoot;
+static int hkCode;           // grabbed keycode, 0 = none
+static unsigned int hkMods;
+static int hkFailed;         // an X error came back for the grab
+
+static XDefaultRootWindowFn pXDefaultRootWindow;
+static XKeysymToKeycodeFn pXKeysymToKeycode;
+static XGrabKeyFn pXGrabKey;
+static XUngrabKeyFn pXUngrabKey;
+static XSyncFn pXSync;
+static XPendingFn pXPending;
+static XNextEventFn pXNextEvent;
+static XConnectionNumberFn pXConnectionNumber;
+static XSetErrorHandlerFn pXSetErrorHandler;
+
+// 0 ok, -1 no libX11, -2 no X display
+static int hkOpen(void) {
+	void *x11 = dlopen("libX11.so.6", RTLD_NOW | RTLD_LOCAL);
+	if (x11 == NULL) {
+		return -1;
+	}
+	XOpenDisplayFn pXOpenDisplay = (XOpenDisplayFn)dlsym(x11, "XOpenDisplay");
+	pXDefaultRootWindow = (XDefaultRootWindowFn)dlsym(x11, "XDefaultRootWindow");
+	pXKeysymToKeycode = (XKeysymToKeycodeFn)dlsym(x11, "XKeysymToKeycode");
+	pXGrabKey = (XGrabKeyFn)dlsym(x11, "XGrabKey");
+	pXUngrabKey = (XUngrabKeyFn)dlsym(x11, "XUngrabKey");
+	pXSync = (XSyncFn)dlsym(x11, "XSync");
+	pXPending = (XPendingFn)dlsym(x11, "XPending");
+	pXNextEvent = (XNextEventFn)dlsym(x11, "XNextEvent");
+	pXConnectionNumber = (XConnectionNumberFn)dlsym(x11, "XConnectionNumber");
+	pXSetErrorHandler = (XSetErrorHandlerFn)dlsym(x11, "XSetErrorHandler");
+	if (!pXOpenDisplay || !pXDefaultRootWindow || !pXKeysymToKeycode || !pXGrabKey || !pXUngrabKey ||
+	    !pXSync || !pXPending || !pXNextEvent || !pXConnectionNumber || !pXSetErrorHandler) {
+		return -1;
+	}
+	hkDpy = pXOpenDisplay(NULL);
+	if (hkDpy == NULL) {
+		return -2;
+	}
+	hkRoot = pXDefaultRootWindow(hkDpy);
+	return 0;
+}
+
+static int hkOnError(void *dpy, void *ev) {
+	hkFailed = 1;
+	return 0;
+}
+
+// lock keys must not matter: grab with every CapsLock (LockMask) and
+// NumLock (Mod2Mask) combination
+static const unsigned int hkLocks[] = {0, 2, 16, 2 | 16};
+
+static void hkUngrab(void) {
+	if (hkCode == 0) {
+		return;
+	}
+	for (int i = 0; i < 4; i++) {
+		pXUngrabKey(hkDpy, hkCode, hkMods | hkLocks[i], hkRoot);
+	}
+	pXSync(hkDpy, 0);
+	hkCode = 0;
+}
+
+// 0 ok, -1 key not on this keyboard, -2 grab refused (taken by another app)
+static int hkGrab(unsigned long keysym, unsigned int mods) {
+	hkUngrab();
+	if (keysym == 0) {
+		return 0;
+	}
+	int code = pXKeysymToKeycode(hkDpy, keysym);
+	if (code == 0) {
+		return -1;
+	}
+	// grab errors arrive asynchronously; Xlib's default handler would exit
+	hkFailed = 0;
+	XErrorHandlerFn prev = pXSetErrorHandler(hkOnError);
+	for (int i = 0; i < 4; i++) {
+		pXGrabKey(hkDpy, code, mods | hkLocks[i], hkRoot, 0, 1, 1); // GrabModeAsync
+	}
+	pXSync(hkDpy, 0);
+	pXSetErrorHandler(prev);
+	hkCode = code;
+	hkMods = mods;
+	if (hkFailed) {
+		hkUngrab();
+		return -2;
+	}
+	return 0;
+}
+
+// 1 hotkey pressed, 0 woken through wakefd, -1 error
+static int hkWait(int wakefd) {
+	int xfd = pXConnectionNumber(hkDpy);
+	for (;;) {
+		while (pXPending(hkDpy) > 0) {
+			long ev[24]; // XEvent
+			pXNextEvent(hkDpy, ev);
+			if (*(int *)ev == 2) { // KeyPress
+				return 1;
+			}
+		}
+		struct pollfd fds[2] = {{xfd, POLLIN, 0}, {wakefd, POLLIN, 0}};
+		if (poll(fds, 2, -1) < 0) {
+			if (errno == EINTR) {
+				continue;
+			}
+			return -1;
+		}
+		if (fds[1].revents & POLLIN) {
+			return 0;
+		}
+	}
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"log"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/mappu/miqt/qt"
+)
+
+// Global hotkey (hotkey.go) on X11 via XGrabKey on the root window, from a
+// thread with its own display connection. Wayland doesn't let apps grab keys;
+// there it only works while an XWayland window has the focus.
+
+type hotkeyReq struct {
+	combo int
+	done  chan error
+}
+
+var (
+	hotkeyOnce sync.Once
+	hotkeyErr  error    // the X display could not be opened
+	hotkeyWake *os.File // write end: a byte wakes the loop for the next request
+	hotkeyReqs = make(chan hotkeyReq, 1)
+	hotkeyGone = make(chan struct{}) // closed when the loop ends
+)
+
+// registerGlobalHotkey binds combo (Qt key|modifiers; 0 unbinds) system-wide.
+func registerGlobalHotkey(combo int) error {
+	hotkeyOnce.Do(func() {
+		ready := make(chan error)
+		go hotkeyLoop(ready)
+		hotkeyErr = <-ready
+	})
+	if hotkeyErr != nil {
+		return hotkeyErr
+	}
+	req := hotkeyReq{combo: combo, done: make(chan error, 1)}
+	select {
+	case hotkeyReqs <- req:
+	case <-hotkeyGone:
+		return errGlobalHotkeyGone
+	}
+	if _, err := hotkeyWake.Write([]byte{0}); err != nil {
+		<-hotkeyReqs
+		return err
+	}
+	select {
+	case err := <-req.done:
+		return err
+	case <-hotkeyGone:
+		return errGlobalHotkeyGone
+	}
+}
+
+// x11Hotkey maps a Qt combo to an X keysym and modifier mask.
+func x11Hotkey(combo int) (keysym C.ulong, mods C.uint, err error) {
+	ch, fn, qm := hotkeyKey(combo)
+	switch {
+	case ch >= 'A' && ch <= 'Z':
+		keysym = C.ulong(ch + 'a' - 'A') // XK_a..XK_z
+	case ch != 0:
+		keysym = C.ulong(ch) // XK_0..XK_9, XK_space
+	case fn != 0:
+		keysym = C.ulong(0xFFBD + fn) // XK_F1 = 0xFFBE
+	default:
+		return 0, 0, errors.New("key can't be used as a global hotkey")
+	}
+	if qm&qt.ShiftModifier != 0 {
+		mods |= 1 // ShiftMask
+	}
+	if qm&qt.ControlModifier != 0 {
+		mods |= 4 // ControlMask
+	}
+	if qm&qt.AltModifier != 0 {
+		mods |= 8 // Mod1Mask
+	}
+	if qm&qt.MetaModifier != 0 {
+		mods |= 64 // Mod4Mask (Super)
+	}
+	return keysym, mods, nil
+}
+
+func hotkeyLoop(ready chan error) {
+	runtime.LockOSThread() // Xlib display used from this thread only
+
+	switch C.hkOpen() {
+	case -1:
+		ready <- errors.New("libX11 not available")
+		return
+	case -2:
+		ready <- errors.New("no X11 display (Wayland session?)")
+		return
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		ready <- err
+		return
+	}
+	hotkeyWake = w
+	ready <- nil
+	defer close(hotkeyGone)
+
+	buf := make([]byte, 1)
+	for {
+		switch C.hkWait(C.int(r.Fd())) {
+		case 1:
+			globalHotkeyPressed()
+		case 0:
+			_, _ = r.Read(buf)
+			req := <-hotkeyReqs
+			keysym, mods, err := C.ulong(0), C.uint(0), error(nil)
+			if req.combo != 0 {
+				keysym, mods, err = x11Hotkey(req.combo)
+			}
+			if err != nil {
+				C.hkGrab(0, 0) // release the old key, as a failed grab does
+			} else {
+				switch C.hkGrab(keysym, mods) {
+				case -1:
+					err = errors.New("key not on this keyboard")
+				case -2:
+					err = errors.New("key already grabbed by another app")
+				}
+			}
+			req.done <- err
+		default:
+			log.Printf("global hotkey: X11 event loop failed")
+			return
+		}
+	}
+}
@@ -0,0 +1,130 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"log"
+
+	"github.com/mappu/miqt/qt"
+)
+
+/*
+Keyboard shortcuts of the camera windows. Each action has a default key;
+key_bindings in the config (Settings → Keys) overrides it per action, and an
+empty value unbinds it. Double-click for fullscreen always works as well.
+*/
+
+// keyAction is one bindable camera-window action.
+type keyAction struct {
+	ID    string // key in key_bindings
+	Label string // shown in Settings
+	Def   string // default key (QKeySequence portable text)
+	Run   func(w *CamWindow)
+}
+
+var keyActions = []keyAction{
+	{"record", "Toggle recording", "Space", func(w *CamWindow) {
+		// the highlighted (active) window is the record target, as before
+		if env.activeWin != nil {
+			w = env.activeWin
+		}
+		w.ToggleRecording()
+	}},
+	{"fullscreen", "Toggle fullscreen", "F", func(w *CamWindow) { w.ToggleFullscreen() }},
+	{"snapshot", "Save snapshot", "S", func(w *CamWindow) {
+		if path, err := w.Snapshot(); err != nil {
+			w.logf("snapshot: %v", err)
+		} else {
+			w.logf("snapshot saved -> %s", path)
+		}
+	}},
+	{"mute", "Toggle mute", "M", func(w *CamWindow) { w.ToggleMute() }},
+	{"clip", "Save clip (instant replay)", "C", func(w *CamWindow) { w.SaveClip() }},
+	{"next_camera", "Next camera", "Tab", func(w *CamWindow) { cycleWindows(w, 1) }},
+	{"prev_camera", "Previous camera", "Shift+Tab", func(w *CamWindow) { cycleWindows(w, -1) }},
+}
+
+// keyBinding is the key for an action: the configured one, else the default.
+func keyBinding(a keyAction) string {
+	if k, ok := globalConfig.KeyBindings[a.ID]; ok {
+		return k
+	}
+	return a.Def
+}
+
+// keyCombo parses a key sequence text into Qt's key|modifiers int (first
+// chord only); 0 when empty or invalid.
+func keyCombo(s string) int {
+	if s == "" {
+		return 0
+	}
+	seq := qt.QKeySequence_FromString2(s, qt.QKeySequence__PortableText)
+	if seq.Count() == 0 {
+		return 0
+	}
+	return seq.OperatorSubscript(0)
+}
+
+// eventCombo turns a key press into the same key|modifiers form. Shift+Tab
+// arrives as Backtab; keypad keys match their normal counterparts.
+func eventCombo(ev *qt.QKeyEvent) int {
+	key := ev.Key()
+	mods := int(ev.Modifiers()) &^ int(qt.KeypadModifier)
+	if key == int(qt.Key_Backtab) {
+		key = int(qt.Key_Tab)
+		mods |= int(qt.ShiftModifier)
+	}
+	return key | mods
+}
+
+// handleKey runs the action bound to the pressed key; false if none is.
+func (w *CamWindow) handleKey(ev *qt.QKeyEvent) bool {
+	combo := eventCombo(ev)
+	for _, a := range keyActions {
+		if k := keyCombo(keyBinding(a)); k != 0 && k == combo {
+			a.Run(w)
+			return true
+		}
+	}
+	return false
+}
+
+// ToggleMute flips this camera's audio and remembers it in the config.
+func (w *CamWindow) ToggleMute() {
+	w.SetMute(!w.muted.Load())
+}
+
+// SetMute mutes or unmutes this camera's audio and remembers it in the
+// config. Takes effect with the next audio packet, without a reconnect.
+func (w *CamWindow) SetMute(mute bool) {
+	w.applyMute(mute)
+	if err := UpdateCamera(w.idKey, func(c *CameraConfig) { c.Mute = mute }); err != nil {
+		log.Printf("save config failed: %v", err)
+	}
+}
+
+// applyMute switches the live audio path only; the caller saves the config.
+func (w *CamWindow) applyMute(mute bool) {
+	w.cfg.Mute = mute
+	if w.muted.Swap(mute) != mute {
+		w.logf("audio %s", map[bool]string{true: "muted", false: "unmuted"}[mute])
+	}
+}
@@ -0,0 +1,261 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/asticode/go-astiav"
+)
+
+/*
+debug.log rotation: when the file would grow past Settings → Advanced → Log
+file size it is renamed to debug.log.1 (older ones shift up to .N, the
+oldest is dropped) and a fresh one is started. Each log call is a single
+write under the lock, so a line never straddles two files. Optionally the
+lines are JSON objects instead of plain text.
+
+The log level (tray → Settings → Log level) is runtime only: at "error" only
+error and warning lines get through, "debug" adds the verbose lines guarded
+by debugLogging(). FFmpeg's own log can be routed in as well.
+*/
+
+const (
+	defaultLogMaxMB = 10
+	defaultLogKeep  = 5
+)
+
+// rotatingLog is the writer behind the standard logger.
+type rotatingLog struct {
+	mu       sync.Mutex
+	path     string
+	f        *os.File
+	size     int64
+	maxBytes int64
+	keep     int
+	json     bool
+	stdout   bool // mirror to stdout (-debug)
+}
+
+// appLog is set up by initlog before anything else logs.
+var appLog *rotatingLog
+
+func openRotatingLog(path string) (*rotatingLog, error) {
+	r := &rotatingLog{path: path, maxBytes: defaultLogMaxMB << 20, keep: defaultLogKeep}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingLog) open() error {
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	r.f, r.size = f, st.Size()
+	return nil
+}
+
+func (r *rotatingLog) Write(p []byte) (int, error) {
+	if logLevel(appLogLevel.Load()) == logError && lineLevel(string(p)) == "info" {
+		return len(p), nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	line := p
+	if r.json {
+		line = jsonLogLine(p)
+	}
+	if r.stdout {
+		_, _ = os.Stdout.Write(line)
+	}
+	if r.maxBytes > 0 && r.size > 0 && r.size+int64(len(line)) > r.maxBytes {
+		if err := r.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "log rotation failed: %v\n", err)
+		}
+	}
+	if r.f == nil {
+		return len(p), nil // reopening failed; keep the app running
+	}
+	n, err := r.f.Write(line)
+	r.size += int64(n)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// rotate shifts debug.log.N-1 → .N … debug.log → .1 and opens a new file.
+func (r *rotatingLog) rotate() error {
+	if r.f != nil {
+		_ = r.f.Close()
+		r.f = nil
+	}
+	_ = os.Remove(fmt.Sprintf("%s.%d", r.path, r.keep))
+	for i := r.keep - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	if r.keep > 0 {
+		_ = os.Rename(r.path, r.path+".1")
+	} else {
+		_ = os.Remove(r.path)
+	}
+	return r.open()
+}
+
+// setStdout turns the stdout mirror on or off.
+func (r *rotatingLog) setStdout(on bool) {
+	r.mu.Lock()
+	r.stdout = on
+	r.mu.Unlock()
+}
+
+// configure applies the log settings; structured lines carry their own
+// timestamp, so the standard prefix is reduced to the source position.
+func (r *rotatingLog) configure(maxMB, keep int, format string) {
+	if maxMB <= 0 {
+		maxMB = defaultLogMaxMB
+	}
+	if keep <= 0 {
+		keep = defaultLogKeep
+	}
+	r.mu.Lock()
+	r.maxBytes = int64(maxMB) << 20
+	r.keep = keep
+	r.json = strings.EqualFold(format, "json")
+	r.mu.Unlock()
+	if r.json {
+		log.SetFlags(log.Lshortfile)
+	} else {
+		log.SetFlags(log.LstdFlags | log.Lmicroseconds)
+	}
+}
+
+// applyLogSettings hands the config's log options to the debug.log writer.
+func applyLogSettings() {
+	if appLog != nil {
+		appLog.configure(globalConfig.LogMaxMB, globalConfig.LogKeep, globalConfig.LogFormat)
+	}
+}
+
+// lineLevel classifies a log line. There are no explicit levels in the
+// app's logging: lines mentioning errors/failures are "error", warnings
+// "warn", everything else "info".
+func lineLevel(msg string) string {
+	lower := strings.ToLower(msg)
+	switch {
+	case strings.Contains(lower, "error") || strings.Contains(lower, "fail") || strings.Contains(lower, "panic"):
+		return "error"
+	case strings.Contains(lower, "warn"):
+		return "warn"
+	}
+	return "info"
+}
+
+// jsonLogLine turns "file.go:12: [Camera] message\n" (log.Lshortfile) into
+// {"time":…,"level":…,"camera":…,"source":…,"msg":…}.
+func jsonLogLine(p []byte) []byte {
+	msg := strings.TrimRight(string(p), "\n")
+	var source, camera string
+	if i := strings.Index(msg, ": "); i > 0 && strings.Contains(msg[:i], ".go:") {
+		source, msg = msg[:i], msg[i+2:]
+	}
+	if strings.HasPrefix(msg, "[") {
+		if i := strings.Index(msg, "] "); i > 0 {
+			camera, msg = msg[1:i], msg[i+2:]
+		}
+	}
+	level := lineLevel(msg)
+	b, err := json.Marshal(struct {
+		Time   string `json:"time"`
+		Level  string `json:"level"`
+		Camera string `json:"camera,omitempty"`
+		Source string `json:"source,omitempty"`
+		Msg    string `json:"msg"`
+	}{time.Now().Format(time.RFC3339Nano), level, camera, source, msg})
+	if err != nil {
+		return p
+	}
+	return append(b, '\n')
+}
+
+// logLevel is the runtime log verbosity.
+type logLevel int32
+
+const (
+	logError logLevel = -1 // errors and warnings only
+	logInfo  logLevel = 0  // everything the app normally logs (default)
+	logDebug logLevel = 1  // plus the verbose debugLogging() lines
+)
+
+var logLevelNames = map[logLevel]string{logError: "Error", logInfo: "Info", logDebug: "Debug"}
+
+var appLogLevel atomic.Int32 // logLevel; logInfo unless -debug
+
+// setLogLevel changes the verbosity right away; not saved.
+func setLogLevel(l logLevel) {
+	if logLevel(appLogLevel.Swap(int32(l))) != l {
+		log.Printf("log level: %s", logLevelNames[l])
+	}
+}
+
+// debugLogging reports whether verbose diagnostics should be logged.
+func debugLogging() bool { return logLevel(appLogLevel.Load()) >= logDebug }
+
+var ffmpegLogOn atomic.Bool
+
+// setFFmpegLogging routes FFmpeg's debug log into the app log (on) or
+// returns it to FFmpeg's default stderr output (off).
+func setFFmpegLogging(on bool) {
+	if ffmpegLogOn.Swap(on) == on {
+		return
+	}
+	if !on {
+		astiav.ResetLogCallback()
+		astiav.SetLogLevel(astiav.LogLevelInfo)
+		log.Printf("ffmpeg log: off")
+		return
+	}
+	astiav.SetLogLevel(astiav.LogLevelDebug)
+	astiav.SetLogCallback(func(c astiav.Classer, l astiav.LogLevel, fmt, msg string) {
+		var cs string
+		if c != nil {
+			if cl := c.Class(); cl != nil {
+				cs = " - class: " + cl.String()
+			}
+		}
+		log.Printf("ffmpeg log: %s%s - level: %d\n", strings.TrimSpace(msg), cs, l)
+	})
+	log.Printf("ffmpeg log: on")
+}
@@ -25,9 +25,8 @@ import (
 	"log"
 	"os"
 	"runtime"
-	"strings"
+	"time"
 
-	astiav "github.com/asticode/go-astiav"
 	"github.com/mappu/miqt/qt"
 )
 
@@ -56,19 +55,15 @@ func main() {
 	defer runtime.UnlockOSThread() // this will run after Exec() returns
 	if *debugG {
 		debugging = "true"
+		DEBUG = true
+		setLogLevel(logDebug)
+		if appLog != nil {
+			appLog.setStdout(true) // initlog ran before the flags were parsed
+		}
 	}
 	log.Printf("Running %s v%s (build: %s)", app, version, build)
 	if *DebugFF {
-		astiav.SetLogLevel(astiav.LogLevelDebug)
-		astiav.SetLogCallback(func(c astiav.Classer, l astiav.LogLevel, fmt, msg string) {
-			var cs string
-			if c != nil {
-				if cl := c.Class(); cl != nil {
-					cs = " - class: " + cl.String()
-				}
-			}
-			log.Printf("ffmpeg log: %s%s - level: %d\n", strings.TrimSpace(msg), cs, l)
-		})
+		setFFmpegLogging(true) // also switchable from the tray
 	}
 	// Turn on Qt’s internal logs
 	//_ = os.Setenv("QT_LOGGING_RULES", "qt.multimedia.*=true;qt.network.*=true")
@@ -78,7 +73,8 @@ func main() {
 	qt.QGuiApplication_SetQuitOnLastWindowClosed(false)
 	qt.QCoreApplication_SetAttribute2(qt.AA_ShareOpenGLContexts, true)
 
-	qt.NewQApplication(os.Args)
+	applyWaylandPreference() // needs to pick the Qt platform before it starts
+	app := qt.NewQApplication(os.Args)
 
 	// immediate action when trying to quit using OS signals
 	qt.QCoreApplication_Instance().InstallEventFilter(newQuitFilter().QObject)
@@ -95,11 +91,6 @@ func main() {
 	qt.QApplication_SetWindowIcon(globalIcon)
 	qt.QGuiApplication_SetWindowIcon(globalIcon)
 
-	// Initialize global audio on the main (Qt) thread to avoid crash.
-	if err := InitGlobalAudio(8000, 1); err != nil {
-		log.Fatalf("audio init failed: %v", err)
-	}
-
 	// Initialize and load configuration
 	cfg, err := loadConfig(env.settingsFile)
 	if err != nil {
@@ -110,11 +101,29 @@ func main() {
 	}
 
 	globalConfig = cfg
+	applyLogSettings()
+	if globalConfig.HideDockIcon {
+		hideDockIcon() // tray-only agent app (macOS); elsewhere the windows skip the taskbar
+	}
 	ensureCameraIDs(globalConfig.Cameras) // ensure that the cameras have identification numbers
 
+	// Initialize global audio on the main (Qt) thread to avoid crash.
+	// Needs the config for the output device; the context can be created only once.
+	// Missing audio hardware is not fatal: we just run video-only.
+	if globalConfig.AudioDevice != "" {
+		selectAudioOutput(globalConfig.AudioDevice)
+	}
+	if err := InitGlobalAudio(8000, 1, time.Duration(globalConfig.AudioBufferMs)*time.Millisecond); err != nil {
+		log.Printf("audio init failed, audio playback disabled: %v", err)
+	}
+
+	// repair recordings left unfinalized by a crash/power loss
+	recoverInterruptedRecordings()
+
 	wins = make([]*CamWindow, len(globalConfig.Cameras))
 
-	// Start any enabled cameras
+	// Start any enabled cameras, staggered (start_stagger_ms)
+	startupSlot = 0
 	for i := range globalConfig.Cameras {
 		if globalConfig.Cameras[i].Disabled {
 			log.Printf("camera %q: disabled, skipping", safeCamTitle(globalConfig.Cameras[i]))
@@ -128,9 +137,11 @@ func main() {
 		}
 		wins[i] = w
 	}
+	startupSlot = -1
 
 	// Tray controller (builds the checkable Cameras menu)
 	tray = NewTrayController(&globalConfig, &wins)
+	applyGlobalHotkey()
 
 	// Give existing windows hooks + the same context menu as the tray
 	for i, w := range wins {
@@ -139,9 +150,18 @@ func main() {
 		}
 		tray.AttachWindowHooks(i, w)
 	}
+	if globalConfig.StartHidden {
+		tray.toggleHideAll(globalConfig.HidePauses) // as a tray click: the next one brings them back
+	}
+	if globalConfig.StartAtLogin {
+		_ = applyAutostart() // keep the entry pointing at this binary
+	}
 	IgnoreSignum()
 
+	watchScreens(app.QGuiApplication) // monitor unplugged: bring its windows back
+
 	go HandleSleep(wins)
+	warnWayland()
 
 	if len(cfg.Cameras) == 0 {
 		qt.QMessageBox_Critical(nil, "Error", "No cameras defined in the configuration")
@@ -151,9 +171,8 @@ func main() {
 	code := qt.QApplication_Exec()
 	// cleanup
 	SaveConfig()
-	for _, w := range wins {
-		w.Close()
-	}
+	stopGridRecording()
+	shutdownCameras(wins) // waits for recordings to be finalized
 	os.Exit(code)
 }
 
@@ -21,10 +21,14 @@
 package main
 
 import (
+	"fmt"
 	"log"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/mappu/miqt/qt"
+	"github.com/mappu/miqt/qt/mainthread"
 )
 
 /*
@@ -43,6 +47,25 @@ type TrayController struct {
 	formDelMenu     *qt.QMenu
 	formSubmenus    map[string]*qt.QMenu
 	formMenuMounted bool
+	// formation rotation: timer while running, next formation index
+	rotTimer *qt.QTimer
+	rotNext  int
+	rotAct   *qt.QAction
+	// tray-click show/hide toggle: windows we hid, and whether we paused them
+	hiddenByTray map[*CamWindow]bool
+	// camera previews on the tray actions (trayicon.go)
+	thumbShown       map[*qt.QAction]uint64
+	thumbPlaceholder *qt.QIcon
+	// connection balloons per camera name, for throttling (events.go)
+	notifyMu  sync.Mutex
+	connNotes map[string]*connNote
+}
+
+// connNote is when a camera's last "connection lost" balloon was shown, and
+// whether its "restored" is still owed.
+type connNote struct {
+	lostAt    time.Time
+	lostShown bool
 }
 
 func NewTrayController(cfg *AppConfig, winsA *[]*CamWindow) *TrayController {
@@ -62,6 +85,10 @@ func NewTrayController(cfg *AppConfig, winsA *[]*CamWindow) *TrayController {
 	t.tray.OnActivated(func(reason qt.QSystemTrayIcon__ActivationReason) {
 		if reason == qt.QSystemTrayIcon__Trigger {
 			if globalConfig.ActiveOnTray {
+				if globalConfig.TrayClick == "toggle" {
+					t.toggleHideAll(globalConfig.HidePauses)
+					return
+				}
 				log.Printf("Tray icon clicked, activating all windows...\n")
 				for _, w := range wins {
 					if w == nil || w.win == nil {
@@ -70,14 +97,98 @@ func NewTrayController(cfg *AppConfig, winsA *[]*CamWindow) *TrayController {
 					w.win.Show()
 					w.win.Raise()
 				}
+				t.refreshActionTitles()
 			}
 		}
 	})
 
 	t.rebuild()
+	t.startStatusIcon()
+	t.startThumbnails()
 	return t
 }
 
+// toggleHideAll hides every visible camera window ("panic hide"), or shows
+// back exactly the ones it hid. With pause (HidePauses for the tray click)
+// the hidden cameras also stop decoding until shown again.
+func (t *TrayController) toggleHideAll(pause bool) {
+	if len(t.hiddenByTray) > 0 {
+		log.Printf("Showing hidden windows...\n")
+		for w, paused := range t.hiddenByTray {
+			if w.closing || w.win == nil {
+				continue // closed/disabled meanwhile
+			}
+			w.win.Show()
+			w.win.Raise()
+			if paused {
+				go w.StartCamera()
+			}
+		}
+		t.hiddenByTray = nil
+		t.refreshActionTitles()
+		return
+	}
+
+	log.Printf("Hiding all windows...\n")
+	hidden := map[*CamWindow]bool{}
+	for _, w := range wins {
+		if w == nil || w.win == nil || w.closing || !w.win.IsVisible() {
+			continue
+		}
+		stop := pause && !w.IsPaused()
+		w.win.Hide()
+		if stop {
+			w.StopCamera()
+		}
+		hidden[w] = stop
+	}
+	if len(hidden) > 0 {
+		t.hiddenByTray = hidden
+	}
+	t.refreshActionTitles()
+}
+
+// showAllCameras is the way back when everything is hidden or closed: every
+// camera window is shown and raised (resuming the ones the tray click paused),
+// and if no window is open at all, every camera is turned back on.
+func (t *TrayController) showAllCameras() {
+	log.Printf("Showing all cameras...\n")
+	for w, paused := range t.hiddenByTray {
+		if paused && !w.closing && w.win != nil {
+			go w.StartCamera()
+		}
+	}
+	t.hiddenByTray = nil
+
+	open := 0
+	for _, w := range *t.wins {
+		if w == nil || w.win == nil || w.closing {
+			continue
+		}
+		showAndFocus(w.win)
+		open++
+	}
+	if open == 0 && len(t.cfg.Cameras) > 0 {
+		idxs := make([]int, len(t.cfg.Cameras))
+		for i := range idxs {
+			idxs[i] = i
+		}
+		t.SetCamerasEnabled(idxs, true)
+	}
+	t.refreshActionTitles()
+}
+
+// anyWindowVisible reports whether some camera window is still on screen.
+// Caller holds t.mu.
+func (t *TrayController) anyWindowVisible() bool {
+	for _, w := range *t.wins {
+		if w != nil && w.win != nil && !w.closing && w.win.IsVisible() {
+			return true
+		}
+	}
+	return false
+}
+
 // Make sure wins has a slot for each camera.
 func (t *TrayController) ensureWinsLen() {
 	if len(*t.wins) < len(t.cfg.Cameras) {
@@ -105,11 +216,7 @@ func (t *TrayController) rebuild() {
 		idx := i
 		c := &t.cfg.Cameras[idx]
 
-		title := c.Name
-		if title == "" {
-			title = c.URL
-		}
-		act := cams.AddAction(title)
+		act := cams.AddAction(cameraActionTitle(*c, (*t.wins)[idx]))
 		act.SetCheckable(true)
 
 		// Enabled = has a live window AND not marked disabled
@@ -129,7 +236,26 @@ func (t *TrayController) rebuild() {
 		t.actions[idx] = act
 	}
 
-	menu.AddActions(t.actions) // as main menu
+	// ungrouped cameras at top level, grouped ones in a submenu per group
+	// (groups in order of first appearance)
+	var groupOrder []string
+	groups := map[string][]int{}
+	for i := range t.cfg.Cameras {
+		g := strings.TrimSpace(t.cfg.Cameras[i].Group)
+		if g == "" {
+			addAct(menu, t.actions[i])
+			continue
+		}
+		if _, ok := groups[g]; !ok {
+			groupOrder = append(groupOrder, g)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	for _, g := range groupOrder {
+		menu.AddMenu(t.groupMenu(g, groups[g]))
+	}
+	// recover windows that are hidden, or all closed
+	menu.AddAction("Show all cameras").OnTriggered(func() { t.showAllCameras() })
 	menu.AddSeparator()
 
 	//if t.formMenu != nil {
@@ -139,6 +265,67 @@ func (t *TrayController) rebuild() {
 	//}
 	t.installFormationsMenu(menu)
 
+	// quick tidy-up: all visible windows in an even grid on one monitor
+	if screens := qt.QGuiApplication_Screens(); len(screens) > 1 {
+		arrangeMenu := menu.AddMenuWithTitle("Arrange in grid")
+		primary := qt.QGuiApplication_PrimaryScreen()
+		for _, scr := range screens {
+			name := scr.Name()
+			title := name
+			if primary != nil && name == primary.Name() {
+				title += " (primary)"
+			}
+			arrangeMenu.AddAction(title).OnTriggered(func() { t.arrangeInGrid(name) })
+		}
+	} else {
+		menu.AddAction("Arrange in grid").OnTriggered(func() { t.arrangeInGrid("") })
+	}
+
+	// a window lost off-screen can't be right-clicked: reset it from here
+	resetMenu := menu.AddMenuWithTitle("Reset window position/size")
+	resetMenu.OnAboutToShow(func() {
+		resetMenu.Clear()
+		for _, w := range wins {
+			if w != nil && !w.closing {
+				resetMenu.AddAction(safeCamTitle(w.cfg)).OnTriggered(func() { w.ResetGeometry() })
+			}
+		}
+		if resetMenu.IsEmpty() {
+			none := resetMenu.AddAction("(No open cameras)")
+			none.SetEnabled(false)
+		}
+	})
+
+	// one composite file of all open cameras
+	gridItem := menu.AddAction("Record grid")
+	gridItem.SetCheckable(true)
+	gridItem.SetChecked(isGridRecording())
+	gridItem.OnTriggered(func() {
+		toggleGridRecording()
+		gridItem.SetChecked(isGridRecording())
+	})
+
+	// one JPEG per live camera, same moment (snapshot.go)
+	menu.AddAction("Snapshot all cameras").OnTriggered(func() { snapshotAll() })
+
+	// click-through windows can't be clicked for their own menu: undo here
+	clickItem := menu.AddAction("Turn off click-through")
+	clickItem.OnTriggered(func() {
+		for _, w := range wins {
+			if w != nil && !w.closing {
+				w.SetClickThrough(false)
+			}
+		}
+	})
+	menu.OnAboutToShow(func() {
+		t.refreshActionTitles() // connection state changes all the time
+		shown := false
+		for _, w := range wins {
+			shown = shown || (w != nil && !w.closing && w.cfg.ClickThrough)
+		}
+		clickItem.SetVisible(shown)
+	})
+
 	optionsMenu := qt.NewQMenu(nil)
 	optionsMenu.SetTitle("Settings")
 
@@ -176,6 +363,45 @@ func (t *TrayController) rebuild() {
 		openFileOrDir(env.configDir)
 	})
 
+	exportItem := optionsMenu.AddAction("Export settings…")
+	exportItem.OnTriggered(func() {
+		log.Printf("Tray export settings clicked...\n")
+		exportConfigInteractive()
+	})
+
+	importItem := optionsMenu.AddAction("Import settings…")
+	importItem.OnTriggered(func() {
+		log.Printf("Tray import settings clicked...\n")
+		importConfigInteractive()
+	})
+
+	eventsItem := optionsMenu.AddAction("Events…")
+	eventsItem.OnTriggered(func() {
+		log.Printf("Tray events clicked, showing connection events...\n")
+		ShowEventsDialog()
+	})
+
+	// verbosity for chasing an issue; back to Info on restart
+	logLevelMenu := optionsMenu.AddMenuWithTitle("Log level")
+	levelItems := map[logLevel]*qt.QAction{}
+	for _, l := range []logLevel{logError, logInfo, logDebug} {
+		a := logLevelMenu.AddAction(logLevelNames[l])
+		a.SetCheckable(true)
+		a.OnTriggered(func() { setLogLevel(l) })
+		levelItems[l] = a
+	}
+	logLevelMenu.AddSeparator()
+	ffmpegLogItem := logLevelMenu.AddAction("FFmpeg log")
+	ffmpegLogItem.SetCheckable(true)
+	ffmpegLogItem.OnTriggered(func() { setFFmpegLogging(ffmpegLogItem.IsChecked()) })
+	logLevelMenu.OnAboutToShow(func() {
+		cur := logLevel(appLogLevel.Load())
+		for l, a := range levelItems {
+			a.SetChecked(l == cur)
+		}
+		ffmpegLogItem.SetChecked(ffmpegLogOn.Load())
+	})
+
 	logFileItem := optionsMenu.AddAction("Logfile")
 	logFileItem.OnTriggered(func() {
 		log.Printf("Tray log file clicked, opening log file...\n")
@@ -217,6 +443,28 @@ func (t *TrayController) rebuild() {
 	})
 
 	t.tray.SetContextMenu(menu)
+	t.refreshThumbnails()
+}
+
+// groupMenu builds the submenu for one camera group: its cameras plus
+// bulk enable/disable. Called from rebuild with t.mu held.
+func (t *TrayController) groupMenu(name string, idxs []int) *qt.QMenu {
+	sub := qt.NewQMenu3(name)
+	for _, i := range idxs {
+		addAct(sub, t.actions[i])
+	}
+	addSep(sub)
+	setAll := func(on bool) {
+		for _, i := range idxs {
+			if i < len(t.actions) && t.actions[i] != nil && t.actions[i].IsChecked() != on {
+				t.actions[i].SetChecked(on) // goes through onActionToggled
+			}
+		}
+	}
+	sub.AddAction("Enable all in group").OnTriggered(func() { setAll(true) })
+	sub.AddAction("Disable all in group").OnTriggered(func() { setAll(false) })
+	sub.OnAboutToShow(func() { t.refreshActionTitles() }) // (offline) marks stay current
+	return sub
 }
 
 // Keep menu check state and actual window state in lockstep.
@@ -232,19 +480,21 @@ func (t *TrayController) onActionToggled(idx int, checked bool, act *qt.QAction)
 	c := &t.cfg.Cameras[idx]
 
 	if !checked {
+		// A window hidden to tray is still running: bring it back instead of closing.
+		if w := (*t.wins)[idx]; w != nil && w.win != nil && !w.win.IsVisible() {
+			showAndFocus(w.win)
+			act.BlockSignals(true)
+			act.SetChecked(true)
+			act.BlockSignals(false)
+			act.SetText(cameraActionTitle(*c, w))
+			return
+		}
 		// Turn OFF → close window and mark disabled
-		c.Disabled = true
 		// Force checkbox to OFF without re-triggering the slot
 		act.BlockSignals(true)
 		act.SetChecked(false)
 		act.BlockSignals(false)
-		// Grab and clear the window slot first, then close non-blocking.
-		w := (*t.wins)[idx]
-		(*t.wins)[idx] = nil
-		if w != nil {
-			w.SuppressOnClosedOnce()
-			w.Close()
-		}
+		t.setCameraEnabled(idx, false)
 
 		if err := SaveConfig(); err != nil {
 			log.Printf("save config: %v", err)
@@ -253,28 +503,75 @@ func (t *TrayController) onActionToggled(idx int, checked bool, act *qt.QAction)
 	}
 
 	// Turn ON → open the window and mark enabled
+	if err := t.setCameraEnabled(idx, true); err != nil {
+		log.Printf("open cam %q: %v", c.Name, err)
+		// revert checkbox to OFF if failed
+		act.BlockSignals(true)
+		act.SetChecked(false)
+		act.BlockSignals(false)
+		return
+	}
+
+	// Force checkbox to ON (should already be true, but keep them in sync)
+	act.BlockSignals(true)
+	act.SetChecked(true)
+	act.BlockSignals(false)
+
+	if err := SaveConfig(); err != nil {
+		log.Printf("save config: %v", err)
+	}
+}
+
+// setCameraEnabled opens (on) or closes the camera's window and marks it
+// enabled/disabled in the config. Called with t.mu held; does not save.
+func (t *TrayController) setCameraEnabled(idx int, on bool) error {
+	c := &t.cfg.Cameras[idx]
+	if !on {
+		c.Disabled = true
+		// Grab and clear the window slot first, then close non-blocking.
+		w := (*t.wins)[idx]
+		(*t.wins)[idx] = nil
+		if w != nil {
+			w.SuppressOnClosedOnce()
+			w.Close()
+		}
+		return nil
+	}
 	c.Disabled = false
 	if (*t.wins)[idx] == nil {
 		w, err := newCamWindow(*c, idx)
 		if err != nil {
-			log.Printf("open cam %q: %v", c.Name, err)
-			// revert checkbox to OFF if failed
-			act.BlockSignals(true)
-			act.SetChecked(false)
-			act.BlockSignals(false)
 			c.Disabled = true
-			return
+			return err
 		}
 		(*t.wins)[idx] = w
 		// give hooks + context menu
 		t.AttachWindowHooks(idx, w)
 	}
+	return nil
+}
 
-	// Force checkbox to ON (should already be true, but keep them in sync)
-	act.BlockSignals(true)
-	act.SetChecked(true)
-	act.BlockSignals(false)
+// SetCamerasEnabled enables or disables several cameras at once (settings
+// list bulk actions), keeping the tray checkboxes in sync; saves once.
+func (t *TrayController) SetCamerasEnabled(idxs []int, on bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ensureWinsLen()
 
+	for _, idx := range idxs {
+		if idx < 0 || idx >= len(t.cfg.Cameras) {
+			continue
+		}
+		if err := t.setCameraEnabled(idx, on); err != nil {
+			log.Printf("open cam %q: %v", t.cfg.Cameras[idx].Name, err)
+		}
+		if idx < len(t.actions) && t.actions[idx] != nil {
+			t.actions[idx].BlockSignals(true)
+			t.actions[idx].SetChecked(!t.cfg.Cameras[idx].Disabled)
+			t.actions[idx].BlockSignals(false)
+			t.actions[idx].SetText(cameraActionTitle(t.cfg.Cameras[idx], (*t.wins)[idx]))
+		}
+	}
 	if err := SaveConfig(); err != nil {
 		log.Printf("save config: %v", err)
 	}
@@ -297,6 +594,109 @@ func (t *TrayController) AttachWindowHooks(idx int, w *CamWindow) {
 		}
 	}
 	w.SetOnClosed(func(i int) { t.WindowWasClosed(i) })
+	w.SetOnHidden(func(i int) { t.refreshActionTitles() })
+}
+
+// cameraActionTitle is the tray label of a camera; windows hidden to tray get a suffix.
+func cameraActionTitle(c CameraConfig, w *CamWindow) string {
+	title := safeCamTitle(c)
+	if w != nil && !w.closing && w.isDown() {
+		title += " (offline)"
+	}
+	if w != nil && w.win != nil && !w.win.IsVisible() {
+		title += " (hidden)"
+	}
+	return title
+}
+
+// refreshActionTitles updates the camera labels (hidden, offline) without a full rebuild.
+func (t *TrayController) refreshActionTitles() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ensureWinsLen()
+	for i, act := range t.actions {
+		if act == nil || i >= len(t.cfg.Cameras) {
+			continue
+		}
+		act.SetText(cameraActionTitle(t.cfg.Cameras[i], (*t.wins)[i]))
+	}
+}
+
+// notifyConnection shows a tray balloon when a camera's stream drops or
+// recovers (Settings → Notify when a camera disconnects or reconnects).
+// Safe to call from the decode goroutine; never blocks it.
+func (t *TrayController) notifyConnection(name string, up bool) {
+	if t == nil || !globalConfig.NotifyConnLoss {
+		return
+	}
+	// a flapping camera: one lost/restored pair per connNotifyThrottle
+	t.notifyMu.Lock()
+	if t.connNotes == nil {
+		t.connNotes = map[string]*connNote{}
+	}
+	n := t.connNotes[name]
+	if n == nil {
+		n = &connNote{}
+		t.connNotes[name] = n
+	}
+	show := false
+	if up {
+		show, n.lostShown = n.lostShown, false
+	} else if time.Since(n.lostAt) >= connNotifyThrottle {
+		show, n.lostAt, n.lostShown = true, time.Now(), true
+	}
+	t.notifyMu.Unlock()
+	if !show {
+		return
+	}
+	msg, icon := "Connection lost, reconnecting…", qt.QSystemTrayIcon__Warning
+	if up {
+		msg, icon = "Connection restored", qt.QSystemTrayIcon__Information
+	}
+	mainthread.Start(func() {
+		if t.tray != nil && t.tray.IsVisible() {
+			t.tray.ShowMessage4(name, msg, icon)
+		}
+	})
+}
+
+// notifyUnsupportedCodec tells the user a camera can't be shown at all with
+// this build. Always shown: unlike a dropped connection it won't fix itself.
+func (t *TrayController) notifyUnsupportedCodec(name, codec string) {
+	if t == nil {
+		return
+	}
+	msg := fmt.Sprintf("Cannot decode the %s stream: this FFmpeg build has no %s decoder.", codec, codec)
+	mainthread.Start(func() {
+		if t.tray != nil && t.tray.IsVisible() {
+			t.tray.ShowMessage4(name, msg, qt.QSystemTrayIcon__Critical)
+		}
+	})
+}
+
+// notifyClip reports a saved (or failed) instant-replay clip; the button
+// has no other visible effect.
+func (t *TrayController) notifyClip(name, msg string) {
+	if t == nil {
+		return
+	}
+	mainthread.Start(func() {
+		if t.tray != nil && t.tray.IsVisible() {
+			t.tray.ShowMessage4(name, msg, qt.QSystemTrayIcon__Information)
+		}
+	})
+}
+
+// notifyLowDisk warns that the disk a camera records to is nearly full.
+func (t *TrayController) notifyLowDisk(name, msg string) {
+	if t == nil {
+		return
+	}
+	mainthread.Start(func() {
+		if t.tray != nil && t.tray.IsVisible() {
+			t.tray.ShowMessage4(name, msg, qt.QSystemTrayIcon__Warning)
+		}
+	})
 }
 
 func (t *TrayController) WindowWasClosed(idx int) {
@@ -323,6 +723,14 @@ func (t *TrayController) WindowWasClosed(idx int) {
 		return
 	}
 
+	// Closing the last window quits instead: keep the camera enabled so the
+	// whole set opens again on the next start.
+	if globalConfig.QuitOnLastClose && !t.anyWindowVisible() {
+		log.Printf("Last camera window closed, quitting...\n")
+		qt.QCoreApplication_Exit()
+		return
+	}
+
 	t.cfg.Cameras[idx].Disabled = true
 
 	// Uncheck the corresponding tray action, if any
@@ -336,3 +744,123 @@ func (t *TrayController) WindowWasClosed(idx int) {
 		log.Printf("save config: %v", err)
 	}
 }
+
+// contextMenu builds the right-click menu of a camera window on demand, so it
+// always reflects the current state: camera-specific actions first, followed
+// by the shared tray menu entries.
+func (w *CamWindow) contextMenu(shared *qt.QMenu) *qt.QMenu {
+	m := qt.NewQMenu(nil)
+	m.SetAttribute2(qt.WA_DeleteOnClose, true)
+
+	if st := w.audioStatus(); st != "" {
+		a := m.AddAction("Audio: " + st)
+		a.SetEnabled(false)
+		addSep(m)
+	}
+
+	m.AddAction("Reconnect now").OnTriggered(func() {
+		w.logf("reconnect now clicked")
+		go w.ReconnectNow()
+	})
+
+	reloadAct := m.AddAction("Reload stream")
+	reloadAct.OnTriggered(func() {
+		w.logf("reload stream clicked")
+		go w.ReloadStream()
+	})
+
+	// stop/start just this feed; the window keeps its place and last frame
+	if w.IsPaused() {
+		m.AddAction("Resume").OnTriggered(func() {
+			w.logf("resume clicked")
+			go w.StartCamera()
+		})
+	} else {
+		m.AddAction("Pause").OnTriggered(func() {
+			w.logf("pause clicked")
+			w.StopCamera()
+		})
+	}
+
+	stretchAct := m.AddAction("Stretch to fit")
+	stretchAct.SetCheckable(true)
+	stretchAct.SetChecked(w.cfg.Stretch)
+	stretchAct.OnToggled(func(on bool) { w.SetStretch(on) })
+	keepAct := m.AddAction("Keep aspect in fullscreen")
+	keepAct.SetCheckable(true)
+	keepAct.SetChecked(w.cfg.FullscreenKeepAspect)
+	keepAct.SetEnabled(w.cfg.Stretch)
+	keepAct.OnToggled(func(on bool) { w.SetFullscreenKeepAspect(on) })
+
+	muteAct := m.AddAction("Mute")
+	muteAct.SetCheckable(true)
+	muteAct.SetChecked(w.muted.Load())
+	muteAct.OnTriggered(func() { w.SetMute(muteAct.IsChecked()) })
+
+	// stats overlays for just this window, e.g. while debugging one camera
+	w.addOverlayMenu(m)
+
+	// e.g. to open the same stream in VLC, or to quote it in a bug report
+	copyMenu := m.AddMenuWithTitle("Copy stream URL")
+	copyMenu.AddAction("With credentials").OnTriggered(func() { w.CopyStreamURL(false) })
+	copyMenu.AddAction("Password masked").OnTriggered(func() { w.CopyStreamURL(true) })
+	m.AddAction("Open in external player").OnTriggered(func() { w.OpenExternally() })
+	if n := w.cfg.ClipSeconds; n > 0 {
+		m.AddAction(fmt.Sprintf("Save clip (last %ds)", n)).OnTriggered(func() { w.SaveClip() })
+	}
+
+	// digital zoom (mouse wheel, drag to pan) and its saved views
+	zoomMenu := m.AddMenuWithTitle("Zoom")
+	saveZoom := zoomMenu.AddAction("Save view as preset…")
+	saveZoom.SetEnabled(w.view != nil && w.view.zoomed())
+	saveZoom.OnTriggered(func() { w.SaveZoomPreset() })
+	resetZoom := zoomMenu.AddAction("Reset zoom")
+	resetZoom.SetEnabled(w.view != nil && w.view.zoomed())
+	resetZoom.OnTriggered(func() { w.ResetZoom() })
+	if len(w.cfg.ZoomPresets) > 0 {
+		zoomMenu.AddSeparator()
+		for _, p := range w.cfg.ZoomPresets {
+			zoomMenu.AddAction(p.Name).OnTriggered(func() { w.RecallZoomPreset(p) })
+		}
+		zoomMenu.AddSeparator()
+		delMenu := zoomMenu.AddMenuWithTitle("Delete preset")
+		for _, p := range w.cfg.ZoomPresets {
+			delMenu.AddAction(p.Name).OnTriggered(func() { w.DeleteZoomPreset(p.Name) })
+		}
+	}
+
+	// ONVIF presets stored on the camera
+	if w.ptzEnabled() {
+		ptzMenu := m.AddMenuWithTitle("PTZ presets")
+		presets := w.ptzPresets()
+		for _, p := range presets {
+			ptzMenu.AddAction(p.Name).OnTriggered(func() { w.PTZGotoPreset(p) })
+		}
+		if len(presets) == 0 {
+			none := ptzMenu.AddAction("(No presets loaded)")
+			none.SetEnabled(false)
+		}
+		ptzMenu.AddSeparator()
+		ptzMenu.AddAction("Refresh presets").OnTriggered(func() { w.PTZRefreshPresets() })
+	}
+
+	// explicit glue groups (borderless drag moves the whole group)
+	m.AddAction("Glue to neighbors").OnTriggered(func() { w.GlueToNeighbors() })
+	if w.isGlued() {
+		m.AddAction("Unglue").OnTriggered(func() { w.Unglue() })
+	}
+
+	// lost or tiny after a monitor change: back to the default size, centered
+	m.AddAction("Reset window position/size").OnTriggered(func() { w.ResetGeometry() })
+
+	// HUD overlay: let clicks through to what's below (undo from the tray menu)
+	m.AddAction("Click-through (turn off from tray)").OnTriggered(func() {
+		w.SetClickThrough(true)
+	})
+
+	if shared != nil {
+		m.AddSeparator()
+		m.AddActions(shared.Actions())
+	}
+	return m
+}
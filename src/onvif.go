@@ -0,0 +1,270 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+/*
+Minimal ONVIF client: just enough SOAP for PTZ. The device service gives the
+media and PTZ service addresses; the first media profile with a PTZ
+configuration is the one moved. Requests carry a WS-Security UsernameToken
+digest, stamped with the camera's clock since many cameras reject a skewed
+Created time.
+*/
+
+const (
+	onvifTimeout = 5 * time.Second
+
+	nsDevice = "http://www.onvif.org/ver10/device/wsdl"
+	nsMedia  = "http://www.onvif.org/ver10/media/wsdl"
+	nsPTZ    = "http://www.onvif.org/ver20/ptz/wsdl"
+	nsSchema = "http://www.onvif.org/ver10/schema"
+)
+
+type onvifClient struct {
+	device     *url.URL
+	user, pass string
+	hc         *http.Client
+	clockSkew  time.Duration // camera clock minus ours
+	mediaURL   string
+	ptzURL     string
+	profile    string // media profile token PTZ commands apply to
+}
+
+// onvifPreset is a PTZ preset stored on the camera.
+type onvifPreset struct {
+	Token string
+	Name  string
+}
+
+// newONVIFClient connects to the device service at raw: clock, service
+// addresses and the PTZ profile. Credentials in raw win over user/pass.
+func newONVIFClient(raw, user, pass string) (*onvifClient, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("bad ONVIF URL %q", raw)
+	}
+	if u.User != nil {
+		user = u.User.Username()
+		pass, _ = u.User.Password()
+		u.User = nil
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/onvif/device_service"
+	}
+	c := &onvifClient{device: u, hc: &http.Client{Timeout: onvifTimeout}}
+
+	// sent without credentials, as the spec allows; failing it just means
+	// no skew correction
+	var t struct {
+		UTC struct {
+			Year   int `xml:"Date>Year"`
+			Month  int `xml:"Date>Month"`
+			Day    int `xml:"Date>Day"`
+			Hour   int `xml:"Time>Hour"`
+			Minute int `xml:"Time>Minute"`
+			Second int `xml:"Time>Second"`
+		} `xml:"Body>GetSystemDateAndTimeResponse>SystemDateAndTime>UTCDateTime"`
+	}
+	if err := c.call(u.String(), `<tds:GetSystemDateAndTime xmlns:tds="`+nsDevice+`"/>`, &t); err == nil && t.UTC.Year > 0 {
+		cam := time.Date(t.UTC.Year, time.Month(t.UTC.Month), t.UTC.Day, t.UTC.Hour, t.UTC.Minute, t.UTC.Second, 0, time.UTC)
+		c.clockSkew = time.Until(cam)
+	}
+	c.user, c.pass = user, pass
+
+	var caps struct {
+		Media string `xml:"Body>GetCapabilitiesResponse>Capabilities>Media>XAddr"`
+		PTZ   string `xml:"Body>GetCapabilitiesResponse>Capabilities>PTZ>XAddr"`
+	}
+	if err := c.call(u.String(), `<tds:GetCapabilities xmlns:tds="`+nsDevice+`"><tds:Category>All</tds:Category></tds:GetCapabilities>`, &caps); err != nil {
+		return nil, fmt.Errorf("GetCapabilities: %w", err)
+	}
+	if caps.PTZ == "" {
+		return nil, errors.New("camera has no ONVIF PTZ service")
+	}
+	c.mediaURL, c.ptzURL = c.sameHost(caps.Media), c.sameHost(caps.PTZ)
+
+	var prof struct {
+		Profiles []struct {
+			Token string    `xml:"token,attr"`
+			PTZ   *struct{} `xml:"PTZConfiguration"`
+		} `xml:"Body>GetProfilesResponse>Profiles"`
+	}
+	if err := c.call(c.mediaURL, `<trt:GetProfiles xmlns:trt="`+nsMedia+`"/>`, &prof); err != nil {
+		return nil, fmt.Errorf("GetProfiles: %w", err)
+	}
+	for _, p := range prof.Profiles {
+		if p.PTZ != nil {
+			c.profile = p.Token
+			break
+		}
+	}
+	if c.profile == "" && len(prof.Profiles) > 0 {
+		c.profile = prof.Profiles[0].Token
+	}
+	if c.profile == "" {
+		return nil, errors.New("camera reports no media profiles")
+	}
+	return c, nil
+}
+
+// sameHost keeps a service address's path but points it at the host we
+// reached the device on: cameras behind NAT/port forwarding report their
+// internal address.
+func (c *onvifClient) sameHost(xaddr string) string {
+	u, err := url.Parse(xaddr)
+	if err != nil || u.Path == "" {
+		return c.device.String()
+	}
+	u.Scheme, u.Host = c.device.Scheme, c.device.Host
+	return u.String()
+}
+
+// ContinuousMove starts moving at the given velocities (-1..1); zero axes
+// are left out so pan/tilt-only or zoom-only cameras accept it.
+func (c *onvifClient) ContinuousMove(pan, tilt, zoom float64) error {
+	var v strings.Builder
+	if pan != 0 || tilt != 0 {
+		fmt.Fprintf(&v, `<tt:PanTilt x="%.2f" y="%.2f"/>`, pan, tilt)
+	}
+	if zoom != 0 {
+		fmt.Fprintf(&v, `<tt:Zoom x="%.2f"/>`, zoom)
+	}
+	body := `<tptz:ContinuousMove xmlns:tptz="` + nsPTZ + `" xmlns:tt="` + nsSchema + `">` +
+		`<tptz:ProfileToken>` + xmlText(c.profile) + `</tptz:ProfileToken>` +
+		`<tptz:Velocity>` + v.String() + `</tptz:Velocity></tptz:ContinuousMove>`
+	return c.call(c.ptzURL, body, nil)
+}
+
+// Stop ends any pan/tilt/zoom movement.
+func (c *onvifClient) Stop() error {
+	body := `<tptz:Stop xmlns:tptz="` + nsPTZ + `">` +
+		`<tptz:ProfileToken>` + xmlText(c.profile) + `</tptz:ProfileToken>` +
+		`<tptz:PanTilt>true</tptz:PanTilt><tptz:Zoom>true</tptz:Zoom></tptz:Stop>`
+	return c.call(c.ptzURL, body, nil)
+}
+
+// Presets lists the presets stored on the camera.
+func (c *onvifClient) Presets() ([]onvifPreset, error) {
+	var r struct {
+		Presets []struct {
+			Token string `xml:"token,attr"`
+			Name  string `xml:"Name"`
+		} `xml:"Body>GetPresetsResponse>Preset"`
+	}
+	body := `<tptz:GetPresets xmlns:tptz="` + nsPTZ + `"><tptz:ProfileToken>` + xmlText(c.profile) + `</tptz:ProfileToken></tptz:GetPresets>`
+	if err := c.call(c.ptzURL, body, &r); err != nil {
+		return nil, err
+	}
+	out := make([]onvifPreset, 0, len(r.Presets))
+	for _, p := range r.Presets {
+		name := p.Name
+		if name == "" {
+			name = p.Token
+		}
+		out = append(out, onvifPreset{Token: p.Token, Name: name})
+	}
+	return out, nil
+}
+
+// GotoPreset moves the camera to a stored preset.
+func (c *onvifClient) GotoPreset(token string) error {
+	body := `<tptz:GotoPreset xmlns:tptz="` + nsPTZ + `">` +
+		`<tptz:ProfileToken>` + xmlText(c.profile) + `</tptz:ProfileToken>` +
+		`<tptz:PresetToken>` + xmlText(token) + `</tptz:PresetToken></tptz:GotoPreset>`
+	return c.call(c.ptzURL, body, nil)
+}
+
+// call posts one SOAP request and decodes the envelope into out (if set).
+// A SOAP fault becomes the error.
+func (c *onvifClient) call(endpoint, body string, out any) error {
+	var env bytes.Buffer
+	env.WriteString(`<?xml version="1.0" encoding="UTF-8"?><s:Envelope xmlns:s="http://www.w3.org/2003/05/soap-envelope">`)
+	env.WriteString(c.security())
+	env.WriteString(`<s:Body>` + body + `</s:Body></s:Envelope>`)
+
+	resp, err := c.hc.Post(endpoint, "application/soap+xml; charset=utf-8", &env)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	var fault struct {
+		Reason string `xml:"Body>Fault>Reason>Text"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		if xml.Unmarshal(data, &fault) == nil && fault.Reason != "" {
+			return fmt.Errorf("%s: %s", resp.Status, fault.Reason)
+		}
+		return errors.New(resp.Status)
+	}
+	if out == nil {
+		return nil
+	}
+	return xml.Unmarshal(data, out)
+}
+
+// security is the WS-Security header: PasswordDigest =
+// base64(sha1(nonce + created + password)); empty without a user.
+func (c *onvifClient) security() string {
+	if c.user == "" {
+		return ""
+	}
+	nonce := make([]byte, 16)
+	_, _ = rand.Read(nonce)
+	created := time.Now().Add(c.clockSkew).UTC().Format("2006-01-02T15:04:05.000Z")
+	h := sha1.New()
+	h.Write(nonce)
+	h.Write([]byte(created))
+	h.Write([]byte(c.pass))
+	digest := base64.StdEncoding.EncodeToString(h.Sum(nil))
+
+	return `<s:Header><wsse:Security s:mustUnderstand="1"` +
+		` xmlns:wsse="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd"` +
+		` xmlns:wsu="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd">` +
+		`<wsse:UsernameToken><wsse:Username>` + xmlText(c.user) + `</wsse:Username>` +
+		`<wsse:Password Type="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-username-token-profile-1.0#PasswordDigest">` + digest + `</wsse:Password>` +
+		`<wsse:Nonce EncodingType="http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-soap-message-security-1.0#Base64Binary">` + base64.StdEncoding.EncodeToString(nonce) + `</wsse:Nonce>` +
+		`<wsu:Created>` + created + `</wsu:Created></wsse:UsernameToken></wsse:Security></s:Header>`
+}
+
+// xmlText escapes s for use as XML character data.
+func xmlText(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,117 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"log"
+
+	"github.com/mappu/miqt/qt"
+)
+
+// overlay is one of the stats overlays drawn over the video. Each is a global
+// setting that a camera can override (show_fps etc. in its camera entry).
+type overlay int
+
+const (
+	ovHealthChip overlay = iota
+	ovFPS
+	ovBitrate
+	ovDrops
+	ovCPU
+	ovStreamInfo
+	ovAudioMeter
+)
+
+// overlays lists the overlays in menu order with their camera override field
+// and the global setting used when the camera leaves it unset.
+var overlays = [...]struct {
+	title  string
+	cam    func(c *CameraConfig) **bool
+	global func() bool
+}{
+	ovHealthChip: {"Health chip", func(c *CameraConfig) **bool { return &c.HealthChip }, func() bool { return globalConfig.HealthChip }},
+	ovFPS:        {"FPS", func(c *CameraConfig) **bool { return &c.ShowFPS }, func() bool { return globalConfig.ShowFPS }},
+	ovBitrate:    {"Bitrate", func(c *CameraConfig) **bool { return &c.ShowBitrate }, func() bool { return globalConfig.ShowBitrate }},
+	ovDrops:      {"Dropped frames %", func(c *CameraConfig) **bool { return &c.ShowDrops }, func() bool { return globalConfig.ShowDrops }},
+	ovCPU:        {"CPU usage", func(c *CameraConfig) **bool { return &c.ShowCPUUsage }, func() bool { return globalConfig.ShowCPUUsage }},
+	ovStreamInfo: {"Stream info", func(c *CameraConfig) **bool { return &c.ShowStreamInfo }, func() bool { return globalConfig.ShowStreamInfo }},
+	ovAudioMeter: {"Audio level meter", func(c *CameraConfig) **bool { return &c.ShowAudioMeter }, func() bool { return globalConfig.ShowAudioMeter }},
+}
+
+// showOverlay reports whether overlay o is on for this camera: its own
+// setting if it has one, else the global one.
+func (c *CameraConfig) showOverlay(o overlay) bool {
+	if v := *overlays[o].cam(c); v != nil {
+		return *v
+	}
+	return overlays[o].global()
+}
+
+// hasOverlayOverrides reports whether any overlay is set on the camera itself.
+func (c *CameraConfig) hasOverlayOverrides() bool {
+	for _, ov := range overlays {
+		if *ov.cam(c) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// SetOverlay turns overlay o on or off for this camera only and saves it.
+func (w *CamWindow) SetOverlay(o overlay, on bool) {
+	w.updateOverlays(func(c *CameraConfig) { *overlays[o].cam(c) = &on })
+}
+
+// ResetOverlays drops the camera's overlay overrides; the global settings apply again.
+func (w *CamWindow) ResetOverlays() {
+	w.updateOverlays(func(c *CameraConfig) {
+		for _, ov := range overlays {
+			*ov.cam(c) = nil
+		}
+	})
+}
+
+func (w *CamWindow) updateOverlays(fn func(c *CameraConfig)) {
+	fn(&w.cfg)
+	if w.view != nil {
+		w.view.Update()
+	}
+	if err := UpdateCamera(w.idKey, fn); err != nil {
+		log.Printf("save config failed: %v", err)
+	}
+}
+
+// addOverlayMenu adds the "Overlays" submenu: one check item per overlay and
+// a reset back to the global settings.
+func (w *CamWindow) addOverlayMenu(m *qt.QMenu) {
+	om := m.AddMenuWithTitle("Overlays")
+	for i, ov := range overlays {
+		o := overlay(i)
+		a := om.AddAction(ov.title)
+		a.SetCheckable(true)
+		a.SetChecked(w.cfg.showOverlay(o))
+		a.OnTriggered(func() { w.SetOverlay(o, a.IsChecked()) })
+	}
+	om.AddSeparator()
+	reset := om.AddAction("Use global settings")
+	reset.SetEnabled(w.cfg.hasOverlayOverrides())
+	reset.OnTriggered(func() { w.ResetOverlays() })
+}
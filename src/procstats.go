@@ -0,0 +1,102 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+/*
+Whole-process resource usage (CPU, memory), complementing the per-camera
+busy-time estimate. Platform specifics (processCPUTime/processRSS) live in
+darwin.go, windows.go and darwin_stub.go.
+*/
+
+// ProcStats is one sample of the app's resource usage.
+type ProcStats struct {
+	CPUPct     float64 // percent of one core since the previous sample
+	RSS        uint64  // resident memory, bytes
+	GoHeap     uint64  // Go heap in use, bytes
+	Goroutines int
+}
+
+// procSampler derives CPU% from the cumulative process CPU time between calls.
+type procSampler struct {
+	mu      sync.Mutex
+	lastAt  time.Time
+	lastCPU time.Duration
+}
+
+func (s *procSampler) Sample() ProcStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	cpu := processCPUTime()
+	var st ProcStats
+	if !s.lastAt.IsZero() {
+		if dt := now.Sub(s.lastAt); dt > 0 {
+			st.CPUPct = float64(cpu-s.lastCPU) / float64(dt) * 100.0
+			if st.CPUPct < 0 {
+				st.CPUPct = 0
+			}
+		}
+	}
+	s.lastAt, s.lastCPU = now, cpu
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	st.RSS = processRSS()
+	st.GoHeap = ms.HeapInuse
+	st.Goroutines = runtime.NumGoroutine()
+	return st
+}
+
+// formatBytes renders a byte count as a short human string (KiB/MiB/GiB).
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// cameraTotals sums decoded fps over all open camera windows and reports how
+// many decode loops are running. Call on the Qt main thread (MetricsSnapshot).
+func cameraTotals() (fps float64, open, decoding int) {
+	for _, w := range wins {
+		if w == nil || w.closing {
+			continue
+		}
+		open++
+		f, _, _, _, _ := w.MetricsSnapshot()
+		fps += f
+	}
+	return fps, open, int(activeDecoders.Load())
+}
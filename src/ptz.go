@@ -0,0 +1,254 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mappu/miqt/qt"
+)
+
+const (
+	ptzSpeed = 0.5              // ContinuousMove velocity of the pad and arrow keys
+	ptzIdle  = 30 * time.Second // worker goroutine exits after this long without commands
+)
+
+// ptzCmd is one queued ONVIF request.
+type ptzCmd struct {
+	name string
+	fn   func(*onvifClient) error
+}
+
+// ptzControl sends a camera's PTZ commands in order from one worker
+// goroutine, so a Stop never overtakes its move and the UI never waits on
+// the network.
+type ptzControl struct {
+	mu      sync.Mutex
+	queue   chan ptzCmd
+	running bool
+
+	client  *onvifClient // worker only
+	key     string       // URL + credentials the client was made for
+	presets atomic.Pointer[[]onvifPreset]
+}
+
+// ptzEnabled reports whether the camera is set up for ONVIF PTZ.
+func (w *CamWindow) ptzEnabled() bool { return w.cfg.PTZ && w.cfg.ONVIFURL != "" }
+
+func (w *CamWindow) ptzSend(name string, fn func(*onvifClient) error) {
+	if !w.ptzEnabled() {
+		return
+	}
+	p := &w.ptz
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.queue == nil {
+		p.queue = make(chan ptzCmd, 16)
+	}
+	if !p.running {
+		p.running = true
+		go w.ptzWorker()
+	}
+	select {
+	case p.queue <- ptzCmd{name, fn}:
+	default:
+		w.logf("ptz: %s dropped, camera not answering", name)
+	}
+}
+
+func (w *CamWindow) ptzWorker() {
+	p := &w.ptz
+	for {
+		select {
+		case c := <-p.queue:
+			cl, err := w.ptzClient()
+			if err == nil {
+				err = c.fn(cl)
+			}
+			if err != nil {
+				w.logf("ptz %s: %v", c.name, err)
+				p.client = nil // reconnect on the next command
+			}
+		case <-time.After(ptzIdle):
+			p.mu.Lock()
+			if len(p.queue) > 0 {
+				p.mu.Unlock()
+				continue
+			}
+			p.running = false
+			p.mu.Unlock()
+			return
+		}
+	}
+}
+
+// ptzClient returns the connected client, (re)connecting when there is
+// none yet or the ONVIF settings changed. Fetches the presets on connect.
+func (w *CamWindow) ptzClient() (*onvifClient, error) {
+	p := &w.ptz
+	key := w.cfg.ONVIFURL + "\x00" + w.cfg.Username + "\x00" + w.cfg.Password
+	if p.client != nil && p.key == key {
+		return p.client, nil
+	}
+	c, err := newONVIFClient(w.cfg.ONVIFURL, w.cfg.Username, w.cfg.Password)
+	if err != nil {
+		return nil, err
+	}
+	p.client, p.key = c, key
+	w.logf("ptz: connected to %s (profile %s)", c.device.Redacted(), c.profile)
+	if list, err := c.Presets(); err == nil {
+		p.presets.Store(&list)
+	} else {
+		w.logf("ptz presets: %v", err)
+	}
+	return c, nil
+}
+
+// PTZMove starts moving in the given directions (-1, 0 or 1 per axis)
+// until PTZStop.
+func (w *CamWindow) PTZMove(pan, tilt, zoom float64) {
+	w.ptzSend("move", func(c *onvifClient) error {
+		return c.ContinuousMove(pan*ptzSpeed, tilt*ptzSpeed, zoom*ptzSpeed)
+	})
+}
+
+func (w *CamWindow) PTZStop() {
+	w.ptzSend("stop", func(c *onvifClient) error { return c.Stop() })
+}
+
+// PTZGotoPreset moves the camera to one of its stored presets.
+func (w *CamWindow) PTZGotoPreset(p onvifPreset) {
+	w.logf("ptz preset %q", p.Name)
+	w.ptzSend("preset", func(c *onvifClient) error { return c.GotoPreset(p.Token) })
+}
+
+// PTZRefreshPresets reloads the preset list shown in the context menu.
+func (w *CamWindow) PTZRefreshPresets() {
+	w.ptzSend("presets", func(c *onvifClient) error {
+		list, err := c.Presets()
+		if err == nil {
+			w.ptz.presets.Store(&list)
+		}
+		return err
+	})
+}
+
+// ptzPresets is the last fetched preset list (nil before connecting).
+func (w *CamWindow) ptzPresets() []onvifPreset {
+	if l := w.ptz.presets.Load(); l != nil {
+		return *l
+	}
+	return nil
+}
+
+// handlePTZKey drives the camera with the arrow keys (pan/tilt) and +/-
+// (zoom): moving while held, stopping on release.
+func (w *CamWindow) handlePTZKey(ev *qt.QKeyEvent, down bool) bool {
+	if !w.ptzEnabled() || int(ev.Modifiers())&^int(qt.KeypadModifier) != 0 {
+		return false
+	}
+	var pan, tilt, zoom float64
+	switch qt.Key(ev.Key()) {
+	case qt.Key_Left:
+		pan = -1
+	case qt.Key_Right:
+		pan = 1
+	case qt.Key_Up:
+		tilt = 1
+	case qt.Key_Down:
+		tilt = -1
+	case qt.Key_Plus, qt.Key_Equal:
+		zoom = 1
+	case qt.Key_Minus:
+		zoom = -1
+	default:
+		return false
+	}
+	if ev.IsAutoRepeat() {
+		return true // already moving
+	}
+	if down {
+		w.PTZMove(pan, tilt, zoom)
+	} else {
+		w.PTZStop()
+	}
+	return true
+}
+
+// installPTZPad adds the pan/tilt/zoom buttons to the video widget; they
+// show while the mouse is over a PTZ camera and move it while held.
+func (w *CamWindow) installPTZPad() {
+	v := w.view
+	pad := qt.NewQWidget(v.QWidget)
+	pad.SetStyleSheet(
+		"QToolButton { color: white; background: rgba(0,0,0,0.55); border: none; border-radius: 4px; font-size: 12pt; }" +
+			"QToolButton:pressed { background: rgba(0,150,255,0.7); }",
+	)
+	grid := qt.NewQGridLayout(pad)
+	grid.SetContentsMargins(0, 0, 0, 0)
+	grid.SetSpacing(2)
+	for _, b := range []struct {
+		text            string
+		row, col        int
+		pan, tilt, zoom float64
+	}{
+		{"▲", 0, 1, 0, 1, 0},
+		{"◀", 1, 0, -1, 0, 0},
+		{"▶", 1, 2, 1, 0, 0},
+		{"▼", 2, 1, 0, -1, 0},
+		{"+", 0, 3, 0, 0, 1},
+		{"−", 2, 3, 0, 0, -1},
+	} {
+		btn := qt.NewQToolButton(pad)
+		btn.SetText(b.text)
+		btn.SetFixedSize2(28, 28)
+		btn.SetFocusPolicy(qt.NoFocus)
+		btn.OnPressed(func() { w.PTZMove(b.pan, b.tilt, b.zoom) })
+		btn.OnReleased(func() { w.PTZStop() })
+		grid.AddWidget2(btn.QWidget, b.row, b.col)
+	}
+	pad.AdjustSize()
+	pad.Hide()
+	v.ptzPad = pad
+
+	v.OnEnterEvent(func(super func(event *qt.QEvent), ev *qt.QEvent) {
+		super(ev)
+		if w.ptzEnabled() {
+			v.placePTZPad()
+			pad.Show()
+		}
+	})
+	v.OnLeaveEvent(func(super func(event *qt.QEvent), ev *qt.QEvent) {
+		super(ev)
+		pad.Hide()
+	})
+}
+
+// placePTZPad keeps the pad in the bottom-right corner.
+func (w *VideoWidget) placePTZPad() {
+	if w.ptzPad == nil {
+		return
+	}
+	const margin = 8
+	w.ptzPad.Move(w.Width()-w.ptzPad.Width()-margin, w.Height()-w.ptzPad.Height()-margin)
+}
@@ -0,0 +1,243 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/asticode/go-astiav"
+)
+
+// defaultRecordCRF is the x264 quality of re-encoded recordings without record_crf.
+const defaultRecordCRF = 23
+
+// recordReencodes reports whether the camera's recordings are re-encoded to
+// H.264 instead of copying the camera's packets (the default).
+func recordReencodes(c CameraConfig) bool {
+	return strings.EqualFold(c.RecordVideoMode, "h264")
+}
+
+// recEncoder re-encodes a camera's decoded frames into its recording file:
+// downscaled to record_size and at record_kbps or record_crf. One per file,
+// so every file starts on a fresh keyframe.
+type recEncoder struct {
+	oc         *astiav.FormatContext // the recording; owned by the decode loop
+	ctx        *astiav.CodecContext
+	st         *astiav.Stream
+	ssc        *astiav.SoftwareScaleContext
+	srcW, srcH int
+	srcPix     astiav.PixelFormat
+	dst        *astiav.Frame
+	hw         *astiav.Frame // hardware-decoded frames are downloaded here first
+	pkt        *astiav.Packet
+	lastPts    int64
+	desc       string // for the log, e.g. "libx264 1280x720 crf 23"
+}
+
+// newRecEncoder adds an encoded video stream to oc for a srcW x srcH source
+// whose frames are timed in tb. Call before oc.WriteHeader.
+func newRecEncoder(oc *astiav.FormatContext, c CameraConfig, srcW, srcH int, tb, rate astiav.Rational) (e *recEncoder, err error) {
+	e = &recEncoder{oc: oc, lastPts: -1}
+	defer func() {
+		if err != nil {
+			e.free()
+			e = nil
+		}
+	}()
+
+	if srcW <= 0 || srcH <= 0 {
+		return e, errors.New("source size unknown")
+	}
+	codec := h264Codec()
+	if codec == nil {
+		return e, errors.New("no H.264 or MPEG-4 encoder in this FFmpeg build")
+	}
+	pix := encoderPixelFormat(codec)
+	maxW, maxH := parseSizeCap(c.RecordSize)
+	w, h := capSize(srcW, srcH, maxW, maxH)
+	w, h = max(2, w&^1), max(2, h&^1) // 4:2:0 wants even sizes
+	fps := 25
+	if rate.Num() > 0 && rate.Den() > 0 {
+		fps = max(1, int(math.Round(rate.Float64())))
+	}
+
+	if e.ctx = astiav.AllocCodecContext(codec); e.ctx == nil {
+		return e, errors.New("AllocCodecContext failed")
+	}
+	e.ctx.SetWidth(w)
+	e.ctx.SetHeight(h)
+	e.ctx.SetPixelFormat(pix)
+	e.ctx.SetTimeBase(tb)
+	if rate.Num() > 0 && rate.Den() > 0 {
+		e.ctx.SetFramerate(rate)
+	}
+	e.ctx.SetGopSize(fps * 2) // a keyframe (and MP4 fragment) every 2 s
+	e.ctx.SetMaxBFrames(0)
+	if oc.OutputFormat().Flags().Has(astiav.IOFormatFlagGlobalheader) {
+		e.ctx.SetFlags(e.ctx.Flags().Add(astiav.CodecContextFlagGlobalHeader))
+	}
+	encOpts := astiav.NewDictionary()
+	defer encOpts.Free()
+	e.desc = fmt.Sprintf("%s %dx%d", codec.Name(), w, h)
+	switch {
+	case c.RecordKbps > 0:
+		e.ctx.SetBitRate(int64(c.RecordKbps) * 1000)
+		if codec.Name() == "libx264" {
+			_ = encOpts.Set("preset", "veryfast", 0)
+		}
+		e.desc += fmt.Sprintf(" %d kbps", c.RecordKbps)
+	case codec.Name() == "libx264":
+		crf := c.RecordCRF
+		if crf <= 0 || crf > 51 {
+			crf = defaultRecordCRF
+		}
+		_ = encOpts.Set("preset", "veryfast", 0)
+		_ = encOpts.Set("crf", fmt.Sprint(crf), 0)
+		e.desc += fmt.Sprintf(" crf %d", crf)
+	default:
+		e.ctx.SetBitRate(int64(w*h) * int64(fps) / 8) // ~0.125 bit/pixel, as grid recordings
+	}
+	if err = e.ctx.Open(codec, encOpts); err != nil {
+		return e, fmt.Errorf("open %s encoder: %w", codec.Name(), err)
+	}
+
+	if e.st = oc.NewStream(codec); e.st == nil {
+		return e, errors.New("NewStream failed")
+	}
+	if err = e.ctx.ToCodecParameters(e.st.CodecParameters()); err != nil {
+		return e, fmt.Errorf("ToCodecParameters: %w", err)
+	}
+	e.st.SetTimeBase(tb)
+
+	e.dst = astiav.AllocFrame()
+	e.dst.SetWidth(w)
+	e.dst.SetHeight(h)
+	e.dst.SetPixelFormat(pix)
+	if err = e.dst.AllocBuffer(0); err != nil {
+		return e, fmt.Errorf("alloc encoder frame: %w", err)
+	}
+	e.hw = astiav.AllocFrame()
+	e.pkt = astiav.AllocPacket()
+	return e, nil
+}
+
+// encode converts one decoded frame and encodes it at pts (encoder time
+// base, counted from the file's start). Frames from before the start or out
+// of order are skipped.
+func (e *recEncoder) encode(f *astiav.Frame, pts int64) error {
+	if pts == astiav.NoPtsValue || pts <= e.lastPts {
+		return nil
+	}
+	if f.HardwareFramesContext() != nil {
+		if err := f.TransferHardwareData(e.hw); err != nil {
+			return fmt.Errorf("hwframe transfer: %w", err)
+		}
+		defer e.hw.Unref()
+		f = e.hw
+	}
+	if err := e.ensureScaler(f); err != nil {
+		return err
+	}
+	// the encoder may still hold the previous frame
+	if err := e.dst.MakeWritable(); err != nil {
+		return fmt.Errorf("MakeWritable: %w", err)
+	}
+	if err := e.ssc.ScaleFrame(f, e.dst); err != nil {
+		return fmt.Errorf("ScaleFrame: %w", err)
+	}
+	e.dst.SetPts(pts)
+	e.lastPts = pts
+	if err := e.ctx.SendFrame(e.dst); err != nil && !errors.Is(err, astiav.ErrEagain) {
+		return fmt.Errorf("SendFrame: %w", err)
+	}
+	return e.drain()
+}
+
+// ensureScaler (re)creates the converter when the source size or format changes;
+// the output size stays fixed for the file.
+func (e *recEncoder) ensureScaler(f *astiav.Frame) error {
+	sw, sh, sp := f.Width(), f.Height(), f.PixelFormat()
+	if e.ssc != nil && sw == e.srcW && sh == e.srcH && sp == e.srcPix {
+		return nil
+	}
+	if e.ssc != nil {
+		e.ssc.Free()
+		e.ssc = nil
+	}
+	ssc, err := astiav.CreateSoftwareScaleContext(sw, sh, sp, e.dst.Width(), e.dst.Height(), e.dst.PixelFormat(),
+		astiav.NewSoftwareScaleContextFlags(astiav.SoftwareScaleContextFlagBilinear))
+	if err != nil {
+		return fmt.Errorf("CreateSoftwareScaleContext(%dx%d %v): %w", sw, sh, sp, err)
+	}
+	e.ssc = ssc
+	e.srcW, e.srcH, e.srcPix = sw, sh, sp
+	return nil
+}
+
+// drain writes out every packet the encoder has ready.
+func (e *recEncoder) drain() error {
+	for {
+		if err := e.ctx.ReceivePacket(e.pkt); err != nil {
+			if errors.Is(err, astiav.ErrEagain) || errors.Is(err, astiav.ErrEof) {
+				return nil
+			}
+			return fmt.Errorf("ReceivePacket: %w", err)
+		}
+		e.pkt.SetStreamIndex(e.st.Index())
+		e.pkt.RescaleTs(e.ctx.TimeBase(), e.st.TimeBase())
+		err := e.oc.WriteInterleavedFrame(e.pkt)
+		e.pkt.Unref()
+		if err != nil && !errors.Is(err, astiav.ErrEagain) {
+			return fmt.Errorf("WriteInterleavedFrame: %w", err)
+		}
+	}
+}
+
+// close flushes the encoder into the file (before its trailer) and frees it.
+func (e *recEncoder) close() error {
+	var err error
+	if err = e.ctx.SendFrame(nil); err == nil {
+		err = e.drain()
+	}
+	e.free()
+	return err
+}
+
+func (e *recEncoder) free() {
+	if e.pkt != nil {
+		e.pkt.Free()
+	}
+	if e.ssc != nil {
+		e.ssc.Free()
+	}
+	if e.dst != nil {
+		e.dst.Free()
+	}
+	if e.hw != nil {
+		e.hw.Free()
+	}
+	if e.ctx != nil {
+		e.ctx.Free()
+	}
+}
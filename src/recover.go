@@ -0,0 +1,185 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	astiav "github.com/asticode/go-astiav"
+)
+
+/*
+Recovery of recordings interrupted by a crash or power loss.
+
+startRecorder drops a "<file>.recording" marker next to every file it
+writes and closeRecorder removes it once the trailer is written. Whatever
+markers survive until the next start belong to files that were never
+finalized; we remux those (stream copy) so players get a proper index.
+*/
+
+const recordingMarkerExt = ".recording"
+
+// writeRecordingMarker flags path as "being recorded".
+func writeRecordingMarker(path string) {
+	if err := os.WriteFile(path+recordingMarkerExt, nil, 0o644); err != nil {
+		log.Printf("recording: cannot write marker for %s: %v", path, err)
+	}
+}
+
+// clearRecordingMarker flags path as finalized.
+func clearRecordingMarker(path string) {
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path + recordingMarkerExt); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("recording: cannot remove marker for %s: %v", path, err)
+	}
+}
+
+// recoverInterruptedRecordings collects leftover markers and repairs the
+// matching files in the background. Call it before any camera can start a
+// new recording, so a file being written now is never mistaken for a leftover.
+func recoverInterruptedRecordings() {
+	root, err := recordingsRoot()
+	if err != nil {
+		return
+	}
+	var markers []string
+	_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && strings.HasSuffix(p, recordingMarkerExt) {
+			markers = append(markers, p)
+		}
+		return nil
+	})
+	if len(markers) == 0 {
+		return
+	}
+	go func() {
+		for _, marker := range markers {
+			media := strings.TrimSuffix(marker, recordingMarkerExt)
+			if _, err := os.Stat(media); err == nil {
+				log.Printf("recording: repairing interrupted file %s", media)
+				if err := remuxFile(media); err != nil {
+					log.Printf("recording: repair of %s failed: %v", media, err)
+				} else {
+					log.Printf("recording: repaired %s", media)
+				}
+			}
+			// one attempt only; a file we can't open now won't open later either
+			_ = os.Remove(marker)
+		}
+	}()
+}
+
+// remuxFile stream-copies path into a fresh container of the same type and
+// replaces the original on success.
+func remuxFile(path string) error {
+	in := astiav.AllocFormatContext()
+	if in == nil {
+		return errors.New("AllocFormatContext")
+	}
+	defer in.Free()
+	if err := in.OpenInput(path, nil, nil); err != nil {
+		return fmt.Errorf("OpenInput: %w", err)
+	}
+	defer in.CloseInput()
+	if err := in.FindStreamInfo(nil); err != nil {
+		return fmt.Errorf("FindStreamInfo: %w", err)
+	}
+
+	ext := filepath.Ext(path)
+	muxer := "mp4"
+	if strings.EqualFold(ext, ".mkv") {
+		muxer = "matroska"
+	}
+	tmp := strings.TrimSuffix(path, ext) + ".repair" + ext
+
+	out, err := astiav.AllocOutputFormatContext(nil, muxer, tmp)
+	if err != nil || out == nil {
+		return fmt.Errorf("AllocOutputFormatContext: %v", err)
+	}
+	defer out.Free()
+
+	streamIx := map[int]int{}
+	for _, is := range in.Streams() {
+		os := out.NewStream(nil)
+		if os == nil {
+			continue
+		}
+		if err := is.CodecParameters().Copy(os.CodecParameters()); err != nil {
+			continue
+		}
+		os.SetTimeBase(is.TimeBase())
+		streamIx[is.Index()] = os.Index()
+	}
+	if len(streamIx) == 0 {
+		return errors.New("no streams to copy")
+	}
+
+	pb, err := astiav.OpenIOContext(tmp, astiav.NewIOContextFlags(astiav.IOContextFlagWrite), nil, nil)
+	if err != nil {
+		return fmt.Errorf("OpenIOContext: %w", err)
+	}
+	out.SetPb(pb)
+	closeOut := func() {
+		_ = pb.Close()
+		pb.Free()
+	}
+	if err := out.WriteHeader(nil); err != nil {
+		closeOut()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("WriteHeader: %w", err)
+	}
+
+	pkt := astiav.AllocPacket()
+	defer pkt.Free()
+	for {
+		if err := in.ReadFrame(pkt); err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, astiav.ErrEof) {
+				// truncated tail is expected here; keep what we have
+				log.Printf("recording: repair of %s stopped early: %v", path, err)
+			}
+			break
+		}
+		if oi, ok := streamIx[pkt.StreamIndex()]; ok {
+			pkt.RescaleTs(in.Streams()[pkt.StreamIndex()].TimeBase(), out.Streams()[oi].TimeBase())
+			pkt.SetStreamIndex(oi)
+			pkt.SetPos(-1)
+			_ = out.WriteInterleavedFrame(pkt)
+		}
+		pkt.Unref()
+	}
+
+	if err := out.WriteTrailer(); err != nil {
+		closeOut()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("WriteTrailer: %w", err)
+	}
+	closeOut()
+	return os.Rename(tmp, path)
+}
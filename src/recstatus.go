@@ -0,0 +1,87 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+/*
+Recording status: while a camera records, its "Recording" pill and the tray
+tooltip show how long the current file runs, how big it is and how much
+space is left on the volume it is written to. The numbers are refreshed
+once a second from the tray status timer, not on every repaint. Below the
+low-disk threshold a tray warning is shown once per file and, optionally,
+the recording is stopped.
+*/
+
+// recFile is the file a camera's recorder is writing (set by the decode
+// goroutine, read on the main thread).
+type recFile struct {
+	path    string
+	started time.Time
+}
+
+// formatElapsed renders a running time as H:MM:SS.
+func formatElapsed(d time.Duration) string {
+	s := int(d / time.Second)
+	return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+}
+
+// updateRecordingStatus refreshes the recording line of every recording
+// camera and acts on low disk space. Main thread only.
+func updateRecordingStatus() {
+	for _, w := range wins {
+		if w == nil || w.closing {
+			continue
+		}
+		rf := w.recOpen.Load()
+		if rf == nil || !w.IsRecording() {
+			w.recLine = ""
+			continue
+		}
+		line := "Recording " + formatElapsed(time.Since(rf.started))
+		if st, err := os.Stat(rf.path); err == nil {
+			line += " · " + formatBytes(uint64(st.Size()))
+		}
+		free, err := diskFree(filepath.Dir(rf.path))
+		if err == nil {
+			line += " · " + formatBytes(free) + " free"
+		}
+		w.recLine = line
+
+		limit := uint64(globalConfig.LowDiskMB) << 20
+		if err != nil || limit == 0 || free >= limit || w.lowDiskFor == rf.path {
+			continue
+		}
+		w.lowDiskFor = rf.path // once per file
+		msg := fmt.Sprintf("Only %s left on the recording disk.", formatBytes(free))
+		if globalConfig.LowDiskStop {
+			msg += " Recording stopped."
+			w.ToggleRecording()
+		}
+		w.logf("recording: %s", msg)
+		tray.notifyLowDisk(w.cfg.Name, msg)
+	}
+}
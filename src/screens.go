@@ -0,0 +1,149 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"log"
+
+	"github.com/mappu/miqt/qt"
+)
+
+/*
+Monitors come and go (laptop undocked, projector unplugged, resolution
+changed). A camera window left where no monitor is anymore can't be reached,
+so after every screen change, and whenever a window is opened, windows that
+are off every monitor are brought back onto the primary one.
+*/
+
+// minVisible is how much of a window (px) must be on some monitor to still
+// count as reachable: enough to grab the title bar.
+const minVisibleW, minVisibleH = 48, 24
+
+// onSomeScreen reports whether r is reachable on a connected monitor.
+func onSomeScreen(r *qt.QRect) bool {
+	for _, scr := range qt.QGuiApplication_Screens() {
+		if in := scr.AvailableGeometry().Intersected(r); in.Width() >= minVisibleW && in.Height() >= minVisibleH {
+			return true
+		}
+	}
+	return false
+}
+
+// centeredOnPrimary places a width×height window centered in the primary
+// monitor's usable area, shrunk to fit, counting w's title bar and borders.
+// scr is nil when there is no primary monitor.
+func (w *CamWindow) centeredOnPrimary(width, height int) (x, y, cw, ch int, scr *qt.QScreen) {
+	scr = qt.QGuiApplication_PrimaryScreen()
+	if scr == nil {
+		return 0, 0, 0, 0, nil
+	}
+	ag := scr.AvailableGeometry()
+	g := w.win.FrameGeometry()
+	fw, fh := g.Width()-w.win.Width(), g.Height()-w.win.Height() // title bar and borders
+	cw = min(width, ag.Width()-fw)
+	ch = min(height, ag.Height()-fh)
+	x = ag.X() + (ag.Width()-cw-fw)/2
+	y = ag.Y() + (ag.Height()-ch-fh)/2
+	return x, y, cw, ch, scr
+}
+
+// rescueOffscreen moves the window onto the primary monitor, centered and
+// shrunk to fit if needed, when it is off every monitor. The new geometry is
+// saved like any other move. Reports whether it moved the window.
+func (w *CamWindow) rescueOffscreen() bool {
+	if w.win == nil || w.closing || w.isFullscreen || usingWayland() {
+		return false // fullscreen follows its monitor; Wayland doesn't tell where windows are
+	}
+	g := w.win.FrameGeometry()
+	if onSomeScreen(g) {
+		return false
+	}
+	x, y, width, height, scr := w.centeredOnPrimary(w.win.Width(), w.win.Height())
+	if scr == nil {
+		return false
+	}
+	w.logf("window was off-screen at %d,%d; moved to %s at %d,%d", g.X(), g.Y(), scr.Name(), x, y)
+	w.win.Resize(width, height)
+	w.win.Move(x, y)
+	return true
+}
+
+// ResetGeometry puts the window back to the default size, centered on the
+// primary monitor (leaving fullscreen first), and saves that as its geometry:
+// the way out for a window that ended up lost or tiny.
+func (w *CamWindow) ResetGeometry() {
+	if w == nil || w.win == nil {
+		return
+	}
+	if w.isFullscreen {
+		w.ToggleFullscreen()
+	}
+	x, y, width, height, scr := w.centeredOnPrimary(defaultWindowW, defaultWindowH)
+	if scr == nil {
+		return
+	}
+	w.logf("window geometry reset to %dx%d at %d,%d on %s", width, height, x, y, scr.Name())
+	w.win.ShowNormal()
+	w.win.Resize(width, height)
+	w.win.Move(x, y)
+	w.win.Raise()
+	w.saveTimer.Stop()
+	w.cfg.X, w.cfg.Y, w.cfg.Width, w.cfg.Height = x, y, width, height
+	if err := UpdateCameraGeometry(w.idKey, x, y, width, height); err != nil {
+		log.Printf("save geometry failed: %v", err)
+	}
+}
+
+// rescueOffscreenWindows runs rescueOffscreen over all open camera windows.
+func rescueOffscreenWindows() {
+	for _, w := range wins {
+		if w != nil {
+			w.rescueOffscreen()
+		}
+	}
+}
+
+// watchScreens re-checks the windows whenever a monitor is added, removed or
+// changes its geometry. Changes come in bursts (and the OS moves windows
+// itself meanwhile), so the check runs once things have settled.
+func watchScreens(app *qt.QGuiApplication) {
+	settle := qt.NewQTimer()
+	settle.SetSingleShot(true)
+	settle.SetInterval(1000)
+	settle.OnTimeout(func() {
+		log.Printf("screens changed: %d monitor(s)", len(qt.QGuiApplication_Screens()))
+		rescueOffscreenWindows()
+	})
+	changed := func() { settle.Start2() }
+	watch := func(scr *qt.QScreen) {
+		scr.OnGeometryChanged(func(*qt.QRect) { changed() })
+		scr.OnAvailableGeometryChanged(func(*qt.QRect) { changed() })
+	}
+	for _, scr := range qt.QGuiApplication_Screens() {
+		watch(scr)
+	}
+	app.OnScreenAdded(func(scr *qt.QScreen) {
+		watch(scr)
+		changed()
+	})
+	app.OnScreenRemoved(func(*qt.QScreen) { changed() })
+	app.OnPrimaryScreenChanged(func(*qt.QScreen) { changed() })
+}
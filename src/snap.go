@@ -0,0 +1,224 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import "github.com/mappu/miqt/qt"
+
+/*
+Snapping of frameless windows while dragging: edges snap to other windows'
+edges (side by side or aligned), centers to centers, and a window can
+continue an equal gap already used between two others. Screen edges snap
+too: the usable area of each monitor and its bounds, which on multi-monitor
+setups are the boundaries to the adjacent screens. The threshold is
+Settings → Snap distance. While a snap holds, thin guide lines show what
+it aligned to.
+*/
+
+const defaultSnapDistance = 12 // px
+
+// snapDistance is the snap threshold in px at 96 dpi; callers scale it for
+// the screen (scalePx), so it feels the same on HiDPI displays.
+func snapDistance() int {
+	if d := globalConfig.SnapDistance; d > 0 {
+		return d
+	}
+	return defaultSnapDistance
+}
+
+type snapRect struct{ x, y, w, h int }
+
+func (r snapRect) right() int  { return r.x + r.w }
+func (r snapRect) bottom() int { return r.y + r.h }
+
+func overlapX(a, b snapRect) bool { return a.x < b.right() && b.x < a.right() }
+func overlapY(a, b snapRect) bool { return a.y < b.bottom() && b.y < a.bottom() }
+
+// which edge of the dragged window a snap lines up (where its guide goes)
+const (
+	snapLead   = iota // left / top edge
+	snapCenter        // center line
+	snapTrail         // right / bottom edge
+)
+
+// guideLine is a vertical (x = pos) or horizontal (y = pos) line spanning
+// the dragged window and the one it snapped to.
+type guideLine struct {
+	vertical bool
+	pos      int
+	a, b     snapRect
+}
+
+// snapAxis keeps the best snap candidate along one axis.
+type snapAxis struct {
+	abs, d int
+	kind   int
+	other  snapRect
+	ok     bool
+}
+
+func (s *snapAxis) consider(d, kind int, other snapRect, limit int) {
+	if abs(d) > limit || (s.ok && abs(d) >= s.abs) {
+		return
+	}
+	*s = snapAxis{abs: abs(d), d: d, kind: kind, other: other, ok: true}
+}
+
+// snapXY snaps a window about to move to (x, y) against all other windows
+// outside the current glue group; returns the snapped position and the
+// guides to show.
+func (w *VideoWidget) snapXY(x, y, ww, wh int) (int, int, []guideLine) {
+	if !w.isFramelessActive() {
+		return x, y, nil
+	}
+	if !w.snapActive() {
+		return x, y, nil
+	}
+
+	// snap against all OTHER windows not in the current group
+	inGroup := map[*CamWindow]bool{}
+	for _, g := range w.group {
+		inGroup[g] = true
+	}
+	var others []snapRect
+	for _, other := range wins {
+		if other == nil || other.win == nil || other.isFullscreen || inGroup[other] || !other.win.IsVisible() {
+			continue
+		}
+		og := other.win.Geometry()
+		others = append(others, snapRect{og.X(), og.Y(), og.Width(), og.Height()})
+	}
+
+	// gaps between neighbours side by side (hgaps) and stacked (vgaps)
+	var hgaps, vgaps []int
+	for _, a := range others {
+		for _, b := range others {
+			if g := b.x - a.right(); g > 0 && overlapY(a, b) {
+				hgaps = append(hgaps, g)
+			}
+			if g := b.y - a.bottom(); g > 0 && overlapX(a, b) {
+				vgaps = append(vgaps, g)
+			}
+		}
+	}
+
+	snap := scalePx(w.QPaintDevice, snapDistance())
+	me := snapRect{x, y, ww, wh}
+	var sx, sy snapAxis
+	for _, o := range others {
+		// Horizontal: my left/right edge to the other's right/left edge,
+		// aligned edges, centers
+		sx.consider(o.right()-me.x, snapLead, o, snap)
+		sx.consider(o.x-me.right(), snapTrail, o, snap)
+		sx.consider(o.x-me.x, snapLead, o, snap)
+		sx.consider(o.right()-me.right(), snapTrail, o, snap)
+		sx.consider((o.x+o.w/2)-(me.x+me.w/2), snapCenter, o, snap)
+		// Vertical: same for top/bottom
+		sy.consider(o.bottom()-me.y, snapLead, o, snap)
+		sy.consider(o.y-me.bottom(), snapTrail, o, snap)
+		sy.consider(o.y-me.y, snapLead, o, snap)
+		sy.consider(o.bottom()-me.bottom(), snapTrail, o, snap)
+		sy.consider((o.y+o.h/2)-(me.y+me.h/2), snapCenter, o, snap)
+		// equal gaps: continue a row/column spaced like two other windows
+		if overlapY(o, me) {
+			for _, g := range hgaps {
+				sx.consider(o.right()+g-me.x, snapLead, o, snap)
+				sx.consider(o.x-g-me.right(), snapTrail, o, snap)
+			}
+		}
+		if overlapX(o, me) {
+			for _, g := range vgaps {
+				sy.consider(o.bottom()+g-me.y, snapLead, o, snap)
+				sy.consider(o.y-g-me.bottom(), snapTrail, o, snap)
+			}
+		}
+	}
+
+	// screen edges, only of monitors the window is level with
+	for _, r := range screenRects() {
+		if overlapY(r, me) {
+			sx.consider(r.x-me.x, snapLead, r, snap)
+			sx.consider(r.right()-me.right(), snapTrail, r, snap)
+		}
+		if overlapX(r, me) {
+			sy.consider(r.y-me.y, snapLead, r, snap)
+			sy.consider(r.bottom()-me.bottom(), snapTrail, r, snap)
+		}
+	}
+
+	me.x += sx.d
+	me.y += sy.d
+	var guides []guideLine
+	if sx.ok {
+		guides = append(guides, guideLine{vertical: true, pos: me.x + sx.kind*me.w/2, a: me, b: sx.other})
+	}
+	if sy.ok {
+		guides = append(guides, guideLine{pos: me.y + sy.kind*me.h/2, a: me, b: sy.other})
+	}
+	return me.x, me.y, guides
+}
+
+// screenRects lists every monitor's available geometry (taskbar/dock
+// excluded) and, where it differs, its full geometry.
+func screenRects() []snapRect {
+	var rs []snapRect
+	for _, scr := range qt.QGuiApplication_Screens() {
+		ag, sg := scr.AvailableGeometry(), scr.Geometry()
+		a := snapRect{ag.X(), ag.Y(), ag.Width(), ag.Height()}
+		rs = append(rs, a)
+		if s := (snapRect{sg.X(), sg.Y(), sg.Width(), sg.Height()}); s != a {
+			rs = append(rs, s)
+		}
+	}
+	return rs
+}
+
+// guide line windows, reused between drags
+var guideWins []*qt.QWidget
+
+// showSnapGuides shows the given lines (and hides the rest). Main thread only.
+func showSnapGuides(lines []guideLine) {
+	const thick = 2
+	for len(guideWins) < len(lines) {
+		g := qt.NewQWidget3(nil, qt.Tool|qt.FramelessWindowHint|qt.WindowStaysOnTopHint|
+			qt.WindowTransparentForInput|qt.WindowDoesNotAcceptFocus)
+		g.SetAttribute2(qt.WA_ShowWithoutActivating, true)
+		g.SetAttribute2(qt.WA_StyledBackground, true)
+		g.SetStyleSheet("background-color: #ff2d95;")
+		guideWins = append(guideWins, g)
+	}
+	for i, g := range guideWins {
+		if i >= len(lines) {
+			g.Hide()
+			continue
+		}
+		l := lines[i]
+		if l.vertical {
+			from, to := min(l.a.y, l.b.y), max(l.a.bottom(), l.b.bottom())
+			g.SetGeometry(l.pos-thick/2, from, thick, to-from)
+		} else {
+			from, to := min(l.a.x, l.b.x), max(l.a.right(), l.b.right())
+			g.SetGeometry(from, l.pos-thick/2, to-from, thick)
+		}
+		g.Show()
+	}
+}
+
+func hideSnapGuides() { showSnapGuides(nil) }
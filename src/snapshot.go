@@ -0,0 +1,248 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/mappu/miqt/qt"
+	"github.com/mappu/miqt/qt/mainthread"
+)
+
+/*
+Snapshots: the S key saves one JPEG next to the camera's recordings; a
+camera with snapshot_every > 0 also saves one every N seconds into
+AnotherRTSP-Recordings/Snapshots/<camera>/ (timelapse, dashboard
+thumbnails). Both encode the latest frame from frameBuf, independent of
+recording. Tray → Snapshot all cameras saves every live camera at once into
+Snapshots/All/<time>/, optionally with a contact sheet tiling them.
+*/
+
+const defaultSnapshotQuality = 90
+
+// snapshotQuality is the JPEG quality (1..100) from Settings → Advanced.
+func snapshotQuality() int {
+	q := globalConfig.SnapshotQuality
+	if q <= 0 {
+		return defaultSnapshotQuality
+	}
+	if q > 100 {
+		return 100
+	}
+	return q
+}
+
+// saveFrameJPEG encodes the latest frame of buf to path and returns the
+// frame's sequence number.
+func saveFrameJPEG(buf *frameBuf, path string) (uint64, error) {
+	seq, fw, fh, data := buf.get()
+	if seq == 0 || fw <= 0 || fh <= 0 || len(data) < fw*fh*4 {
+		return 0, fmt.Errorf("no frame yet")
+	}
+	if err := saveBGRAJPEG(fw, fh, data, path); err != nil {
+		return 0, err
+	}
+	return seq, nil
+}
+
+// saveBGRAJPEG writes a packed BGRA picture as a JPEG. QImage is reentrant,
+// so this is fine off the main thread.
+func saveBGRAJPEG(fw, fh int, data []byte, path string) error {
+	img := qt.NewQImage3(fw, fh, qt.QImage__Format_RGB32)
+	defer img.Delete()
+	copy(unsafe.Slice((*byte)(img.Bits()), fw*fh*4), data[:fw*fh*4])
+
+	if !img.Save3(path, "JPG", snapshotQuality()) {
+		return fmt.Errorf("cannot write %s", path)
+	}
+	return nil
+}
+
+func snapshotStamp(t time.Time) string { return t.Format("2006-01-02_15-04-05.000") }
+
+// Snapshot saves the current frame as a JPEG next to the camera's
+// recordings and returns its path.
+func (w *CamWindow) Snapshot() (string, error) {
+	dir, err := recordingDir(w)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "snapshot_"+snapshotStamp(time.Now())+".jpg")
+	if _, err := saveFrameJPEG(&w.buf, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// snapshotsDir is AnotherRTSP-Recordings/Snapshots/<camera>, created if needed.
+func snapshotsDir(w *CamWindow) (string, error) {
+	root, err := recordingsRoot()
+	if err != nil {
+		return "", err
+	}
+	camName := w.cfg.Name
+	if camName == "" {
+		camName = w.cfg.URL
+	}
+	dir := filepath.Join(root, "Snapshots", sanitizeFSComponent(camName))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// ApplySnapshotSettings (re)starts or stops the periodic snapshot timer
+// from cfg.SnapshotEvery. Call on the Qt main thread.
+func (w *CamWindow) ApplySnapshotSettings() {
+	if w == nil || w.win == nil {
+		return
+	}
+	every := w.cfg.SnapshotEvery
+	if every <= 0 {
+		if w.snapTimer != nil {
+			w.snapTimer.Stop()
+		}
+		return
+	}
+	if w.snapTimer == nil {
+		w.snapTimer = qt.NewQTimer2(w.win.QObject)
+		w.snapTimer.OnTimeout(w.periodicSnapshot)
+	}
+	w.snapTimer.Start(every * 1000)
+}
+
+// periodicSnapshot saves one timed snapshot; a frozen stream (no new frame
+// since the last one) or a paused camera is skipped.
+func (w *CamWindow) periodicSnapshot() {
+	if w.closing || w.IsPaused() {
+		return
+	}
+	if seq, _, _, _ := w.buf.get(); seq == 0 || seq == w.lastSnapSeq {
+		return
+	}
+	dir, err := snapshotsDir(w)
+	if err != nil {
+		w.logf("periodic snapshot: %v", err)
+		return
+	}
+	path := filepath.Join(dir, snapshotStamp(time.Now())+".jpg")
+	seq, err := saveFrameJPEG(&w.buf, path)
+	if err != nil {
+		w.logf("periodic snapshot: %v", err)
+		return
+	}
+	w.lastSnapSeq = seq
+}
+
+// contact sheet tile size; a camera's frame is fitted inside, letterboxed
+const sheetTileW, sheetTileH = 640, 360
+
+// snapshotAll saves the current frame of every live camera into
+// Snapshots/All/<time>/<camera>.jpg, all taken at the same moment, plus
+// contact-sheet.jpg when Settings → Advanced asks for it. The frames are
+// copied here (main thread); encoding and writing run in the background and
+// the tray reports the result.
+func snapshotAll() {
+	type shot struct {
+		name string
+		buf  *frameBuf
+	}
+	var shots []shot
+	for _, w := range wins {
+		if w == nil || w.closing || w.IsPaused() || w.connState() != connConnected {
+			continue
+		}
+		if buf := w.buf.clone(); buf.seq > 0 {
+			shots = append(shots, shot{safeCamTitle(w.cfg), buf})
+		}
+	}
+	if len(shots) == 0 {
+		notifySnapshots("No camera has a live picture to save.", qt.QSystemTrayIcon__Warning)
+		return
+	}
+	root, err := recordingsRoot()
+	if err != nil {
+		log.Printf("snapshot all: %v", err)
+		return
+	}
+	dir := filepath.Join(root, "Snapshots", "All", snapshotStamp(time.Now()))
+	sheet := globalConfig.SnapshotContactSheet
+
+	go func() {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			log.Printf("snapshot all: %v", err)
+			notifySnapshots(err.Error(), qt.QSystemTrayIcon__Warning)
+			return
+		}
+		var wg sync.WaitGroup
+		var saved atomic.Int32
+		used := map[string]int{} // cameras with the same name get _2, _3, ...
+		for _, s := range shots {
+			base := sanitizeFSComponent(s.name)
+			if used[base]++; used[base] > 1 {
+				base = fmt.Sprintf("%s_%d", base, used[base])
+			}
+			path := filepath.Join(dir, base+".jpg")
+			wg.Add(1)
+			go func(buf *frameBuf) {
+				defer wg.Done()
+				if _, err := saveFrameJPEG(buf, path); err != nil {
+					log.Printf("snapshot all: %v", err)
+					return
+				}
+				saved.Add(1)
+			}(s.buf)
+		}
+		if sheet {
+			bufs := make([]*frameBuf, len(shots))
+			for i, s := range shots {
+				bufs[i] = s.buf
+			}
+			cols := int(math.Ceil(math.Sqrt(float64(len(bufs)))))
+			rows := (len(bufs) + cols - 1) / cols
+			cw, ch := cols*sheetTileW, rows*sheetTileH
+			canvas := make([]byte, cw*ch*4)
+			composeGrid(canvas, cw, ch, bufs)
+			if err := saveBGRAJPEG(cw, ch, canvas, filepath.Join(dir, "contact-sheet.jpg")); err != nil {
+				log.Printf("snapshot all: contact sheet: %v", err)
+			}
+		}
+		wg.Wait()
+		log.Printf("snapshot all: %d of %d camera(s) saved -> %s", saved.Load(), len(shots), dir)
+		notifySnapshots(fmt.Sprintf("%d snapshot(s) saved to %s", saved.Load(), dir), qt.QSystemTrayIcon__Information)
+	}()
+}
+
+func notifySnapshots(msg string, icon qt.QSystemTrayIcon__MessageIcon) {
+	mainthread.Start(func() {
+		if tray != nil && tray.tray != nil && tray.tray.IsVisible() {
+			tray.tray.ShowMessage4("Snapshot all cameras", msg, icon)
+		}
+	})
+}
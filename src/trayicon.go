@@ -0,0 +1,192 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"fmt"
+
+	"github.com/mappu/miqt/qt"
+)
+
+/*
+Tray icon status: the app icon gets a small colored badge when something
+needs attention, so the state is visible without opening any window.
+The variants ship in the qrc resources next to icon.png (icon-warning.png,
+icon-recording.png); regenerate them when the app icon changes.
+*/
+
+type trayStatus int
+
+const (
+	trayOK        trayStatus = iota // all enabled cameras streaming (plain icon)
+	trayWarning                     // some camera is (re)connecting or unreachable
+	trayRecording                   // some camera (or the grid) is recording
+)
+
+// collectTrayStatus aggregates the open cameras; recording wins over
+// warnings. Main thread only. Also returns a tooltip summary.
+func collectTrayStatus() (trayStatus, string) {
+	var open, down, rec int
+	for _, w := range wins {
+		if w == nil || w.closing || w.IsPaused() {
+			continue
+		}
+		open++
+		if w.IsRecording() {
+			rec++
+		}
+		if w.isDown() {
+			down++
+		}
+	}
+	tip := fmt.Sprintf("%s: %d camera(s)", app, open)
+	if down > 0 {
+		tip += fmt.Sprintf(", %d disconnected", down)
+	}
+	if rec > 0 {
+		tip += fmt.Sprintf(", %d recording", rec)
+	}
+	if isGridRecording() {
+		tip += ", recording grid"
+	}
+	for _, w := range wins {
+		if w != nil && !w.closing && w.recLine != "" {
+			tip += "\n" + w.cfg.Name + ": " + w.recLine
+		}
+	}
+	switch {
+	case rec > 0 || isGridRecording():
+		return trayRecording, tip
+	case down > 0:
+		return trayWarning, tip
+	}
+	return trayOK, tip
+}
+
+// startStatusIcon loads the icon variants and keeps the tray icon and
+// tooltip in step with the cameras (checked every second, set on change).
+func (t *TrayController) startStatusIcon() {
+	icons := map[trayStatus]*qt.QIcon{
+		trayOK:        globalIcon,
+		trayWarning:   qt.NewQIcon4(":/icon-warning.png"),
+		trayRecording: qt.NewQIcon4(":/icon-recording.png"),
+	}
+	cur, curTip := trayOK, ""
+	update := func() {
+		updateRecordingStatus()
+		st, tip := collectTrayStatus()
+		if st != cur {
+			cur = st
+			t.tray.SetIcon(icons[st])
+		}
+		if tip != curTip {
+			curTip = tip
+			t.tray.SetToolTip(tip)
+		}
+	}
+	tm := qt.NewQTimer2(t.tray.QObject)
+	tm.SetInterval(1000)
+	tm.OnTimeout(update)
+	tm.Start2()
+	update()
+}
+
+// Camera previews in the tray menu: each camera action shows a small
+// thumbnail of its latest frame, a gray placeholder while it is off or has
+// no picture yet. Refreshed every few seconds, only for new frames.
+
+const (
+	thumbW, thumbH  = 64, 36 // rendered size; the menu scales it to its icon size
+	thumbIntervalMs = 5000
+)
+
+// placeholderThumb is shown for disabled cameras and ones without a frame.
+func placeholderThumb() *qt.QIcon {
+	pm := qt.NewQPixmap2(thumbW, thumbH)
+	pm.FillWithFillColor(qt.NewQColor11(60, 60, 60, 255))
+	p := qt.NewQPainter2(pm.QPaintDevice)
+	p.SetPenWithPen(qt.NewQPen3(qt.NewQColor11(120, 120, 120, 255)))
+	p.DrawRect2(0, 0, thumbW-1, thumbH-1)
+	p.DrawLine2(0, 0, thumbW-1, thumbH-1)
+	p.DrawLine2(0, thumbH-1, thumbW-1, 0)
+	p.End()
+	return qt.NewQIcon2(pm)
+}
+
+// frameThumb scales the latest frame of buf down to an icon. Returns nil
+// when there is no frame yet. Main thread only.
+func frameThumb(buf *frameBuf) (*qt.QIcon, uint64) {
+	seq, fw, fh, data := buf.get()
+	if seq == 0 || fw <= 0 || fh <= 0 || len(data) < fw*fh*4 {
+		return nil, 0
+	}
+	img := qt.NewQImage6(&data[0], fw, fh, fw*4, qt.QImage__Format_RGB32)
+	defer img.Delete()
+	small := img.Scaled3(thumbW, thumbH, qt.KeepAspectRatio, qt.SmoothTransformation)
+	return qt.NewQIcon2(qt.QPixmap_FromImage(small)), seq
+}
+
+// refreshThumbnails updates the camera actions' icons. thumbShown keeps the
+// frame seq each action shows (0 = placeholder), so unchanged ones are skipped.
+func (t *TrayController) refreshThumbnails() {
+	if t.thumbPlaceholder == nil {
+		t.thumbPlaceholder = placeholderThumb()
+	}
+	shown := make(map[*qt.QAction]uint64, len(t.actions)) // drops actions of older menus
+	for i, act := range t.actions {
+		if act == nil {
+			continue
+		}
+		last, seen := t.thumbShown[act]
+		var w *CamWindow
+		if i < len(*t.wins) && i < len(t.cfg.Cameras) && !t.cfg.Cameras[i].Disabled {
+			w = (*t.wins)[i]
+		}
+		var icon *qt.QIcon
+		var seq uint64
+		if w != nil && !w.closing {
+			if cur, _, _, _ := w.buf.get(); seen && cur == last {
+				shown[act] = last
+				continue
+			}
+			icon, seq = frameThumb(&w.buf)
+		}
+		if icon == nil {
+			if !seen || last != 0 {
+				act.SetIcon(t.thumbPlaceholder)
+			}
+			shown[act] = 0
+			continue
+		}
+		act.SetIcon(icon) // copied by Qt
+		icon.Delete()
+		shown[act] = seq
+	}
+	t.thumbShown = shown
+}
+
+// startThumbnails refreshes the camera previews periodically.
+func (t *TrayController) startThumbnails() {
+	tm := qt.NewQTimer2(t.tray.QObject)
+	tm.SetInterval(thumbIntervalMs)
+	tm.OnTimeout(t.refreshThumbnails)
+	tm.Start2()
+}
@@ -28,7 +28,11 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -52,6 +56,7 @@ type frameBuf struct {
 	w   int
 	h   int
 	b   []byte
+	at  time.Time // when the frame was stored
 }
 
 func (f *frameBuf) put(w, h int, src []byte) uint64 {
@@ -68,9 +73,25 @@ func (f *frameBuf) put(w, h int, src []byte) uint64 {
 
 	f.w = w
 	f.h = h
+	f.at = time.Now()
 	return atomic.AddUint64(&f.seq, 1)
 }
 
+// age is how long ago the current frame was stored.
+func (f *frameBuf) age() time.Duration {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return time.Since(f.at)
+}
+
+// clone is a private copy of the current frame, safe to use while the
+// decoder keeps writing into f.
+func (f *frameBuf) clone() *frameBuf {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return &frameBuf{seq: atomic.LoadUint64(&f.seq), w: f.w, h: f.h, b: slices.Clone(f.b), at: f.at}
+}
+
 // get returns (seq, w, h, data). If seq==0 there is no frame yet.
 func (f *frameBuf) get() (uint64, int, int, []byte) {
 	f.mu.RLock()
@@ -93,6 +114,9 @@ type bgraScaler struct {
 	srcW, srcH int
 	srcPix     astiav.PixelFormat
 	dstW, dstH int
+	flags      astiav.SoftwareScaleContextFlags // from -sflags=..., 0 = default
+	maxW, maxH int                              // preview size cap (maxDecodeSize), 0 = none
+	logf       func(format string, args ...any) // reports capping; nil = silent
 }
 
 func (s *bgraScaler) close() {
@@ -117,9 +141,15 @@ func (s *bgraScaler) ensure(src *astiav.Frame) error {
 	// Free existing
 	s.close()
 
-	// Destination: same size, BGRA
-	dw, dh := sw, sh
-	flags := astiav.NewSoftwareScaleContextFlags() // default (bilinear)
+	// Destination: same size, BGRA (downscaled to the preview cap if set)
+	dw, dh := capSize(sw, sh, s.maxW, s.maxH)
+	if (dw != sw || dh != sh) && s.logf != nil {
+		s.logf("preview capped: %dx%d decoded, shown at %dx%d", sw, sh, dw, dh)
+	}
+	flags := s.flags
+	if flags == 0 {
+		flags = astiav.NewSoftwareScaleContextFlags() // default (bilinear)
+	}
 	ssc, err := astiav.CreateSoftwareScaleContext(
 		sw, sh, sp,
 		dw, dh, astiav.PixelFormatBgra,
@@ -146,10 +176,82 @@ func (s *bgraScaler) ensure(src *astiav.Frame) error {
 	s.srcW, s.srcH, s.srcPix = sw, sh, sp
 	s.dstW, s.dstH = dw, dh
 
-	log.Printf("scaler ready: %dx%d %s -> BGRA", sw, sh, sp.String())
+	log.Printf("scaler ready: %dx%d %s -> %dx%d BGRA", sw, sh, sp.String(), dw, dh)
 	return nil
 }
 
+// maxDecodeSize is the preview size cap from the settings (0, 0 = none).
+func maxDecodeSize() (w, h int) {
+	return parseSizeCap(globalConfig.MaxDecodeRes)
+}
+
+// parseSizeCap reads a "WxH" size cap (0, 0 = none or not a size).
+func parseSizeCap(s string) (w, h int) {
+	a, b, ok := strings.Cut(strings.ToLower(s), "x")
+	if !ok {
+		return 0, 0
+	}
+	w, err1 := strconv.Atoi(strings.TrimSpace(a))
+	h, err2 := strconv.Atoi(strings.TrimSpace(b))
+	if err1 != nil || err2 != nil || w < 160 || h < 120 {
+		return 0, 0
+	}
+	return w, h
+}
+
+// Decoder error handling choices (camera dialog and Settings → Advanced).
+// errDetectLevels are err_detect values from lenient to strict; "ignore"
+// turns the checks off. concealModes are the ec (error concealment) modes
+// besides FFmpeg's default guess_mvs+deblock.
+var (
+	errDetectLevels = []string{"ignore", "crccheck", "careful", "compliant", "aggressive"}
+	concealModes    = []string{"favor_inter", "guess_mvs", "deblock", "off"}
+)
+
+// errDetect is the decoder's err_detect: the camera's own, else the global
+// default, else "careful".
+func (w *CamWindow) errDetect() string {
+	switch {
+	case w.cfg.ErrDetect != "":
+		return w.cfg.ErrDetect
+	case globalConfig.DefaultErrDetect != "":
+		return globalConfig.DefaultErrDetect
+	}
+	return "careful"
+}
+
+// errConceal is the decoder's ec option value for the camera (or global)
+// concealment mode; "" leaves FFmpeg's default.
+func (w *CamWindow) errConceal() string {
+	mode := w.cfg.ErrConceal
+	if mode == "" {
+		mode = globalConfig.DefaultErrConceal
+	}
+	switch mode {
+	case "off":
+		return "0"
+	case "favor_inter":
+		return "guess_mvs+deblock+favor_inter" // on top of the default
+	}
+	return mode
+}
+
+// capSize fits sw x sh into a maxW x maxH box (either orientation, so a
+// portrait stream isn't squeezed by a landscape cap), keeping the aspect
+// ratio and even dimensions. Smaller sizes pass through.
+func capSize(sw, sh, maxW, maxH int) (int, int) {
+	if maxW <= 0 || maxH <= 0 {
+		return sw, sh
+	}
+	long, short := max(sw, sh), min(sw, sh)
+	capLong, capShort := max(maxW, maxH), min(maxW, maxH)
+	if long <= capLong && short <= capShort {
+		return sw, sh
+	}
+	s := math.Min(float64(capLong)/float64(long), float64(capShort)/float64(short))
+	return max(2, int(float64(sw)*s)&^1), max(2, int(float64(sh)*s)&^1)
+}
+
 // toBGRA converts a decoded frame into a tightly packed BGRA slice.
 func (s *bgraScaler) toBGRA(src *astiav.Frame) (int, int, []byte, error) {
 	if err := s.ensure(src); err != nil {
@@ -179,35 +281,222 @@ func (s *bgraScaler) toBGRA(src *astiav.Frame) (int, int, []byte, error) {
 // =======================================
 //
 
-func (w *CamWindow) decodeLoop() {
-	defer close(w.done)
+// unsupportedCodecError: the stream's video codec has no decoder in the
+// linked FFmpeg (e.g. H.265 in a build without it).
+type unsupportedCodecError struct{ codec string }
+
+func (e *unsupportedCodecError) Error() string {
+	return fmt.Sprintf("FindDecoder(video): no decoder for %s", e.codec)
+}
+
+// activeDecoders counts running decodeLoop goroutines (shown in About).
+var activeDecoders atomic.Int32
+
+// decodeLoop runs one decoder generation: stop/done are that generation's
+// channels (see startLoop), so a restart swapping in new ones never affects
+// a loop that is still winding down. It connects after delay.
+func (w *CamWindow) decodeLoop(stop <-chan struct{}, done chan struct{}, delay time.Duration) {
+	defer close(done)
+	activeDecoders.Add(1)
+	defer activeDecoders.Add(-1)
 	runtime.LockOSThread()
 	defer runtime.UnlockOSThread()
 
+	if delay > 0 {
+		w.logf("waiting %v before connecting (staggered start)", delay)
+		select {
+		case <-stop:
+			return
+		case <-time.After(delay):
+		}
+	}
+
+	fails := 0 // attempts in a row that never got a frame through
 	for {
 		// allow stop without blocking
 		select {
-		case <-w.stop:
+		case <-stop:
 			return
 		default:
 		}
 
-		if err := w.openAndDecode(); err != nil {
-			log.Printf("[%s] decode error: %v", w.cfg.Name, err)
+		var uc *unsupportedCodecError
+		if err := w.openAndDecode(stop); errors.As(err, &uc) {
+			w.setUnsupported(uc.codec)
+			fails = 0 // a stream reset won't bring a decoder either
+		} else if err != nil {
+			w.logf("decode error: %v", err)
+			msg := err.Error()
+			w.lastErr.Store(&msg)
+			switch w.connState() {
+			case connConnected:
+				fails = 0 // the session worked for a while; this is a fresh drop
+			case connUnreachable:
+				// host down (reach check): a stream reset can't help, don't count it
+			default:
+				fails++
+			}
 			w.setReconnectSoon()
 		}
 
-		// small backoff between reconnects
+		// endless failures with the backoff pinned: drop everything this
+		// camera learned and start over, like toggling it off and on
+		if n := restartAfter(); n > 0 && fails >= n {
+			select {
+			case <-stop:
+				return // paused, hidden or closed meanwhile: nothing to reset
+			default:
+			}
+			w.logf("watchdog: %d failed attempts in a row, resetting the stream", fails)
+			go w.restartDecoderWith(watchdogRestart, true) // waits for this loop to exit
+			return
+		}
+
+		// wait until the scheduled retry (at least a small pause between reconnects)
+		wait := w.retryIn()
+		if wait < time.Second {
+			wait = time.Second
+		}
 		select {
-		case <-w.stop:
+		case <-stop:
 			return
-		case <-time.After(1 * time.Second):
+		case <-time.After(wait):
+		}
+	}
+}
+
+// describeStreams summarizes what FindStreamInfo found, for the log.
+func describeStreams(fc *astiav.FormatContext) string {
+	var parts []string
+	for i, s := range fc.Streams() {
+		par := s.CodecParameters()
+		desc := fmt.Sprintf("#%d %s %s", i, par.MediaType(), par.CodecID().Name())
+		switch par.MediaType() {
+		case astiav.MediaTypeVideo:
+			desc += fmt.Sprintf(" %dx%d", par.Width(), par.Height())
+			if r := s.AvgFrameRate(); r.Num() > 0 && r.Den() > 0 {
+				desc += fmt.Sprintf(" @ %.2f fps", r.Float64())
+			}
+		case astiav.MediaTypeAudio:
+			desc += fmt.Sprintf(" %d Hz %dch", par.SampleRate(), par.ChannelLayout().Channels())
 		}
+		parts = append(parts, desc)
+	}
+	if len(parts) == 0 {
+		return "no streams"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// fpsLimiter drops decoded frames arriving faster than a max rate, judged by
+// PTS (wall clock when the frame has none).
+type fpsLimiter struct {
+	interval float64 // seconds between shown frames; 0 = no cap
+	tb       float64 // seconds per PTS tick
+	last     float64
+	inited   bool
+}
+
+func newFPSLimiter(maxFPS, tbNum, tbDen int) *fpsLimiter {
+	l := &fpsLimiter{}
+	if maxFPS > 0 {
+		l.interval = 1 / float64(maxFPS)
+	}
+	if tbNum > 0 && tbDen > 0 {
+		l.tb = float64(tbNum) / float64(tbDen)
+	}
+	return l
+}
+
+func (l *fpsLimiter) allow(pts int64) bool {
+	if l.interval <= 0 {
+		return true
+	}
+	var t float64
+	if pts == astiav.NoPtsValue || l.tb <= 0 {
+		t = float64(time.Now().UnixNano()) / 1e9
+	} else {
+		t = float64(pts) * l.tb
+	}
+	// 10% slack for jitter; a backwards jump (stream reset) always passes
+	if l.inited && t >= l.last && t-l.last < l.interval*0.9 {
+		return false
 	}
+	l.last, l.inited = t, true
+	return true
 }
 
-func (w *CamWindow) openAndDecode() error {
-	const stallCutoff = 10 * time.Second
+// playableAudio reports whether decoded audio can go to the player as is:
+// packed S16, mono, 8 kHz (typical G.711).
+func playableAudio(f astiav.SampleFormat, channels, rate int) bool {
+	return f == astiav.SampleFormatS16 && channels == 1 && rate == 8000
+}
+
+// realtimePacer holds packets back until their timestamp comes due, so a
+// file or HLS playlist plays at normal speed instead of as fast as it reads.
+type realtimePacer struct {
+	start  time.Time
+	base   float64
+	inited bool
+}
+
+func (p *realtimePacer) wait(pkt *astiav.Packet, tb astiav.Rational, stop <-chan struct{}) {
+	pts := pkt.Pts()
+	if pts == astiav.NoPtsValue {
+		pts = pkt.Dts()
+	}
+	if pts == astiav.NoPtsValue || tb.Den() <= 0 {
+		return
+	}
+	t := float64(pts) * float64(tb.Num()) / float64(tb.Den())
+	ahead := time.Duration((t-p.base)*float64(time.Second)) - time.Since(p.start)
+	// first packet, or a timestamp jump (loop, discontinuity): resync
+	if !p.inited || ahead > 5*time.Second || ahead < -5*time.Second {
+		p.start, p.base, p.inited = time.Now(), t, true
+		return
+	}
+	if ahead > 0 {
+		select {
+		case <-stop:
+		case <-time.After(ahead):
+		}
+	}
+}
+
+func (w *CamWindow) openAndDecode(stop <-chan struct{}) error {
+	stallCutoff := w.stallCutoff() // 0 = watchdog disabled
+
+	// optional fast pre-check: skip the heavy OpenInput when the host is down
+	if w.cfg.ReachCheck {
+		reach := 1500 * time.Millisecond
+		if w.cfg.ConnectTimeout > 0 {
+			reach = w.connectTimeout() // a slow link needs its own timeout here too
+		}
+		if err := checkReachable(w.streamURL(), reach); err != nil {
+			w.setConnState(connUnreachable)
+			return err
+		}
+		// host answers again; keep showing "reconnecting" until frames flow
+		w.state.CompareAndSwap(int32(connUnreachable), int32(connReconnecting))
+	}
+
+	// rtsps://: check the certificate ourselves, FFmpeg won't
+	if src := w.streamURL(); isRTSPS(src) {
+		if err := checkTLS(src, w.connectTimeout(), w.cfg.TLSInsecure); err != nil {
+			return err
+		}
+	}
+
+	// Reload stream: probe the stream in full, whatever the camera's settings
+	// trim for a fast start, and forget what the last session found out.
+	// Retries keep doing so until a stream has been probed
+	reprobe := w.reprobe.Load()
+	if reprobe {
+		w.streamInfo.Store(nil)
+		w.pktPtsInited = false
+		w.tbNum, w.tbDen = 0, 0
+		w.fpsNom, w.fpsDen = 0, 0
+	}
 
 	// ---------- input ----------
 	fc := astiav.AllocFormatContext()
@@ -216,48 +505,131 @@ func (w *CamWindow) openAndDecode() error {
 	}
 	defer fc.Free()
 
+	params := parseFFmpegParams(w.cfg.FFmpegParams)
+	issues := append([]string(nil), params.Problems...)
+	for _, p := range params.Problems {
+		w.logf("ffmpeg params: %s (ignored)", p)
+	}
+	defer func() { w.paramIssues.Store(&issues) }()
+
 	rd := astiav.NewDictionary()
 	defer rd.Free()
 
-	if w.cfg.RTSPTCP {
+	src := w.streamURL()
+	kind := streamKindOf(src)
+	// files and HLS playlists carry real timestamps and must be paced by
+	// them; everything else is live and gets stamped on arrival
+	paced := kind == streamFile || (kind == streamHTTP && isHLS(src))
+	timeoutUS := strconv.FormatInt(w.connectTimeout().Microseconds(), 10)
+
+	// RTSP over TLS only carries media interleaved on its TCP connection
+	if kind == streamRTSP && (w.cfg.RTSPTCP || isRTSPS(src)) {
 		_ = rd.Set("rtsp_transport", "tcp", 0)
 		_ = rd.Set("rtsp_flags", "prefer_tcp", 0)
 	}
-	_ = rd.Set("buffer_size", "1048576", 0) // 1 MiB
+	// caching_ms (VLC's "network caching"): let the demuxer hold and reorder
+	// that much of the stream, which rides out jitter at the cost of latency
+	caching := time.Duration(max(w.cfg.Caching, 0)) * time.Millisecond
+	if kind == streamRTSP || kind == streamOther {
+		if caching > 0 {
+			// socket buffer for the whole window at up to ~20 Mbit/s
+			_ = rd.Set("buffer_size", strconv.Itoa(max(1<<20, int(caching.Milliseconds())*2500)), 0)
+			_ = rd.Set("max_delay", strconv.FormatInt(caching.Microseconds(), 10), 0)
+		} else {
+			_ = rd.Set("buffer_size", "1048576", 0) // 1 MiB
+			_ = rd.Set("max_delay", "500000", 0)    // 0.5s
+		}
+	}
+	if kind == streamHTTP {
+		_ = rd.Set("reconnect", "1", 0)
+		_ = rd.Set("rw_timeout", timeoutUS, 0)
+	}
 	_ = rd.Set("flags", "+low_delay", 0)
-	_ = rd.Set("fflags", "+nobuffer+discardcorrupt+genpts", 0) // reduce latency
-	_ = rd.Set("max_delay", "500000", 0)                       // 0.5s
-	_ = rd.Set("use_wallclock_as_timestamps", "1", 0)
-	if w.cfg.Probesize > 0 {
+	if paced {
+		_ = rd.Set("fflags", "+discardcorrupt+genpts", 0)
+	} else {
+		if caching > 0 || reprobe {
+			_ = rd.Set("fflags", "+discardcorrupt+genpts", 0) // buffering wanted
+		} else {
+			_ = rd.Set("fflags", "+nobuffer+discardcorrupt+genpts", 0) // reduce latency
+		}
+		_ = rd.Set("use_wallclock_as_timestamps", "1", 0)
+	}
+	if w.cfg.Probesize > 0 && !(reprobe && w.cfg.Probesize < 5000000) {
 		_ = rd.Set("probesize", fmt.Sprintf("%d", w.cfg.Probesize), 0)
 	} else {
 		_ = rd.Set("probesize", "5000000", 0) // default 5MB
 	}
-	_ = rd.Set("reorder_queue_size", "0", 0)
-	_ = rd.Set("stimeout", "5000000", 0) // 5s (µs)
+	if reprobe && w.cfg.AnalyzeUS < 5000000 {
+		_ = rd.Set("analyzeduration", "5000000", 0) // FFmpeg's default 5s
+	} else if w.cfg.AnalyzeUS > 0 {
+		_ = rd.Set("analyzeduration", fmt.Sprintf("%d", w.cfg.AnalyzeUS), 0)
+	}
+	if kind == streamRTSP {
+		if caching > 0 {
+			_ = rd.Set("reorder_queue_size", "500", 0) // UDP packets put back in order within max_delay
+		} else {
+			_ = rd.Set("reorder_queue_size", "0", 0)
+		}
+		_ = rd.Set("timeout", timeoutUS, 0) // socket I/O timeout (µs); called stimeout before FFmpeg 5
+	}
 
-	applyFmtParams(w.cfg.FFmpegParams, rd)
+	applyFmtParams(params, rd)
 
-	log.Printf("[%s] ffmpeg options: %s", w.cfg.Name, JoinDict(rd))
+	w.logf("ffmpeg options: %s", JoinDict(rd))
 
-	if err := fc.OpenInput(w.cfg.URL, nil, rd); err != nil {
+	input := streamURLWithAuth(src, w.cfg.Username, w.cfg.Password)
+	if kind == streamFile {
+		input = localPath(src)
+	}
+	openStart := time.Now()
+	if err := fc.OpenInput(input, nil, rd); err != nil {
+		if w.cfg.SubURL != "" && w.useMain.Swap(false) {
+			// main stream unavailable: keep showing something
+			w.logf("main stream failed (%v), falling back to substream", err)
+		}
 		return fmt.Errorf("OpenInput: %w", err)
 	}
+	// whatever is left in rd was not recognized by the demuxer/protocol
+	if left := JoinDict(rd); left != "" {
+		w.logf("ffmpeg options not used: %s", left)
+	}
+	for _, k := range unusedParams(rd, params.Format, "f") {
+		issues = append(issues, k+": not recognized by the input")
+	}
+	// connected; probing the codecs can take seconds on some streams
+	opened := time.Since(openStart)
+	w.setConnState(connDetecting)
+	probeStart := time.Now()
 	if err := fc.FindStreamInfo(nil); err != nil {
 		return fmt.Errorf("FindStreamInfo: %w", err)
 	}
+	w.logf("opened in %v, stream detected in %v: %s",
+		opened.Round(time.Millisecond), time.Since(probeStart).Round(time.Millisecond), describeStreams(fc))
 
-	// ---------- auto select video stream ----------
-	vIdx := -1
+	// ---------- video stream: the configured one, else the first ----------
+	vIdx, nVideo := -1, 0
 	for i, s := range fc.Streams() {
 		if s.CodecParameters().MediaType() == astiav.MediaTypeVideo {
-			vIdx = i
-			break
+			if vIdx < 0 {
+				vIdx = i
+			}
+			nVideo++
 		}
 	}
 	if vIdx < 0 {
 		return errors.New("no video stream")
 	}
+	if i := w.cfg.VideoStreamIndex; i >= 0 && i != vIdx {
+		if i < len(fc.Streams()) && fc.Streams()[i].CodecParameters().MediaType() == astiav.MediaTypeVideo {
+			vIdx = i
+		} else if i > 0 {
+			w.logf("stream #%d is not a video stream, using #%d", i, vIdx)
+		}
+	}
+	if nVideo > 1 {
+		w.logf("%d video streams, decoding #%d", nVideo, vIdx)
+	}
 
 	// --- find audio stream (optional) ---
 	aIdx := -1
@@ -274,7 +646,7 @@ func (w *CamWindow) openAndDecode() error {
 	vpar := vst.CodecParameters()
 	vdec := astiav.FindDecoder(vpar.CodecID())
 	if vdec == nil {
-		return errors.New("FindDecoder(video) nil")
+		return &unsupportedCodecError{codec: vpar.CodecID().Name()}
 	}
 	vctx := astiav.AllocCodecContext(vdec)
 	if vctx == nil {
@@ -291,9 +663,11 @@ func (w *CamWindow) openAndDecode() error {
 		vctx.SetThreadCount(w.cfg.Threads)
 	} else if n := vdec.Name(); n == "hevc" || n == "h265" {
 		vctx.SetThreadCount(1)
+	} else if t := decodeThreads(); t > 0 {
+		vctx.SetThreadCount(t)
 	}
 
-	// force software decode; avoid hardware frames
+	// software decode unless a hardware device is configured and can be opened
 	vopts := astiav.NewDictionary()
 	defer vopts.Free()
 
@@ -301,19 +675,49 @@ func (w *CamWindow) openAndDecode() error {
 	if hwaccel == "" {
 		hwaccel = "none"
 	}
+	if hwaccel != "none" {
+		hdc, err := openHwDevice(hwaccel)
+		if err != nil {
+			w.logf("hwaccel %s unavailable, decoding in software: %v", hwaccel, err)
+		} else {
+			defer hdc.Free()
+			vctx.SetHardwareDeviceContext(hdc) // takes its own reference
+		}
+	}
 
-	_ = vopts.Set("hwaccel", hwaccel, 0)
-	_ = vopts.Set("err_detect", "careful", 0)
-	_ = vopts.Set("flags2", "+showall", 0)
+	errDetect := w.errDetect()
+	if errDetect == "ignore" {
+		errDetect = "0"
+	}
+	_ = vopts.Set("err_detect", errDetect, 0)
+	if ec := w.errConceal(); ec != "" {
+		_ = vopts.Set("ec", ec, 0)
+	}
+	// keeping the last good frame: no gray/smeared frames before the first
+	// keyframe, and none after a decode error until the next one (decoder loop)
+	hideCorrupt := globalConfig.HideCorruptFrames
+	if hideCorrupt {
+		_ = vopts.Set("flags", "-output_corrupt", 0)
+	} else {
+		_ = vopts.Set("flags2", "+showall", 0)
+	}
 	_ = vopts.Set("skip_frame", "default", 0)
 
-	applyDecParams(w.cfg.FFmpegParams, vopts)
+	applyDecParams(params, vopts)
 
-	log.Printf("[%s] ffmpeg video options: %s", w.cfg.Name, JoinDict(vopts))
+	w.logf("ffmpeg video options: %s", JoinDict(vopts))
 
 	if err := vctx.Open(vdec, vopts); err != nil {
 		return fmt.Errorf("open video: %w", err)
 	}
+	if left := JoinDict(vopts); left != "" {
+		w.logf("ffmpeg video options not used: %s", left)
+	}
+	for _, k := range unusedParams(vopts, params.Codec, "c") {
+		issues = append(issues, k+": not recognized by the decoder")
+	}
+	info := fmt.Sprintf("%s %dx%d %s", strings.ToUpper(vdec.Name()), vctx.Width(), vctx.Height(), vctx.PixelFormat().String())
+	w.streamInfo.Store(&info)
 
 	// initialize PTS gap estimator
 	w.pktPtsInited = false
@@ -326,16 +730,18 @@ func (w *CamWindow) openAndDecode() error {
 	}
 	w.fpsNom, w.fpsDen = r.Num(), r.Den()
 
+	if reprobe {
+		w.reprobe.Store(false)
+		w.logf("re-probed stream: %s %dx%d %s @ %d/%d fps",
+			vdec.Name(), vctx.Width(), vctx.Height(), vctx.PixelFormat().String(), w.fpsNom, w.fpsDen)
+	}
+
 	// --- audio decoder ---
 	var (
 		aCtx    *astiav.CodecContext
 		aFrame  *astiav.Frame
 		aPlayer oto.Player
-		aPipeR  *io.PipeReader
-		aPipeW  *io.PipeWriter
-		audioCh chan []byte
-		// signals audio writer exit
-		audioDone chan struct{}
+		aBuf    *pcmBuffer // bounded PCM queue feeding aPlayer
 	)
 	if aIdx >= 0 {
 		aPar := fc.Streams()[aIdx].CodecParameters()
@@ -351,69 +757,66 @@ func (w *CamWindow) openAndDecode() error {
 				}
 			}
 		}
+		format := fmt.Sprintf("%s %d Hz %dch", strings.ToUpper(aPar.CodecID().Name()), aPar.SampleRate(), aPar.ChannelLayout().Channels())
+		switch {
+		case aCtx == nil:
+			w.setAudioState(audioUnsupported, "no decoder for "+format)
+		case !playableAudio(aCtx.SampleFormat(), aCtx.ChannelLayout().Channels(), aCtx.SampleRate()):
+			w.setAudioState(audioUnsupported, format)
+		default:
+			w.setAudioState(audioPlayable, format)
+		}
+	} else {
+		w.setAudioState(audioNoTrack, "")
 	}
 	defer func() {
 		if aFrame != nil {
 			aFrame.Free()
 		}
+		if aBuf != nil {
+			aBuf.Close() // unblocks the player's reader
+		}
 		if aPlayer != nil {
 			_ = aPlayer.Close()
 		}
-		if audioCh != nil {
-			close(audioCh)
-		}
-		if aPipeR != nil {
-			_ = aPipeR.Close()
-		}
-		if aPipeW != nil {
-			_ = aPipeW.Close()
-		}
-		if audioDone != nil {
-			select {
-			case <-audioDone:
-			case <-time.After(500 * time.Millisecond):
-			}
-		}
 		if aCtx != nil {
 			aCtx.Free()
 		}
 	}()
 
 	// --- Recorder state (single connection, toggled by CamWindow.IsRecording) ---
+	var recStarted time.Time // start of the current file, for segmenting
+	// timestamp of the first packet after the file was opened: every stream of
+	// the file counts from here, so audio and video start out aligned
+	recOrigin, recOriginTB := astiav.NoPtsValue, astiav.NewRational(1, 1)
+	audioSynced := false // the AAC timeline has been placed on the source clock
 
 	closeRecorder := func() {
 		if w.recCtx == nil {
 			return
 		}
 
-		// Flush audio encoder (if any)
-		if w.aEncCtx != nil && w.aEncStream != nil {
-			_ = w.aEncCtx.SendFrame(nil)
-			for {
-				pkt := astiav.AllocPacket()
-				if err := w.aEncCtx.ReceivePacket(pkt); err != nil {
-					pkt.Free()
-					break
-				}
-
-				pkt.SetStreamIndex(w.aEncStream.Index())
-				pkt.RescaleTs(
-					w.aEncCtx.TimeBase(),
-					w.aEncStream.TimeBase(),
-				)
-
-				if err := w.recCtx.WriteInterleavedFrame(pkt); err != nil && !errors.Is(err, astiav.ErrEagain) {
-					log.Printf("[%s] recording: WriteInterleavedFrame (audio flush) error: %v", w.cfg.Name, err)
-				}
+		// Re-encoded video: whatever the encoder still holds
+		if w.vEnc != nil {
+			if err := w.vEnc.close(); err != nil {
+				w.logf("recording: video encoder flush: %v", err)
+			}
+			w.vEnc = nil
+		}
 
-				pkt.Unref()
-				pkt.Free()
+		// Flush audio encoder (if any): what the resampler still holds, the
+		// FIFO's last partial frame, then whatever the encoder still holds
+		if w.aEncCtx != nil && w.aEncStream != nil {
+			if w.aFifo != nil {
+				w.flushAudioResampler()
+				w.drainAudioFifo(true)
 			}
+			w.encodeAudio(nil)
 		}
 
 		// Write trailer for container
 		if err := w.recCtx.WriteTrailer(); err != nil && !errors.Is(err, astiav.ErrEagain) {
-			log.Printf("[%s] recording: WriteTrailer error: %v", w.cfg.Name, err)
+			w.logf("recording: WriteTrailer error: %v", err)
 		}
 
 		if w.recIO != nil {
@@ -421,29 +824,23 @@ func (w *CamWindow) openAndDecode() error {
 			w.recIO.Free()
 			w.recIO = nil
 		}
+		// trailer written: the file is final
+		clearRecordingMarker(w.recPath)
+		w.recPath = ""
 
 		if w.recCtx != nil {
 			w.recCtx.Free()
 			w.recCtx = nil
 		}
 
-		if w.aEncFrame != nil {
-			w.aEncFrame.Free()
-			w.aEncFrame = nil
-		}
-		if w.aSwr != nil {
-			w.aSwr.Free()
-			w.aSwr = nil
-		}
-		if w.aEncCtx != nil {
-			w.aEncCtx.Free()
-			w.aEncCtx = nil
-		}
-		w.aEncStream = nil
+		w.freeAudioEncoder()
 		w.recStreamIx = nil
+		w.recOpen.Store(nil)
 		w.audioPts = 0
+		recOrigin = astiav.NoPtsValue
+		audioSynced = false
 
-		log.Printf("[%s] recording stopped", w.cfg.Name)
+		w.logf("recording stopped")
 	}
 
 	defer closeRecorder()
@@ -454,16 +851,17 @@ func (w *CamWindow) openAndDecode() error {
 		}
 
 		started := time.Now()
-		outPath, err := recordingFilePath(w, started)
+		container, ext := recordingFormat(w.cfg)
+		outPath, err := recordingFilePath(w, started, ext)
 		if err != nil {
-			log.Printf("[%s] recording: cannot build path: %v", w.cfg.Name, err)
+			w.logf("recording: cannot build path: %v", err)
 			closeRecorder()
 			return
 		}
 
-		oc, err := astiav.AllocOutputFormatContext(nil, "mp4", outPath)
+		oc, err := astiav.AllocOutputFormatContext(nil, container, outPath)
 		if err != nil || oc == nil {
-			log.Printf("[%s] recording: AllocOutputFormatContext failed: %v", w.cfg.Name, err)
+			w.logf("recording: AllocOutputFormatContext failed: %v", err)
 			closeRecorder()
 			return
 		}
@@ -471,17 +869,33 @@ func (w *CamWindow) openAndDecode() error {
 		ioFlags := astiav.NewIOContextFlags(astiav.IOContextFlagWrite)
 		pb, err := astiav.OpenIOContext(outPath, ioFlags, nil, nil)
 		if err != nil {
-			log.Printf("[%s] recording: OpenIOContext failed: %v", w.cfg.Name, err)
+			w.logf("recording: OpenIOContext failed: %v", err)
 			oc.Free()
 			closeRecorder()
 			return
 		}
 		oc.SetPb(pb)
 
-		// --- Video stream: stream copy ---
+		// --- Video stream: stream copy, or re-encoded (record_video_mode h264) ---
 		w.recStreamIx = make(map[int]int)
 
+		if recordReencodes(w.cfg) {
+			rate := vst.AvgFrameRate()
+			if rate.Num() <= 0 {
+				rate = vst.RFrameRate()
+			}
+			if enc, err := newRecEncoder(oc, w.cfg, vctx.Width(), vctx.Height(), vst.TimeBase(), rate); err != nil {
+				w.logf("recording: cannot re-encode video, copying the stream instead: %v", err)
+			} else {
+				w.vEnc = enc
+				w.logf("recording: re-encoding video as %s", enc.desc)
+			}
+		}
+
 		for _, is := range fc.Streams() { // <--- fc, not fmtCtx
+			if w.vEnc != nil {
+				break // the encoder's stream replaces the copied one
+			}
 			par := is.CodecParameters()
 			if par.MediaType() != astiav.MediaTypeVideo {
 				continue
@@ -492,7 +906,7 @@ func (w *CamWindow) openAndDecode() error {
 				continue
 			}
 			if err := par.Copy(os.CodecParameters()); err != nil {
-				log.Printf("[%s] recording: copy video codec params failed: %v", w.cfg.Name, err)
+				w.logf("recording: copy video codec params failed: %v", err)
 				continue
 			}
 
@@ -500,8 +914,8 @@ func (w *CamWindow) openAndDecode() error {
 			w.recStreamIx[is.Index()] = os.Index()
 		}
 
-		if len(w.recStreamIx) == 0 {
-			log.Printf("[%s] recording: no video stream found", w.cfg.Name)
+		if len(w.recStreamIx) == 0 && w.vEnc == nil {
+			w.logf("recording: no video stream found")
 			_ = pb.Close()
 			pb.Free()
 			oc.Free()
@@ -510,17 +924,38 @@ func (w *CamWindow) openAndDecode() error {
 			return
 		}
 
+		// record_audio: false means video-only files, no encoder or resampler at all
+		recAudio := aIdx >= 0 && (w.cfg.RecordAudio == nil || *w.cfg.RecordAudio)
+
+		// --- Audio stream copy (source already AAC) ---
+		copyAudio := false
+		if recAudio && strings.EqualFold(w.cfg.RecordAudioMode, "copy") {
+			ais := fc.Streams()[aIdx]
+			apar := ais.CodecParameters()
+			if apar.CodecID() != astiav.CodecIDAac {
+				w.logf("recording: source audio is %s, not AAC; re-encoding instead of copy", apar.CodecID().String())
+			} else if os := oc.NewStream(nil); os != nil {
+				if err := apar.Copy(os.CodecParameters()); err != nil {
+					w.logf("recording: copy audio codec params failed: %v", err)
+				} else {
+					os.SetTimeBase(ais.TimeBase())
+					w.recStreamIx[ais.Index()] = os.Index()
+					copyAudio = true
+				}
+			}
+		}
+
 		// --- AAC ---
 		// se the existing decoder context aCtx and aIdx from playStreamForWindow.
-		if aCtx != nil && aIdx >= 0 {
+		if !copyAudio && aCtx != nil && recAudio {
 			// AAC encoder
 			ac := astiav.FindEncoder(astiav.CodecIDAac)
 			if ac == nil {
-				log.Printf("[%s] recording: AAC encoder not found", w.cfg.Name)
+				w.logf("recording: AAC encoder not found")
 			} else {
 				ctx := astiav.AllocCodecContext(ac)
 				if ctx == nil {
-					log.Printf("[%s] recording: AllocCodecContext for AAC failed", w.cfg.Name)
+					w.logf("recording: AllocCodecContext for AAC failed")
 				} else {
 					// Target: 44.1 kHz mono FLTP
 					const sr = 44100
@@ -562,7 +997,7 @@ func (w *CamWindow) openAndDecode() error {
 					}
 
 					if err := ctx.Open(ac, nil); err != nil {
-						log.Printf("[%s] recording: AAC encoder open failed: %v", w.cfg.Name, err)
+						w.logf("recording: AAC encoder open failed: %v", err)
 						ctx.Free()
 					} else {
 						w.aEncCtx = ctx
@@ -571,10 +1006,10 @@ func (w *CamWindow) openAndDecode() error {
 						// Output audio stream in the MP4
 						os := oc.NewStream(ac)
 						if os == nil {
-							log.Printf("[%s] recording: NewStream for AAC failed", w.cfg.Name)
+							w.logf("recording: NewStream for AAC failed")
 						} else {
 							if err := w.aEncCtx.ToCodecParameters(os.CodecParameters()); err != nil {
-								log.Printf("[%s] recording: ToCodecParameters failed: %v", w.cfg.Name, err)
+								w.logf("recording: ToCodecParameters failed: %v", err)
 							}
 							os.SetTimeBase(w.aEncCtx.TimeBase())
 							w.aEncStream = os
@@ -582,10 +1017,12 @@ func (w *CamWindow) openAndDecode() error {
 							// Resampler context – libswresample will configure itself on first ConvertFrame()
 							swr := astiav.AllocSoftwareResampleContext()
 							if swr == nil {
-								log.Printf("[%s] recording: AllocSoftwareResampleContext failed", w.cfg.Name)
+								w.logf("recording: AllocSoftwareResampleContext failed")
 							} else {
 								w.aSwr = swr
+								w.aSwrFrame = astiav.AllocFrame()
 								w.aEncFrame = astiav.AllocFrame()
+								w.aFifo = astiav.AllocAudioFifo(ctx.SampleFormat(), ctx.ChannelLayout().Channels(), max(ctx.FrameSize(), 1024))
 							}
 						}
 					}
@@ -593,27 +1030,27 @@ func (w *CamWindow) openAndDecode() error {
 			}
 		}
 
-		if err := oc.WriteHeader(nil); err != nil {
-			log.Printf("[%s] recording: WriteHeader failed: %v", w.cfg.Name, err)
+		// Fragmented MP4: every keyframe starts a self-contained fragment, so a
+		// file cut short by a crash/power loss stays playable up to that point.
+		muxOpts := astiav.NewDictionary()
+		defer muxOpts.Free()
+		if container == "mp4" {
+			_ = muxOpts.Set("movflags", "frag_keyframe+empty_moov+default_base_moof", 0)
+		}
+
+		if err := oc.WriteHeader(muxOpts); err != nil {
+			w.logf("recording: WriteHeader failed: %v", err)
 			_ = pb.Close()
 			pb.Free()
 			oc.Free()
 			w.recStreamIx = nil
 
-			if w.aSwr != nil {
-				w.aSwr.Free()
-				w.aSwr = nil
-			}
-			if w.aEncCtx != nil {
-				w.aEncCtx.Free()
-				w.aEncCtx = nil
-			}
-			if w.aEncFrame != nil {
-				w.aEncFrame.Free()
-				w.aEncFrame = nil
-			}
 			//closeRecorder() FIXME
-			w.aEncStream = nil
+			w.freeAudioEncoder()
+			if w.vEnc != nil {
+				w.vEnc.free()
+				w.vEnc = nil
+			}
 			w.audioPts = 0
 
 			return
@@ -621,12 +1058,35 @@ func (w *CamWindow) openAndDecode() error {
 
 		w.recCtx = oc
 		w.recIO = pb
-		log.Printf("[%s] recording started -> %s", w.cfg.Name, outPath)
+		w.recPath = outPath
+		recStarted = started
+		w.recOpen.Store(&recFile{path: outPath, started: started})
+		writeRecordingMarker(outPath)
+		w.logf("recording started -> %s", outPath)
 	}
 	// end of recorder block
 
 	// ---------- runtime ----------
 	var scaler bgraScaler
+	if v, ok := params.Scale["flags"]; ok {
+		scaler.flags, _ = swsFlags(v) // validated while parsing
+	}
+	scaler.maxW, scaler.maxH = maxDecodeSize()
+	scaler.logf = w.logf
+	limiter := newFPSLimiter(w.maxFPS(), w.tbNum, w.tbDen) // preview cap; recording is unaffected
+	waitKey := false                                       // hide_corrupt_frames: skip to the next keyframe after a decode error
+	// instant replay buffer (clip.go); audio only when it can be stream-copied
+	var ring *packetRing
+	clipAudio := -1
+	if n := w.cfg.ClipSeconds; n > 0 {
+		ring = &packetRing{keep: time.Duration(n) * time.Second}
+		defer ring.reset()
+		if aIdx >= 0 && fc.Streams()[aIdx].CodecParameters().CodecID() == astiav.CodecIDAac {
+			clipAudio = aIdx
+		}
+	}
+	segment := w.recordSegment()
+	var pacer realtimePacer
 	defer scaler.close()
 
 	pkt := astiav.AllocPacket()
@@ -641,7 +1101,7 @@ func (w *CamWindow) openAndDecode() error {
 	for {
 		// allow graceful stop
 		select {
-		case <-w.stop:
+		case <-stop:
 			return nil
 		default:
 		}
@@ -651,17 +1111,31 @@ func (w *CamWindow) openAndDecode() error {
 				break
 			}
 			// Ignore transient RTSP hiccups and continue.
-			if time.Since(lastProgress) > stallCutoff {
+			if stallCutoff == 0 {
+				// no watchdog: quiet periods are fine, real read errors are not
+				if !errors.Is(err, astiav.ErrEagain) {
+					return fmt.Errorf("ReadFrame: %w", err)
+				}
+			} else if time.Since(lastProgress) > stallCutoff {
 				return fmt.Errorf("stalled (>%s without progress)", stallCutoff)
 			}
 			time.Sleep(10 * time.Millisecond)
 			continue
 		}
+		if paced {
+			pacer.wait(pkt, fc.Streams()[pkt.StreamIndex()].TimeBase(), stop)
+		}
 
 		// Check hotkey state and start/stop recording as needed
 		if w.IsRecording() {
 			if w.recCtx == nil {
 				startRecorder()
+			} else if segment > 0 && pkt.StreamIndex() == vIdx && pkt.Flags().Has(astiav.PacketFlagKey) &&
+				time.Since(recStarted) >= segment {
+				// next segment starts on this keyframe so every file plays on its own
+				w.logf("recording: segment of %v done", segment)
+				closeRecorder()
+				startRecorder()
 			}
 		} else {
 			if w.recCtx != nil {
@@ -671,20 +1145,36 @@ func (w *CamWindow) openAndDecode() error {
 
 		si := pkt.StreamIndex()
 
+		if w.recCtx != nil && recOrigin == astiav.NoPtsValue {
+			recOrigin, recOriginTB = pkt.Dts(), fc.Streams()[si].TimeBase()
+			if recOrigin == astiav.NoPtsValue {
+				recOrigin = pkt.Pts()
+			}
+		}
+
 		// If recorder is active, clone this packet and mux it
 		if w.recCtx != nil {
-			if outIdx, ok := w.recStreamIx[si]; ok {
+			if outIdx, ok := w.recOutStream(si, aIdx); ok {
 				recPkt := astiav.AllocPacket()
 				if recPkt != nil {
 					if err := recPkt.Ref(pkt); err == nil {
 						inStream := fc.Streams()[si]
 						outStream := w.recCtx.Streams()[outIdx]
 
+						if recOrigin != astiav.NoPtsValue {
+							off := astiav.RescaleQ(recOrigin, recOriginTB, inStream.TimeBase())
+							if recPkt.Pts() != astiav.NoPtsValue {
+								recPkt.SetPts(recPkt.Pts() - off)
+							}
+							if recPkt.Dts() != astiav.NoPtsValue {
+								recPkt.SetDts(recPkt.Dts() - off)
+							}
+						}
 						recPkt.RescaleTs(inStream.TimeBase(), outStream.TimeBase())
 						recPkt.SetStreamIndex(outIdx)
 
 						if err := w.recCtx.WriteInterleavedFrame(recPkt); err != nil && !errors.Is(err, astiav.ErrEagain) {
-							log.Printf("[%s] recording: WriteInterleavedFrame error: %v", w.cfg.Name, err)
+							w.logf("recording: WriteInterleavedFrame error: %v", err)
 						}
 					}
 					recPkt.Unref()
@@ -693,8 +1183,20 @@ func (w *CamWindow) openAndDecode() error {
 			}
 		}
 
+		if ring != nil && (si == vIdx || si == clipAudio) {
+			ring.push(pkt, si == vIdx && pkt.Flags().Has(astiav.PacketFlagKey))
+		}
+		if w.clipReq.Swap(false) && ring != nil {
+			w.writeClipFrom(ring, fc, vIdx, clipAudio)
+		}
+
 		// --- audio path ---
-		if aCtx != nil && pkt.StreamIndex() == aIdx && !w.cfg.Mute {
+		// decoded when heard, metered or recorded; mute stops playback and
+		// recording, mute_playback / record_audio set them apart
+		muted := w.muted.Load() // toggled live from the menu / M key
+		silent := muted || w.cfg.MutePlayback
+		recordsAudio := w.recCtx != nil && w.aEncCtx != nil && w.audioToRecording(muted)
+		if aCtx != nil && pkt.StreamIndex() == aIdx && (!silent || w.cfg.showOverlay(ovAudioMeter) || recordsAudio) {
 			if err := aCtx.SendPacket(pkt); err == nil || errors.Is(err, astiav.ErrEagain) {
 				for {
 					if err := aCtx.ReceiveFrame(aFrame); err != nil {
@@ -702,43 +1204,30 @@ func (w *CamWindow) openAndDecode() error {
 						break
 					}
 
-					// play only packed S16, mono, 8 kHz (typical G.711).
-					if aFrame.SampleFormat() == astiav.SampleFormatS16 &&
-						aFrame.ChannelLayout().Channels() == 1 &&
-						aFrame.SampleRate() == 8000 {
+					if w.cfg.showOverlay(ovAudioMeter) {
+						if peak, ok := framePeak(aFrame); ok {
+							w.setAudioPeak(peak)
+						}
+					}
+
+					// play only what the player takes as is (see playableAudio)
+					if !silent && audioAvailable() &&
+						playableAudio(aFrame.SampleFormat(), aFrame.ChannelLayout().Channels(), aFrame.SampleRate()) {
 
 						// Create an Oto Player once per camera.
-						if aPlayer == nil || aPipeW == nil {
-							pr, pw := io.Pipe()
-							p := GlobalAudioContext.NewPlayer(pr)
+						if aPlayer == nil || aBuf == nil {
+							// ~0.5s of 8 kHz mono S16; older audio is dropped when the player lags
+							b := newPCMBuffer(8000, audioUnderrunSilence())
+							p := GlobalAudioContext.NewPlayer(b)
 							if p == nil {
-								_ = pw.Close()
+								b.Close()
 								log.Printf("audio: NewPlayer failed")
 								aFrame.Unref()
 								continue
 							}
 							p.Play()
 							aPlayer = p
-							aPipeR = pr
-							aPipeW = pw
-							audioCh = make(chan []byte, 8)
-							audioDone = make(chan struct{})
-							go func() {
-								defer close(audioDone)
-								for {
-									select {
-									case <-w.stop:
-										return
-									case buf, ok := <-audioCh:
-										if !ok {
-											return
-										}
-										if _, err := aPipeW.Write(buf); err != nil {
-											return
-										}
-									}
-								}
-							}()
+							aBuf = b
 						}
 
 						// For packed S16 mono: data[0] holds nb_samples * 2 bytes.
@@ -748,71 +1237,34 @@ func (w *CamWindow) openAndDecode() error {
 							if need > len(pcm) {
 								need = len(pcm)
 							}
-							if audioCh != nil {
-								buf := make([]byte, need)
-								copy(buf, pcm[:need])
-								select {
-								case audioCh <- buf:
-								default:
-									// drop if writer is backed up
-								}
-							}
+							_, _ = aBuf.Write(pcm[:need]) // never blocks
 						}
 					}
 					// start of audio recording block
 					// --- Recording: feed this decoded frame into AAC encoder ---
-					if w.recCtx != nil && w.aEncCtx != nil && w.aSwr != nil && w.aEncStream != nil && w.aEncFrame != nil {
-						// AAC uses fixed-size frames; typically 1024 samples.
-						frameSize := w.aEncCtx.FrameSize()
-						if frameSize <= 0 {
-							frameSize = 1024
-						}
-
-						// Prepare encoder frame as 44.1 kHz mono FLTP with frameSize samples.
-						w.aEncFrame.Unref()
-						w.aEncFrame.SetSampleFormat(w.aEncCtx.SampleFormat())
-						w.aEncFrame.SetChannelLayout(w.aEncCtx.ChannelLayout())
-						w.aEncFrame.SetSampleRate(w.aEncCtx.SampleRate())
-						w.aEncFrame.SetNbSamples(frameSize)
-
-						if err := w.aEncFrame.AllocBuffer(0); err != nil {
-							log.Printf("[%s] recording: audio frame AllocBuffer failed: %v", w.cfg.Name, err)
-						} else {
-							// Convert from decoder format (8 kHz S16 mono) to encoder format (44.1 kHz FLTP mono).
-							// Note: SoftwareResampleContext.ConvertFrame(src, dst)
-							if err := w.aSwr.ConvertFrame(aFrame, w.aEncFrame); err != nil {
-								log.Printf("[%s] recording: swr ConvertFrame failed: %v", w.cfg.Name, err)
-							} else if ns := w.aEncFrame.NbSamples(); ns > 0 {
-								// Set PTS in samples, time base = 1 / sampleRate
-								w.aEncFrame.SetPts(w.audioPts)
+					if recordsAudio && w.aSwr != nil && w.aEncStream != nil && w.aFifo != nil {
+						// Convert from decoder format (8 kHz S16 mono) to encoder format (44.1 kHz FLTP mono);
+						// swr allocates the output for however many samples come out.
+						w.aSwrFrame.Unref()
+						w.aSwrFrame.SetSampleFormat(w.aEncCtx.SampleFormat())
+						w.aSwrFrame.SetChannelLayout(w.aEncCtx.ChannelLayout())
+						w.aSwrFrame.SetSampleRate(w.aEncCtx.SampleRate())
+
+						if err := w.aSwr.ConvertFrame(aFrame, w.aSwrFrame); err != nil {
+							w.logf("recording: swr ConvertFrame failed: %v", err)
+						} else if ns := w.aSwrFrame.NbSamples(); ns > 0 {
+							// PTS in samples (time base 1/sampleRate), counted from the
+							// recording origin; gaps in the source move it forward
+							if !w.syncAudioPts(aFrame.Pts(), fc.Streams()[aIdx].TimeBase(), recOrigin, recOriginTB, &audioSynced) {
+								aFrame.Unref()
+								continue // audio ran ahead of the source clock: drop this frame
+							}
+							// AAC takes fixed-size frames: buffer, then encode whole ones
+							if _, err := w.aFifo.Write(w.aSwrFrame); err != nil {
+								w.logf("recording: audio FIFO write failed: %v", err)
+							} else {
 								w.audioPts += int64(ns)
-
-								// Send frame to encoder
-								if err := w.aEncCtx.SendFrame(w.aEncFrame); err != nil && !errors.Is(err, astiav.ErrEagain) {
-									log.Printf("[%s] recording: AAC SendFrame error: %v", w.cfg.Name, err)
-								} else {
-									// Read all available encoded packets
-									for {
-										ep := astiav.AllocPacket()
-										if err := w.aEncCtx.ReceivePacket(ep); err != nil {
-											ep.Free()
-											break
-										}
-
-										ep.SetStreamIndex(w.aEncStream.Index())
-										ep.RescaleTs(
-											w.aEncCtx.TimeBase(),
-											w.aEncStream.TimeBase(),
-										)
-
-										if err := w.recCtx.WriteInterleavedFrame(ep); err != nil && !errors.Is(err, astiav.ErrEagain) {
-											log.Printf("[%s] recording: WriteInterleavedFrame (audio) error: %v", w.cfg.Name, err)
-										}
-
-										ep.Unref()
-										ep.Free()
-									}
-								}
+								w.drainAudioFifo(false)
 							}
 						}
 					} // end of audio recording block
@@ -826,7 +1278,7 @@ func (w *CamWindow) openAndDecode() error {
 		}
 
 		if si == vIdx {
-			if globalConfig.ShowDrops { // if frame drop display is enabled we will start to collect the samples
+			if w.cfg.showOverlay(ovDrops) { // if frame drop display is enabled we will start to collect the samples
 				// accumulate payload size even before decode
 				atomic.AddInt64(&w.bytesVideo, int64(pkt.Size()))
 				// --- PTS-based gap estimator ---
@@ -859,7 +1311,9 @@ func (w *CamWindow) openAndDecode() error {
 				}
 			}
 			pktStart := time.Now() // start measure cpu utilization
-			if err := vctx.SendPacket(pkt); err == nil {
+			if err := vctx.SendPacket(pkt); err != nil && !errors.Is(err, astiav.ErrEagain) {
+				waitKey = hideCorrupt // the frames referencing this one would smear
+			} else if err == nil {
 				for {
 					err := vctx.ReceiveFrame(vf)
 					// EAGAIN: no more frames for this packet; not a drop
@@ -868,32 +1322,57 @@ func (w *CamWindow) openAndDecode() error {
 					}
 					if err != nil {
 						// count hard decode errors as "drops"
-						if globalConfig.ShowDrops {
+						if w.cfg.showOverlay(ovDrops) {
 							atomic.AddInt64(&w.decodeErrs, 1)
 							atomic.AddInt64(&w.framesDropped, 1)
 						}
+						waitKey = hideCorrupt
 						break
 					}
+					// re-encoded recording: every decoded frame, before the preview's fps cap
+					if w.vEnc != nil && w.recCtx != nil && recOrigin != astiav.NoPtsValue {
+						pts := vf.Pts()
+						if pts != astiav.NoPtsValue {
+							pts -= astiav.RescaleQ(recOrigin, recOriginTB, vst.TimeBase())
+						}
+						if err := w.vEnc.encode(vf, pts); err != nil {
+							w.logf("recording: video encode: %v", err)
+						}
+					}
+					// after a decode error: keep the last good picture until a keyframe
+					if waitKey {
+						if !vf.KeyFrame() {
+							vf.Unref()
+							continue
+						}
+						waitKey = false
+					}
 
 					// success...
 
+					// over the fps cap: drop before the expensive convert/copy
+					if !limiter.allow(vf.Pts()) {
+						vf.Unref()
+						continue
+					}
+
 					// (optional) log the source geometry
 					if false {
 						ls := vf.Linesize()
-						log.Printf("[%s] src fmt=%s w=%d h=%d L0=%d L1=%d L2=%d",
-							w.cfg.Name, vf.PixelFormat().String(),
+						w.logf("src fmt=%s w=%d h=%d L0=%d L1=%d L2=%d",
+							vf.PixelFormat().String(),
 							vf.Width(), vf.Height(), ls[0], ls[1], ls[2])
 					}
 					// If decoder returned hardware frames, transfer to software before scaling.
 					srcFrame := vf
 					if vf.HardwareFramesContext() != nil {
 						if swFrame == nil {
-							log.Printf("[%s] hwframe transfer skipped: no software frame", w.cfg.Name)
+							w.logf("hwframe transfer skipped: no software frame")
 							vf.Unref()
 							continue
 						}
 						if err := vf.TransferHardwareData(swFrame); err != nil {
-							log.Printf("[%s] hwframe transfer failed: %v", w.cfg.Name, err)
+							w.logf("hwframe transfer failed: %v", err)
 							vf.Unref()
 							continue
 						}
@@ -906,7 +1385,7 @@ func (w *CamWindow) openAndDecode() error {
 					bw, bh, bgra, err := scaler.toBGRA(srcFrame)
 					atomic.AddInt64(&w.busyNS, time.Since(t0).Nanoseconds())
 					if err != nil {
-						log.Printf("[%s] toBGRA error: %v", w.cfg.Name, err)
+						w.logf("toBGRA error: %v", err)
 						if srcFrame == swFrame {
 							swFrame.Unref()
 						}
@@ -920,6 +1399,10 @@ func (w *CamWindow) openAndDecode() error {
 					atomic.AddInt64(&w.framesDecoded, 1)                     // bump the frame counter
 					w.lastAdvance = time.Now()
 					lastProgress = time.Now()
+					if w.connState() != connConnected {
+						w.backoff = time.Second // healthy again: restart the backoff ladder
+						w.setConnState(connConnected)
+					}
 
 					if srcFrame == swFrame {
 						swFrame.Unref()
@@ -933,7 +1416,7 @@ func (w *CamWindow) openAndDecode() error {
 
 		pkt.Unref()
 
-		if time.Since(lastProgress) > stallCutoff {
+		if stallCutoff > 0 && time.Since(lastProgress) > stallCutoff {
 			return fmt.Errorf("[%s] stall watchdog: no progress for %s", w.cfg.Name, stallCutoff)
 		}
 	}
@@ -952,8 +1435,224 @@ func (w *CamWindow) openAndDecode() error {
 	return nil
 }
 
-// recordingFilePath builds $HOME/AnotherRTSP-Recordings/<camera>/YYYY-MM-DD_HH-MM-SS.mp4
-func recordingFilePath(w *CamWindow, started time.Time) (string, error) {
+// recordingFormat maps the camera's RecordContainer to an FFmpeg muxer name and file extension.
+// MKV survives an interrupted recording far better than MP4 (no trailing moov atom).
+func recordingFormat(c CameraConfig) (muxer, ext string) {
+	switch strings.ToLower(strings.TrimSpace(c.RecordContainer)) {
+	case "mkv", "matroska":
+		return "matroska", ".mkv"
+	default:
+		return "mp4", ".mp4"
+	}
+}
+
+// defaultSegmentMin is the file length for always_record cameras without
+// their own record_segment_min.
+const defaultSegmentMin = 15
+
+// recordSegment is how long one recording file runs before the next one is
+// started; 0 keeps a single file until recording stops.
+func (w *CamWindow) recordSegment() time.Duration {
+	m := w.cfg.RecordSegmentMin
+	if m <= 0 {
+		if !w.cfg.AlwaysRecord {
+			return 0
+		}
+		m = defaultSegmentMin
+	}
+	return time.Duration(m) * time.Minute
+}
+
+// audioDriftTolerance is how far the AAC timeline of a recording may stray
+// from the source clock (lost packets, a camera clock running off) before it
+// is corrected.
+const audioDriftTolerance = 100 * time.Millisecond
+
+// syncAudioPts keeps w.audioPts, the PTS of the next buffered sample, on the
+// source clock. The first frame of a recording is placed where the source says
+// it belongs relative to the recording origin; later on a gap moves the
+// timeline forward, while audio running ahead has this frame dropped (false)
+// since timestamps can't go back.
+func (w *CamWindow) syncAudioPts(src int64, srcTB astiav.Rational, origin int64, originTB astiav.Rational, synced *bool) bool {
+	if src == astiav.NoPtsValue || origin == astiav.NoPtsValue || w.aEncCtx == nil {
+		return true // nothing to go by: keep counting samples
+	}
+	encTB := w.aEncCtx.TimeBase()
+	want := astiav.RescaleQ(src, srcTB, encTB) - astiav.RescaleQ(origin, originTB, encTB)
+	if !*synced {
+		*synced = true
+		if want > w.audioPts {
+			w.audioPts = want
+		}
+		return true
+	}
+	tol := astiav.RescaleQ(audioDriftTolerance.Microseconds(), astiav.TimeBaseQ, encTB)
+	drift := want - w.audioPts
+	switch {
+	case drift > tol:
+		w.logf("recording: audio fell %v behind the video, resyncing",
+			time.Duration(astiav.RescaleQ(drift, encTB, astiav.TimeBaseQ))*time.Microsecond)
+		w.padAudioFifo() // what's buffered belongs before the gap
+		w.audioPts = want
+	case drift < -tol:
+		w.logf("recording: audio ran %v ahead of the video, dropping a frame",
+			time.Duration(astiav.RescaleQ(-drift, encTB, astiav.TimeBaseQ))*time.Microsecond)
+		return false
+	}
+	return true
+}
+
+// drainAudioFifo encodes the buffered recording audio in frames of exactly the
+// AAC encoder's frame size. With flush the remainder goes out too, as a last
+// shorter frame the encoder pads with silence.
+func (w *CamWindow) drainAudioFifo(flush bool) {
+	frameSize := w.aEncCtx.FrameSize()
+	if frameSize <= 0 {
+		frameSize = 1024
+	}
+	for {
+		n := w.aFifo.Size()
+		if n == 0 || (n < frameSize && !flush) {
+			return
+		}
+		n = min(n, frameSize)
+		// the FIFO ends at audioPts, so its head is that many samples earlier
+		pts := w.audioPts - int64(w.aFifo.Size())
+
+		w.aEncFrame.Unref()
+		w.aEncFrame.SetSampleFormat(w.aEncCtx.SampleFormat())
+		w.aEncFrame.SetChannelLayout(w.aEncCtx.ChannelLayout())
+		w.aEncFrame.SetSampleRate(w.aEncCtx.SampleRate())
+		w.aEncFrame.SetNbSamples(n)
+		if err := w.aEncFrame.AllocBuffer(0); err != nil {
+			w.logf("recording: audio frame AllocBuffer failed: %v", err)
+			return
+		}
+		if _, err := w.aFifo.Read(w.aEncFrame); err != nil {
+			w.logf("recording: audio FIFO read failed: %v", err)
+			return
+		}
+		w.aEncFrame.SetPts(pts)
+		w.encodeAudio(w.aEncFrame)
+	}
+}
+
+// padAudioFifo tops the buffered recording audio up with silence to a whole
+// AAC frame and encodes it, so it goes out at its own position before the
+// timeline jumps. (A short frame would end the encoder's input.)
+func (w *CamWindow) padAudioFifo() {
+	if w.aFifo == nil || w.aEncFrame == nil {
+		return
+	}
+	frameSize := w.aEncCtx.FrameSize()
+	if frameSize <= 0 {
+		frameSize = 1024
+	}
+	rem := w.aFifo.Size() % frameSize
+	if rem == 0 {
+		return
+	}
+	n := frameSize - rem
+	w.aEncFrame.Unref()
+	w.aEncFrame.SetSampleFormat(w.aEncCtx.SampleFormat())
+	w.aEncFrame.SetChannelLayout(w.aEncCtx.ChannelLayout())
+	w.aEncFrame.SetSampleRate(w.aEncCtx.SampleRate())
+	w.aEncFrame.SetNbSamples(n)
+	if err := w.aEncFrame.AllocBuffer(0); err != nil {
+		w.logf("recording: audio frame AllocBuffer failed: %v", err)
+		return
+	}
+	if err := w.aEncFrame.SamplesFillSilence(); err != nil {
+		w.logf("recording: audio silence fill failed: %v", err)
+		return
+	}
+	if _, err := w.aFifo.Write(w.aEncFrame); err != nil {
+		w.logf("recording: audio FIFO write failed: %v", err)
+		return
+	}
+	w.audioPts += int64(n)
+	w.drainAudioFifo(false)
+}
+
+// flushAudioResampler moves the samples the resampler still holds (its
+// filter delay) into the FIFO, so the end of a recording isn't cut off.
+func (w *CamWindow) flushAudioResampler() {
+	if w.aSwr == nil || w.aSwrFrame == nil {
+		return
+	}
+	w.aSwrFrame.Unref()
+	w.aSwrFrame.SetSampleFormat(w.aEncCtx.SampleFormat())
+	w.aSwrFrame.SetChannelLayout(w.aEncCtx.ChannelLayout())
+	w.aSwrFrame.SetSampleRate(w.aEncCtx.SampleRate())
+	if err := w.aSwr.ConvertFrame(nil, w.aSwrFrame); err != nil {
+		w.logf("recording: swr flush failed: %v", err)
+		return
+	}
+	if ns := w.aSwrFrame.NbSamples(); ns > 0 {
+		if _, err := w.aFifo.Write(w.aSwrFrame); err != nil {
+			w.logf("recording: audio FIFO write failed: %v", err)
+			return
+		}
+		w.audioPts += int64(ns)
+	}
+}
+
+// encodeAudio sends one frame (nil: end of stream) to the AAC encoder and
+// muxes every packet it has ready.
+func (w *CamWindow) encodeAudio(f *astiav.Frame) {
+	if err := w.aEncCtx.SendFrame(f); err != nil && !errors.Is(err, astiav.ErrEagain) {
+		w.logf("recording: AAC SendFrame error: %v", err)
+		return
+	}
+	for {
+		ep := astiav.AllocPacket()
+		if err := w.aEncCtx.ReceivePacket(ep); err != nil {
+			ep.Free()
+			return
+		}
+
+		ep.SetStreamIndex(w.aEncStream.Index())
+		ep.RescaleTs(
+			w.aEncCtx.TimeBase(),
+			w.aEncStream.TimeBase(),
+		)
+
+		if err := w.recCtx.WriteInterleavedFrame(ep); err != nil && !errors.Is(err, astiav.ErrEagain) {
+			w.logf("recording: WriteInterleavedFrame (audio) error: %v", err)
+		}
+
+		ep.Unref()
+		ep.Free()
+	}
+}
+
+// freeAudioEncoder releases the recording's AAC encoder, resampler and FIFO.
+func (w *CamWindow) freeAudioEncoder() {
+	if w.aFifo != nil {
+		w.aFifo.Free()
+		w.aFifo = nil
+	}
+	if w.aSwrFrame != nil {
+		w.aSwrFrame.Free()
+		w.aSwrFrame = nil
+	}
+	if w.aEncFrame != nil {
+		w.aEncFrame.Free()
+		w.aEncFrame = nil
+	}
+	if w.aSwr != nil {
+		w.aSwr.Free()
+		w.aSwr = nil
+	}
+	if w.aEncCtx != nil {
+		w.aEncCtx.Free()
+		w.aEncCtx = nil
+	}
+	w.aEncStream = nil
+}
+
+// recordingsRoot is $HOME/AnotherRTSP-Recordings.
+func recordingsRoot() (string, error) {
 	// Prefer env.homeDir, but fall back to os.UserHomeDir
 	base := env.homeDir
 	if base == "" {
@@ -963,18 +1662,111 @@ func recordingFilePath(w *CamWindow, started time.Time) (string, error) {
 		}
 		base = h
 	}
+	return filepath.Join(base, "AnotherRTSP-Recordings"), nil
+}
 
+// recordingDir is $HOME/AnotherRTSP-Recordings/<camera>, created if needed.
+func recordingDir(w *CamWindow) (string, error) {
+	root, err := recordingsRoot()
+	if err != nil {
+		return "", err
+	}
 	camName := w.cfg.Name
 	if camName == "" {
 		camName = w.cfg.URL
 	}
-	camName = sanitizeFSComponent(camName)
-
-	dir := filepath.Join(base, "AnotherRTSP-Recordings", camName)
+	dir := filepath.Join(root, sanitizeFSComponent(camName))
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		return "", err
 	}
+	return dir, nil
+}
+
+// defaultRecordNameTemplate gives YYYY-MM-DD_HH-MM-SS, the historic naming.
+const defaultRecordNameTemplate = "{date}_{time}"
+
+// recordingFileName expands the recording name template (Settings →
+// Recording file name). Tokens: {camera}, {id}, {date}, {time}, {seq}; seq
+// counts on from the highest one among the folder's files of this template
+// (0001, 0002, …). Without {seq} a clashing name gets "-2", "-3", … appended.
+func recordingFileName(dir, tmpl string, c CameraConfig, camName string, started time.Time, ext string) string {
+	if strings.TrimSpace(tmpl) == "" {
+		tmpl = defaultRecordNameTemplate
+	}
+	expand := func(seq int) string {
+		name := strings.NewReplacer(
+			"{camera}", camName,
+			"{id}", c.ID,
+			"{date}", started.Format("2006-01-02"),
+			"{time}", started.Format("15-04-05"),
+			"{seq}", fmt.Sprintf("%04d", seq),
+		).Replace(tmpl)
+		return sanitizeFSComponent(name)
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(dir, name+ext))
+		return err == nil
+	}
+
+	if strings.Contains(tmpl, "{seq}") {
+		seq := lastRecordingSeq(dir, tmpl, c, camName, ext) + 1
+		for exists(expand(seq)) {
+			seq++
+		}
+		return expand(seq) + ext
+	}
+	name := expand(0)
+	for n := 2; exists(name); n++ {
+		name = fmt.Sprintf("%s-%d", expand(0), n)
+	}
+	return name + ext
+}
+
+// lastRecordingSeq is the highest {seq} of the files in dir that tmpl could
+// have named (any date and time, this camera), 0 when there are none.
+func lastRecordingSeq(dir, tmpl string, c CameraConfig, camName, ext string) int {
+	// expand with markers that survive sanitizing, then turn them into patterns
+	const mDate, mTime, mSeq = "\x00date\x00", "\x00time\x00", "\x00seq\x00"
+	name := sanitizeFSComponent(strings.NewReplacer(
+		"{camera}", camName,
+		"{id}", c.ID,
+		"{date}", mDate,
+		"{time}", mTime,
+		"{seq}", mSeq,
+	).Replace(tmpl))
+	pat := regexp.QuoteMeta(name + ext)
+	pat = strings.ReplaceAll(pat, mDate, `\d{4}-\d{2}-\d{2}`)
+	pat = strings.ReplaceAll(pat, mTime, `\d{2}-\d{2}-\d{2}`)
+	pat = strings.Replace(pat, mSeq, `(\d+)`, 1) // a repeated {seq} has the same value
+	pat = strings.ReplaceAll(pat, mSeq, `\d+`)
+	re, err := regexp.Compile("^" + pat + "$")
+	if err != nil {
+		return 0
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+	last := 0
+	for _, e := range entries {
+		if m := re.FindStringSubmatch(e.Name()); m != nil {
+			if n, err := strconv.Atoi(m[1]); err == nil && n > last {
+				last = n
+			}
+		}
+	}
+	return last
+}
+
+// recordingFilePath builds $HOME/AnotherRTSP-Recordings/<camera>/<template><ext>
+// (default template: YYYY-MM-DD_HH-MM-SS).
+func recordingFilePath(w *CamWindow, started time.Time, ext string) (string, error) {
+	dir, err := recordingDir(w)
+	if err != nil {
+		return "", err
+	}
+	camName := filepath.Base(dir)
 
-	fname := started.Format("2006-01-02_15-04-05") + ".mp4"
+	fname := recordingFileName(dir, globalConfig.RecordNameTemplate, w.cfg, camName, started, ext)
 	return filepath.Join(dir, fname), nil
 }
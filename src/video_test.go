@@ -0,0 +1,174 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	astiav "github.com/asticode/go-astiav"
+)
+
+// syncAudioPts places recorded AAC audio (time base 1/44100) on the source
+// clock: 8 kHz camera audio against a 90 kHz video origin.
+func TestSyncAudioPts(t *testing.T) {
+	enc := astiav.AllocCodecContext(nil)
+	defer enc.Free()
+	enc.SetTimeBase(astiav.NewRational(1, 44100))
+	srcTB, videoTB := astiav.NewRational(1, 8000), astiav.NewRational(1, 90000)
+
+	// one step is an audio frame: its source PTS, the samples it adds once
+	// kept, whether it is kept and where the timeline is after the sync
+	type step struct {
+		src     int64
+		samples int64
+		keep    bool
+		pts     int64
+	}
+	const frame = 882 // 20 ms: 160 samples at 8 kHz, resampled to 44.1 kHz
+	tests := []struct {
+		name   string
+		origin int64 // recording origin, video time base
+		steps  []step
+	}{
+		{"origin offset", 90000, []step{
+			{src: 12000, samples: frame, keep: true, pts: 22050}, // 1.5 s source = 0.5 s into the file
+			{src: 12160, samples: frame, keep: true, pts: 22050 + frame},
+		}},
+		{"audio from before the origin is dropped", 90000, []step{
+			{src: 4000, samples: frame, keep: true, pts: 0}, // the first frame opens the track
+			{src: 4160, samples: frame, keep: false, pts: frame},
+			{src: 12000, samples: frame, keep: true, pts: 22050}, // past the origin: resynced
+		}},
+		{"steady stream", 0, []step{
+			{src: 0, samples: frame, keep: true, pts: 0},
+			{src: 160, samples: frame, keep: true, pts: frame},
+			{src: 320, samples: frame, keep: true, pts: 2 * frame},
+		}},
+		{"small jitter is tolerated", 0, []step{
+			{src: 0, samples: frame, keep: true, pts: 0},
+			{src: 400, samples: frame, keep: true, pts: frame}, // 30 ms late: within 100 ms
+		}},
+		{"gap resyncs forward", 0, []step{
+			{src: 0, samples: frame, keep: true, pts: 0},
+			{src: 8000, samples: frame, keep: true, pts: 44100}, // 1 s of lost packets
+			{src: 8160, samples: frame, keep: true, pts: 44100 + frame},
+		}},
+		{"audio ahead of video is dropped", 0, []step{
+			{src: 0, samples: 44100, keep: true, pts: 0}, // a burst: 1 s of samples at once
+			{src: 160, samples: frame, keep: false, pts: 44100},
+			{src: 8000, samples: frame, keep: true, pts: 44100}, // caught up again
+		}},
+		{"no timestamps: keep counting", 0, []step{
+			{src: astiav.NoPtsValue, samples: frame, keep: true, pts: 0},
+			{src: astiav.NoPtsValue, samples: frame, keep: true, pts: frame},
+		}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := &CamWindow{aEncCtx: enc}
+			synced := false
+			for i, st := range tc.steps {
+				prev := w.audioPts
+				keep := w.syncAudioPts(st.src, srcTB, tc.origin, videoTB, &synced)
+				if keep != st.keep {
+					t.Errorf("step %d: kept %v, want %v", i, keep, st.keep)
+				}
+				if w.audioPts != st.pts {
+					t.Errorf("step %d: pts %d, want %d", i, w.audioPts, st.pts)
+				}
+				if w.audioPts < prev {
+					t.Errorf("step %d: pts went back from %d to %d", i, prev, w.audioPts)
+				}
+				if keep {
+					w.audioPts += st.samples
+				}
+			}
+		})
+	}
+}
+
+// With record_audio_mode: copy the source audio packets are muxed as they
+// come; mute and record_audio must leave them out just like encoded audio.
+func TestRecOutStreamCopiedAudio(t *testing.T) {
+	on, off := true, false
+	const vIdx, aIdx = 0, 1
+	cases := []struct {
+		name      string
+		record    *bool
+		muted     bool
+		wantAudio bool
+	}{
+		{"default", nil, false, true},
+		{"default muted", nil, true, false},
+		{"always muted", &on, true, true},
+		{"never", &off, false, false},
+		{"never muted", &off, true, false},
+	}
+	for _, tc := range cases {
+		w := &CamWindow{cfg: CameraConfig{RecordAudioMode: "copy", RecordAudio: tc.record}}
+		w.recStreamIx = map[int]int{vIdx: 0, aIdx: 1}
+		w.muted.Store(tc.muted)
+		if out, ok := w.recOutStream(vIdx, aIdx); !ok || out != 0 {
+			t.Errorf("%s: video -> %d, %v; want 0, true", tc.name, out, ok)
+		}
+		if out, ok := w.recOutStream(aIdx, aIdx); ok != tc.wantAudio || (ok && out != 1) {
+			t.Errorf("%s: audio -> %d, %v; want recorded=%v", tc.name, out, ok, tc.wantAudio)
+		}
+		if _, ok := w.recOutStream(2, aIdx); ok {
+			t.Errorf("%s: unmapped stream recorded", tc.name)
+		}
+	}
+}
+
+// {seq} continues from the highest number already in the folder, also with
+// the documented {camera}_{date}_{time}_{seq} where the other tokens alone
+// would make every name unique.
+func TestRecordingFileNameSeq(t *testing.T) {
+	dir := t.TempDir()
+	const tmpl = "{camera}_{date}_{time}_{seq}"
+	c := CameraConfig{ID: "c1", Name: "Cam"}
+	started := time.Date(2025, 1, 31, 18, 4, 5, 0, time.Local)
+
+	if got := recordingFileName(dir, tmpl, c, "Cam", started, ".mp4"); got != "Cam_2025-01-31_18-04-05_0001.mp4" {
+		t.Fatalf("empty folder: %s", got)
+	}
+	for _, f := range []string{
+		"Cam_2025-01-30_10-00-00_0001.mp4",
+		"Cam_2025-01-31_09-00-00_0007.mp4",
+		"Cam_2025-01-31_09-30-00_0003.mp4",
+		"Other_2025-01-31_09-00-00_0042.mp4", // another camera's name
+		"Cam_2025-01-31_09-00-00_0099.mkv",   // another container
+		"Cam_notes_0050.mp4",                 // not this template
+	} {
+		if err := os.WriteFile(filepath.Join(dir, f), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := recordingFileName(dir, tmpl, c, "Cam", started, ".mp4"); got != "Cam_2025-01-31_18-04-05_0008.mp4" {
+		t.Errorf("next: %s, want seq 0008", got)
+	}
+	if got := recordingFileName(dir, "", c, "Cam", started, ".mp4"); got != "2025-01-31_18-04-05.mp4" {
+		t.Errorf("default template: %s", got)
+	}
+}
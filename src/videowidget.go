@@ -23,7 +23,10 @@ package main
 import (
 	"fmt"
 	"log"
+	"math"
+	"runtime"
 	"strings"
+	"time"
 	"unsafe"
 
 	"github.com/mappu/miqt/qt"
@@ -34,6 +37,12 @@ type VideoWidget struct {
 	*qt.QWidget
 	buf     *frameBuf
 	Stretch bool
+	// keep the aspect ratio while fullscreen, whatever Stretch says
+	FullscreenAspect bool
+	// display transform (degrees clockwise + mirroring)
+	Rotate       int
+	FlipH, FlipV bool
+	Aspect       float64 // forced display aspect (w/h); 0 = use the source size
 	// drag/resize state for frameless windows ---
 	owner    *CamWindow
 	dragging bool
@@ -51,6 +60,12 @@ type VideoWidget struct {
 	groupPos   map[*CamWindow]struct{ X, Y int }
 	ctxMenu    *qt.QMenu
 	menuHooked bool
+	zoom       zoomState   // digital zoom/pan (zoom.go)
+	ptzPad     *qt.QWidget // ONVIF pan/tilt/zoom buttons, shown on hover (ptz.go)
+	// no-signal placeholder
+	blankSince time.Time // first paint without a picture; zero once there is one
+	noSigImg   *qt.QImage
+	noSigPath  string // file noSigImg was loaded from
 }
 
 const (
@@ -60,6 +75,251 @@ const (
 	edgeBot   = 8
 )
 
+// uiScale is how much larger than on a 96 dpi screen hit margins and overlay
+// spacing should be for pd's screen. Qt 5 only raises the device pixel ratio
+// with high-DPI scaling on (always on macOS), and then logical pixels already
+// have that physical size; without it (Windows/X11 default) desktop scaling
+// shows up as a higher logical DPI instead. So logical DPI covers both.
+func uiScale(pd *qt.QPaintDevice) float64 {
+	base := 96.0
+	if runtime.GOOS == "darwin" {
+		base = 72 // points; Retina is in the device pixel ratio
+	}
+	if s := float64(pd.LogicalDpiX()) / base; s > 1 {
+		return s
+	}
+	return 1
+}
+
+// scalePx converts a length in 96-dpi pixels to pd's logical pixels.
+func scalePx(pd *qt.QPaintDevice, px int) int {
+	return int(math.Round(float64(px) * uiScale(pd)))
+}
+
+// px scales a 96-dpi overlay length for the screen the widget is on.
+func (w *VideoWidget) px(n int) int { return scalePx(w.QPaintDevice, n) }
+
+// drawConnState paints a centered "Reconnecting…" pill (with the retry
+// countdown) unless the owner's stream is connected; "Paused" when stopped.
+func (w *VideoWidget) drawConnState(p *qt.QPainter) {
+	if w.owner == nil {
+		return
+	}
+	var txt string
+	col := qt.NewQColor11(255, 255, 255, 230)
+	if w.owner.IsPaused() {
+		w.drawPill(p, "Paused", col)
+		return
+	}
+	switch w.owner.connState() {
+	case connConnected:
+		return
+	case connConnecting:
+		txt = "Connecting…"
+	case connDetecting:
+		txt = "Detecting stream…"
+	case connReconnecting:
+		txt = "Reconnecting…"
+	case connUnreachable:
+		txt = "Unreachable"
+		col = qt.NewQColor11(255, 120, 120, 230)
+	case connUnsupported:
+		codec := "this codec"
+		if c := w.owner.badCodec.Load(); c != nil {
+			codec = *c
+		}
+		// retried rarely (Reload retries now): no countdown
+		w.drawPill(p, "Unsupported codec: no "+codec+" decoder in this FFmpeg build", qt.NewQColor11(255, 120, 120, 230))
+		return
+	}
+	if d := w.owner.retryIn(); d > 0 && w.owner.connState() != connConnecting {
+		txt += fmt.Sprintf(" (retry in %ds)", int(d.Round(time.Second)/time.Second))
+	}
+	w.drawPill(p, txt, col)
+}
+
+// staleFrame reports whether the picture on show is left over from a stream
+// that is down (not connected, not paused), and whether it has been up longer
+// than stale_frame_timeout and should give way to black.
+func (w *VideoWidget) staleFrame() (stale, expired bool) {
+	if w.owner == nil || w.owner.IsPaused() || w.owner.connState() == connConnected {
+		return false, false
+	}
+	limit := time.Duration(globalConfig.StaleFrameSecs) * time.Second
+	return true, limit > 0 && w.buf.age() > limit
+}
+
+// noSignalDelay is how long a camera has to be without a picture before the
+// no-signal placeholder replaces the plain black background.
+const noSignalDelay = 3 * time.Second
+
+// drawNoSignal paints the configured no-signal background, image and text
+// once the widget has shown no picture for noSignalDelay. The connection
+// pill still goes on top, so the text sits above the center.
+func (w *VideoWidget) drawNoSignal(p *qt.QPainter) {
+	if w.blankSince.IsZero() {
+		w.blankSince = time.Now()
+	}
+	if time.Since(w.blankSince) < noSignalDelay {
+		return
+	}
+	if c := qt.NewQColor6(globalConfig.NoSignalColor); globalConfig.NoSignalColor != "" && c.IsValid() {
+		p.FillRect6(w.Rect(), c)
+	}
+	if img := w.noSignalImage(); img != nil {
+		iw, ih := float64(img.Width()), float64(img.Height())
+		s := math.Min(float64(w.Width())/iw, float64(w.Height())/ih)
+		dw, dh := iw*s, ih*s
+		dst := qt.NewQRectF4((float64(w.Width())-dw)/2, (float64(w.Height())-dh)/2, dw, dh)
+		p.DrawImage(dst, img, qt.NewQRectF4(0, 0, iw, ih))
+	}
+	if txt := globalConfig.NoSignalText; txt != "" {
+		p.Save()
+		f := qt.NewQFont5(p.Font())
+		if ps := f.PointSize(); ps > 0 {
+			f.SetPointSize(ps * 2)
+		}
+		f.SetBold(true)
+		p.SetFont(f)
+		p.SetPenWithPen(qt.NewQPen3(qt.NewQColor11(255, 255, 255, 220)))
+		p.DrawText6(qt.NewQRect4(0, 0, w.Width(), w.Height()/2-w.px(16)), int(qt.AlignHCenter|qt.AlignBottom), txt)
+		p.Restore()
+	}
+}
+
+// noSignalImage returns the no_signal_image picture, loading it again when
+// the setting changes; nil when unset or unreadable.
+func (w *VideoWidget) noSignalImage() *qt.QImage {
+	path := globalConfig.NoSignalImage
+	if path != w.noSigPath {
+		w.noSigPath = path
+		if w.noSigImg != nil {
+			w.noSigImg.Delete()
+			w.noSigImg = nil
+		}
+		if path != "" {
+			if img := qt.NewQImage8(path); !img.IsNull() {
+				w.noSigImg = img
+			} else {
+				log.Printf("no-signal image %q: cannot load", path)
+			}
+		}
+	}
+	return w.noSigImg
+}
+
+// drawBitrateWarning flags a camera whose input has stayed above its
+// expected max bitrate, top-center so it clears the title and health chip.
+func (w *VideoWidget) drawBitrateWarning(p *qt.QPainter) {
+	if w.owner == nil || !w.owner.bitrateHigh || w.owner.connState() != connConnected {
+		return
+	}
+	txt := fmt.Sprintf("High bitrate: %.0f kbps (limit %d)", w.owner.bitrateKbps, w.owner.maxKbps())
+	fm := qt.NewQFontMetrics(p.Font())
+	pillW := fm.BoundingRectWithText(txt).Width() + w.px(24)
+	pillH := fm.Height() + w.px(8)
+	rect := qt.NewQRect4((w.Width()-pillW)/2, w.px(6), pillW, pillH)
+	p.FillRect6(rect, qt.NewQColor11(0, 0, 0, 170))
+	p.SetPenWithPen(qt.NewQPen3(qt.NewQColor11(255, 170, 0, 240)))
+	p.DrawText6(rect, int(qt.AlignCenter), txt)
+}
+
+// drawPill paints txt centered on a translucent dark pill.
+func (w *VideoWidget) drawPill(p *qt.QPainter, txt string, col *qt.QColor) {
+	fm := qt.NewQFontMetrics(p.Font())
+	pillW := fm.BoundingRectWithText(txt).Width() + w.px(24)
+	pillH := fm.Height() + w.px(12)
+	rect := qt.NewQRect4((w.Width()-pillW)/2, (w.Height()-pillH)/2, pillW, pillH)
+	p.FillRect6(rect, qt.NewQColor11(0, 0, 0, 170))
+	p.SetPenWithPen(qt.NewQPen3(col))
+	p.DrawText6(rect, int(qt.AlignCenter), txt)
+}
+
+// drawAudioMeter paints a small horizontal level bar (peak, dBFS scale from
+// -60 to 0) bottom-left, ending above y; the audio status instead when
+// nothing is decoded, and "muted" next to the bar while muted.
+func (w *VideoWidget) drawAudioMeter(p *qt.QPainter, bottom int) {
+	mw, mh := w.px(120), w.px(10)
+	x, y := w.px(8), bottom-mh-w.px(4)
+	status := w.owner.audioStatus()
+	level, ok := w.owner.audioLevel()
+	if !ok {
+		if status == "" || status == "playing" {
+			status = "no audio"
+		}
+		p.SetPenWithPen(qt.NewQPen3(qt.NewQColor11(255, 255, 255, 160)))
+		p.DrawText2(qt.NewQPoint2(x, y+mh), status)
+		return
+	}
+	if status == "muted" {
+		p.SetPenWithPen(qt.NewQPen3(qt.NewQColor11(255, 255, 255, 160)))
+		p.DrawText2(qt.NewQPoint2(x+mw+w.px(6), y+mh), "muted")
+	}
+	p.FillRect6(qt.NewQRect4(x, y, mw, mh), qt.NewQColor11(0, 0, 0, 150))
+	frac := 0.0
+	if level > 0 {
+		frac = (20*math.Log10(level) + 60) / 60
+		frac = math.Max(0, math.Min(1, frac))
+	}
+	col := qt.NewQColor11(0, 200, 120, 230) // green
+	switch {
+	case frac > 0.95: // above -3 dBFS: clipping territory
+		col = qt.NewQColor11(220, 0, 0, 230)
+	case frac > 0.8: // above -12 dBFS
+		col = qt.NewQColor11(220, 180, 0, 230)
+	}
+	if fw := int(frac * float64(mw-2)); fw > 0 {
+		p.FillRect6(qt.NewQRect4(x+1, y+1, fw, mh-2), col)
+	}
+}
+
+// drawActiveBorder outlines the active camera (env.activeWin), so it is clear
+// which window the SPACE record hotkey targets. By default only in borderless
+// mode, where there is no title bar to show focus.
+func (w *VideoWidget) drawActiveBorder(p *qt.QPainter) {
+	if w.owner == nil || w.owner != env.activeWin {
+		return
+	}
+	switch globalConfig.ActiveBorder {
+	case "never":
+		return
+	case "always":
+	default:
+		if !w.isFramelessActive() {
+			return
+		}
+	}
+	col := qt.NewQColor11(0, 150, 255, 230)
+	if c := qt.NewQColor6(globalConfig.ActiveBorderColor); globalConfig.ActiveBorderColor != "" && c.IsValid() {
+		col = c
+	}
+	bw := w.px(3)
+	pen := qt.NewQPen3(col)
+	pen.SetWidth(bw)
+	p.SetPenWithPen(pen)
+	p.SetBrushWithStyle(qt.NoBrush)
+	p.DrawRect2(bw/2, bw/2, w.Width()-bw, w.Height()-bw)
+}
+
+// Default and floor of the camera window's minimum size (min_window_w/h).
+const (
+	defaultMinWindowW, defaultMinWindowH = 200, 140
+	minWindowFloor                       = 32
+)
+
+// minWindowSize is the smallest a camera's video area may get, from the
+// settings; it applies to the widget and to the frameless drag-resize.
+func minWindowSize() (w, h int) {
+	w, h = globalConfig.MinWindowW, globalConfig.MinWindowH
+	if w <= 0 {
+		w = defaultMinWindowW
+	}
+	if h <= 0 {
+		h = defaultMinWindowH
+	}
+	return max(w, minWindowFloor), max(h, minWindowFloor)
+}
+
 func NewVideoWidget(buf *frameBuf, parent *qt.QWidget, stretch bool) *VideoWidget {
 	w := &VideoWidget{
 		QWidget: qt.NewQWidget(parent),
@@ -71,20 +331,13 @@ func NewVideoWidget(buf *frameBuf, parent *qt.QWidget, stretch bool) *VideoWidge
 	w.titleLbl.SetParent(w.QWidget)
 	w.titleLbl.SetText("") // set later via SetOverlayTitle
 	// readable on any video; can be tweaked
-	w.titleLbl.SetStyleSheet(
-		"color: rgba(255,255,255,0.97);" +
-			"background: rgba(0,0,0,0.55);" +
-			"padding: 2px 8px;" +
-			"border-radius: 6px;" +
-			"font-size: 11pt;",
-	)
+	w.styleTitle()
 	// Don't intercept mouse (so drag/resize still works if you have it)
 	w.titleLbl.SetAttribute2(qt.WA_TransparentForMouseEvents, true)
 	w.titleLbl.Hide()
 
 	w.SetAttribute2(qt.WA_OpaquePaintEvent, true)
 	w.SetAutoFillBackground(false)
-	w.SetMinimumSize2(32, 32)
 
 	w.OnPaintEvent(func(super func(event *qt.QPaintEvent), event *qt.QPaintEvent) {
 		p := qt.NewQPainter2(w.QPaintDevice)
@@ -94,10 +347,16 @@ func NewVideoWidget(buf *frameBuf, parent *qt.QWidget, stretch bool) *VideoWidge
 
 		// latest frame
 		seq, srcW, srcH, data := w.buf.get()
-		if seq == 0 || srcW <= 0 || srcH <= 0 || len(data) < srcW*srcH*4 {
+		stale, expired := w.staleFrame()
+		if seq == 0 || srcW <= 0 || srcH <= 0 || len(data) < srcW*srcH*4 || expired {
+			w.drawNoSignal(p)
+			w.drawConnState(p)
+			w.drawActiveBorder(p)
 			return
 		}
 
+		w.blankSince = time.Time{}
+
 		// Build a QImage that we own (Format_RGB32 == 4 bytes/pixel, BGRA layout on little-endian)
 		img := qt.NewQImage3(srcW, srcH, qt.QImage__Format_RGB32)
 		defer img.Delete()
@@ -114,41 +373,84 @@ func NewVideoWidget(buf *frameBuf, parent *qt.QWidget, stretch bool) *VideoWidge
 			return
 		}
 
+		// aspect override fixes sources with a wrong SAR
+		viewW, viewH := srcW, srcH
+		if w.Aspect > 0 {
+			viewW = int(float64(srcH)*w.Aspect + 0.5)
+		}
+		// a quarter turn swaps the picture's on-screen width/height
+		if w.Rotate == 90 || w.Rotate == 270 {
+			viewW, viewH = viewH, viewW
+		}
+
+		stretch := w.Stretch
+		if top := w.QWidget.Window(); stretch && w.FullscreenAspect && top != nil && top.IsFullScreen() {
+			stretch = false
+		}
 		var dest *qt.QRect
-		if w.Stretch {
+		if stretch {
 			// fill widget (may distort)
 			dest = qt.NewQRect4(0, 0, dstW, dstH)
 		} else {
 			// keep aspect (letterbox/pillarbox)
-			sx := float64(dstW) / float64(srcW)
-			sy := float64(dstH) / float64(srcH)
+			sx := float64(dstW) / float64(viewW)
+			sy := float64(dstH) / float64(viewH)
 			s := sx
 			if sy < s {
 				s = sy
 			}
-			outW := int(float64(srcW)*s + 0.5)
-			outH := int(float64(srcH)*s + 0.5)
+			outW := int(float64(viewW)*s + 0.5)
+			outH := int(float64(viewH)*s + 0.5)
 			offX := (dstW - outW) / 2
 			offY := (dstH - outH) / 2
 			dest = qt.NewQRect4(offX, offY, outW, outH)
 		}
 
-		srcRect := qt.NewQRect4(0, 0, srcW, srcH)
+		w.zoom.dest = dest
+		srcRect := w.zoomSource(srcW, srcH)
 		p.SetRenderHint2(qt.QPainter__SmoothPixmapTransform, true)
-		p.DrawImage2(dest, img, srcRect)
+		if w.Rotate == 0 && !w.FlipH && !w.FlipV {
+			p.DrawImage(qt.NewQRectF5(dest), img, srcRect)
+		} else {
+			// rotate/mirror around the centre of the destination rect
+			p.Save()
+			p.Translate2(float64(dest.X())+float64(dest.Width())/2, float64(dest.Y())+float64(dest.Height())/2)
+			p.Rotate(float64(w.Rotate))
+			sx, sy := 1.0, 1.0
+			if w.FlipH {
+				sx = -1
+			}
+			if w.FlipV {
+				sy = -1
+			}
+			p.Scale(sx, sy)
+			outW, outH := dest.Width(), dest.Height()
+			if w.Rotate == 90 || w.Rotate == 270 {
+				outW, outH = outH, outW
+			}
+			p.DrawImage(qt.NewQRectF4(float64(-outW/2), float64(-outH/2), float64(outW), float64(outH)), img, srcRect)
+			p.Restore()
+		}
 		// --- overlays ---
+		// stale frame of a dropped stream: dim it and say so on top of it
+		if stale {
+			p.FillRect6(w.Rect(), qt.NewQColor11(0, 0, 0, 110))
+		}
+		w.drawConnState(p)
+		w.drawBitrateWarning(p)
 		if w.owner != nil {
+			cfg := &w.owner.cfg
 			// 4.a) Health chip (0–5), top-left under the title
-			if globalConfig.HealthChip {
+			if cfg.showOverlay(ovHealthChip) {
 				_, _, _, _, health := w.owner.MetricsSnapshot()
 				// chip geometry
-				const pad = 8
-				const chipH = 22
-				barW, gap := 12, 2
+				pad := w.px(8)
+				chipH := w.px(22)
+				barW, gap := w.px(12), w.px(2)
 				chipW := pad + 5*barW + 4*gap + pad // total width = L pad + bars + gaps + R pad
 				// place at top-right
-				x := w.Width() - chipW - 2
-				y := 2
+				x := w.Width() - chipW - w.px(2)
+				y := w.px(2)
 
 				// background
 				bg := qt.NewQColor11(0, 0, 0, 160)
@@ -157,15 +459,15 @@ func NewVideoWidget(buf *frameBuf, parent *qt.QWidget, stretch bool) *VideoWidge
 				p.SetPenWithPen(pen)
 				p.SetBrush(qt.NewQBrush11(bg, qt.SolidPattern))
 				rect := qt.NewQRect4(x, y, chipW, chipH)
-				p.DrawRoundedRect3(rect, 6, 6)
+				p.DrawRoundedRect3(rect, float64(w.px(6)), float64(w.px(6)))
 
 				// 5 mini-bars (2px gap)
 				filled := health
 				for i := 0; i < 5; i++ {
 					bx := x + pad + i*(barW+gap)
-					by := y + 4
+					by := y + w.px(4)
 					bw := barW
-					bh := chipH - 8
+					bh := chipH - w.px(8)
 					col := qt.NewQColor11(80, 80, 80, 220) // off
 					if i < filled {
 						// greenish gradient by level
@@ -187,20 +489,25 @@ func NewVideoWidget(buf *frameBuf, parent *qt.QWidget, stretch bool) *VideoWidge
 			}
 
 			// 4.b) Stats text (bottom-left)
-			if globalConfig.ShowFPS || globalConfig.ShowBitrate || globalConfig.ShowDrops || globalConfig.ShowCPUUsage {
+			statsTop := w.Height() - w.px(8) // stream info and the audio meter stack above the pill
+			if cfg.showOverlay(ovFPS) || cfg.showOverlay(ovBitrate) || cfg.showOverlay(ovDrops) || cfg.showOverlay(ovCPU) {
 				fps, kbps, drops, _, _ := w.owner.MetricsSnapshot()
 				parts := []string{}
-				if globalConfig.ShowFPS {
-					parts = append(parts, fmt.Sprintf("FPS: %.1f", fps))
+				if cfg.showOverlay(ovFPS) {
+					if capFPS := w.owner.maxFPS(); capFPS > 0 {
+						parts = append(parts, fmt.Sprintf("FPS: %.1f (cap %d)", fps, capFPS))
+					} else {
+						parts = append(parts, fmt.Sprintf("FPS: %.1f", fps))
+					}
 				}
-				if globalConfig.ShowBitrate {
-					parts = append(parts, fmt.Sprintf("Bitrate: %.1f kbps", kbps))
+				if cfg.showOverlay(ovBitrate) {
+					parts = append(parts, fmt.Sprintf("Bitrate: %.1f kbps (peak %.0f)", kbps, w.owner.peakKbps))
 				}
-				if globalConfig.ShowDrops {
+				if cfg.showOverlay(ovDrops) {
 					parts = append(parts, fmt.Sprintf("Drops: %.1f%%", drops))
 				}
 
-				if globalConfig.ShowCPUUsage {
+				if cfg.showOverlay(ovCPU) {
 					parts = append(parts, fmt.Sprintf("CPU: %.0f%%", w.owner.cpuPct))
 				}
 
@@ -208,28 +515,45 @@ func NewVideoWidget(buf *frameBuf, parent *qt.QWidget, stretch bool) *VideoWidge
 					txt := strings.Join(parts, "  |  ")
 					// measure and draw a pill background
 					fm := qt.NewQFontMetrics(p.Font())
-					tw := fm.BoundingRectWithText(txt).Width() + 16
-					th := fm.Height() + 8
-					x := 8
-					y := w.Height() - th - 8
+					tw := fm.BoundingRectWithText(txt).Width() + w.px(16)
+					th := fm.Height() + w.px(8)
+					x := w.px(8)
+					y := w.Height() - th - w.px(8)
 					p.FillRect6(qt.NewQRect4(x, y, tw, th), qt.NewQColor11(0, 0, 0, 150))
 					p.SetPenWithPen(qt.NewQPen3(qt.NewQColor11(255, 255, 255, 230)))
-					p.DrawText2(qt.NewQPoint2(x+8, y+th-8), txt)
+					p.DrawText2(qt.NewQPoint2(x+w.px(8), y+th-w.px(8)), txt)
+					statsTop = y
 				}
 			}
+			if info := w.owner.streamInfo.Load(); cfg.showOverlay(ovStreamInfo) && info != nil {
+				fm := qt.NewQFontMetrics(p.Font())
+				tw := fm.BoundingRectWithText(*info).Width() + w.px(16)
+				th := fm.Height() + w.px(8)
+				x, y := w.px(8), statsTop-th-w.px(4)
+				p.FillRect6(qt.NewQRect4(x, y, tw, th), qt.NewQColor11(0, 0, 0, 150))
+				p.SetPenWithPen(qt.NewQPen3(qt.NewQColor11(255, 255, 255, 230)))
+				p.DrawText2(qt.NewQPoint2(x+w.px(8), y+th-w.px(8)), *info)
+				statsTop = y
+			}
+			if cfg.showOverlay(ovAudioMeter) {
+				w.drawAudioMeter(p, statsTop)
+			}
 		}
 
 		// --- Recording pill (bottom-right) ---
 		if w.owner != nil && w.owner.IsRecording() {
 			txt := "Recording"
+			if w.owner.recLine != "" {
+				txt = w.owner.recLine
+			}
 
 			fm := qt.NewQFontMetrics(p.Font())
 			textRect := fm.BoundingRectWithText(txt)
-			pillW := textRect.Width() + 16
-			pillH := textRect.Height() + 10
+			pillW := textRect.Width() + w.px(16)
+			pillH := textRect.Height() + w.px(10)
 
 			// Place at bottom-right with small margin
-			margin := 8
+			margin := w.px(8)
 			x := w.Width() - pillW - margin
 			y := w.Height() - pillH - margin
 
@@ -242,11 +566,12 @@ func NewVideoWidget(buf *frameBuf, parent *qt.QWidget, stretch bool) *VideoWidge
 			p.SetPenWithPen(pen)
 
 			// Baseline: a bit above bottom of pill
-			textX := x + 8
-			textY := y + pillH - fm.Descent() - 2
+			textX := x + w.px(8)
+			textY := y + pillH - fm.Descent() - w.px(2)
 			p.DrawText2(qt.NewQPoint2(textX, textY), txt)
 		}
 
+		w.drawActiveBorder(p)
 	})
 	w.SetMouseTracking(true) // track hover to update resize cursor
 
@@ -273,24 +598,19 @@ func NewVideoWidget(buf *frameBuf, parent *qt.QWidget, stretch bool) *VideoWidge
 
 	// Mouse press: enter move/resize mode
 	w.OnMousePressEvent(func(super func(event *qt.QMouseEvent), ev *qt.QMouseEvent) {
+		if w.startPan(ev) {
+			ev.Accept()
+			return
+		}
 		if ev.Button() != qt.LeftButton || !w.isFramelessActive() {
 			super(ev)
 			return
 		}
 
-		env.activeWin = w.owner
+		setActiveWin(w.owner)
 
-		if globalConfig.ActiveOnWin && !w.IsFullScreen() {
-			log.Printf("focus frameless activated")
-			for _, w := range wins {
-				if w == nil {
-					continue
-				}
-				log.Printf("Activating window: %s", w.cfg.Name)
-				w.win.Raise()
-				w.win.ActivateWindow()
-				w.win.SetFocus()
-			}
+		if !w.IsFullScreen() {
+			raiseOnClick(w.owner)
 		}
 
 		log.Printf("active window set to: %s", env.activeWin.cfg.Name)
@@ -320,23 +640,23 @@ func NewVideoWidget(buf *frameBuf, parent *qt.QWidget, stretch bool) *VideoWidge
 		} else {
 			w.dragging = true
 			w.SetCursor(qt.NewQCursor2(qt.SizeAllCursor))
-			if w.snapActive() {
-				w.buildGroup() // only build glue group when enabled
-			} else {
-				w.group = nil
-				w.groupPos = nil
-			}
+			w.buildGroup() // explicit glue group, or touching windows when snapping is on
 		}
 		ev.Accept()
 	})
 
 	// update geometry or cursor
 	w.OnMouseMoveEvent(func(super func(event *qt.QMouseEvent), ev *qt.QMouseEvent) {
+		if w.zoom.panning {
+			w.panTo(ev)
+			ev.Accept()
+			return
+		}
 		if !w.isFramelessActive() {
 			super(ev)
 			return
 		}
-		if DEBUG {
+		if debugLogging() {
 			log.Printf("[%s] frameless window moved to %dx%d", w.owner.cfg.Name, ev.Pos().X(), ev.Pos().Y())
 		}
 
@@ -360,14 +680,10 @@ func NewVideoWidget(buf *frameBuf, parent *qt.QWidget, stretch bool) *VideoWidge
 		dx := gp.X() - w.pressGX
 		dy := gp.Y() - w.pressGY
 
-		// Min sizes (respect your current min; widget has 32x32, use window min if set)
-		minW, minH := 160, 120
-		if top.MinimumSize().Width() > 0 {
-			minW = top.MinimumSize().Width()
-		}
-		if top.MinimumSize().Height() > 0 {
-			minH = top.MinimumSize().Height()
-		}
+		// Min sizes: the configured one (minWindowSize), or the window's own if larger
+		minW, minH := minWindowSize()
+		minW = max(minW, top.MinimumSize().Width())
+		minH = max(minH, top.MinimumSize().Height())
 
 		nx, ny := w.origX, w.origY
 		nw, nh := w.origW, w.origH
@@ -409,11 +725,16 @@ func NewVideoWidget(buf *frameBuf, parent *qt.QWidget, stretch bool) *VideoWidge
 		// Apply geometry
 		top.SetGeometry(nx, ny, nw, nh)
 
-		if w.snapActive() {
+		if w.snapActive() || len(w.group) > 1 {
 			// snap the lead window, then offset the group by the same delta
-			nx, ny = w.snapXY(nx, ny, w.origW, w.origH)
-			sdx := nx - (w.origX + dx)
-			sdy := ny - (w.origY + dy)
+			sdx, sdy := 0, 0
+			if w.snapActive() {
+				var guides []guideLine
+				nx, ny, guides = w.snapXY(nx, ny, w.origW, w.origH)
+				showSnapGuides(guides)
+				sdx = nx - (w.origX + dx)
+				sdy = ny - (w.origY + dy)
+			}
 
 			// move glued group (if any); always include owner
 			moved := false
@@ -430,6 +751,7 @@ func NewVideoWidget(buf *frameBuf, parent *qt.QWidget, stretch bool) *VideoWidge
 				w.owner.win.Move(nx, ny)
 			}
 		} else {
+			hideSnapGuides()
 			if w.owner != nil && w.owner.win != nil {
 				w.owner.win.Move(nx, ny)
 			}
@@ -439,8 +761,14 @@ func NewVideoWidget(buf *frameBuf, parent *qt.QWidget, stretch bool) *VideoWidge
 
 	// Mouse release: leave move/resize mode
 	w.OnMouseReleaseEvent(func(super func(event *qt.QMouseEvent), ev *qt.QMouseEvent) {
+		if w.zoom.panning {
+			w.endPan()
+			ev.Accept()
+			return
+		}
 		if (w.dragging || w.resizing) && ev.Button() == qt.LeftButton {
 			w.dragging, w.resizing = false, false
+			hideSnapGuides()
 			w.edgeMask = 0
 			w.UnsetCursor()
 			w.group = nil
@@ -451,17 +779,29 @@ func NewVideoWidget(buf *frameBuf, parent *qt.QWidget, stretch bool) *VideoWidge
 		super(ev)
 	})
 
+	// wheel: digital zoom around the cursor
+	w.OnWheelEvent(func(super func(event *qt.QWheelEvent), ev *qt.QWheelEvent) {
+		steps := float64(ev.AngleDelta().Y()) / 120
+		if steps == 0 {
+			super(ev)
+			return
+		}
+		w.zoomAt(math.Pow(zoomWheelStep, steps), ev.Pos().X(), ev.Pos().Y())
+		ev.Accept()
+	})
+
 	// keep the label placed at top-left on resize
 	w.OnResizeEvent(func(super func(*qt.QResizeEvent), ev *qt.QResizeEvent) {
 		super(ev)
 		if w.titleLbl != nil && w.titleLbl.IsVisible() {
-			const margin = 8
+			margin := w.px(8)
 			w.titleLbl.Move(margin, margin)
 		}
+		w.placePTZPad()
 	})
 	// ensure the central content can force the window to be visible-sized
 	w.SetSizePolicy2(qt.QSizePolicy__Expanding, qt.QSizePolicy__Expanding)
-	w.SetMinimumSize2(200, 140) // ~ 16:9-ish; adjust if needed
+	w.SetMinimumSize2(minWindowSize()) // Settings → Minimum window size
 
 	return w
 }
@@ -476,7 +816,9 @@ func (w *VideoWidget) SetOverlayTitle(text string, visible bool) {
 	}
 	w.titleLbl.SetText(text)
 	if visible {
-		const margin = 8
+		margin := w.px(8)
+		// the window may have moved to a screen with other scaling
+		w.styleTitle()
 		w.titleLbl.AdjustSize() // fit content
 		w.titleLbl.Move(margin, margin)
 		w.titleLbl.Show()
@@ -486,8 +828,39 @@ func (w *VideoWidget) SetOverlayTitle(text string, visible bool) {
 	}
 }
 
+// styleTitle styles the overlay title label, readable on any video; padding
+// and corners follow the screen scaling, the font size is in points anyway.
+func (w *VideoWidget) styleTitle() {
+	w.titleLbl.SetStyleSheet(fmt.Sprintf(
+		"color: rgba(255,255,255,0.97);"+
+			"background: rgba(0,0,0,0.55);"+
+			"padding: %dpx %dpx;"+
+			"border-radius: %dpx;"+
+			"font-size: 11pt;",
+		w.px(2), w.px(8), w.px(6)))
+}
+
 func (w *VideoWidget) SetOwner(cw *CamWindow) { w.owner = cw }
 
+// SetTransform sets the display rotation (degrees) and mirroring, then repaints.
+func (w *VideoWidget) SetTransform(rotate int, flipH, flipV bool) {
+	if w == nil {
+		return
+	}
+	w.Rotate = normalizeRotation(rotate)
+	w.FlipH, w.FlipV = flipH, flipV
+	w.Update()
+}
+
+// SetAspectRatio overrides the aspect used for letterboxing ("auto" = source), then repaints.
+func (w *VideoWidget) SetAspectRatio(ratio string) {
+	if w == nil {
+		return
+	}
+	w.Aspect = parseAspectRatio(ratio)
+	w.Update()
+}
+
 func (w *VideoWidget) isFramelessActive() bool {
 	top := w.QWidget.Window()
 	if top == nil {
@@ -496,11 +869,14 @@ func (w *VideoWidget) isFramelessActive() bool {
 	if !globalConfig.NoWindowsTitles {
 		return false
 	} // only in borderless mode
+	if w.owner != nil && w.owner.cfg.ClickThrough {
+		return false // clicks belong to the windows below: no drag/resize
+	}
 	return !top.IsFullScreen()
 }
 
 func (w *VideoWidget) hitEdges(px, py int) int {
-	const m = 8
+	m := w.px(8)
 	r := w.Rect()
 	mask := 0
 	if px <= m {
@@ -519,46 +895,55 @@ func (w *VideoWidget) hitEdges(px, py int) int {
 }
 
 func (w *VideoWidget) buildGroup() {
-	if !w.snapActive() {
-		// no-op glue when disabled
-		w.group = nil
-		w.groupPos = nil
-		return
-	}
 	w.group = nil
 	w.groupPos = map[*CamWindow]struct{ X, Y int }{}
 	if w.owner == nil || w.owner.win == nil {
 		return
 	}
-	if !w.isFramelessActive() {
-		return
+	if !w.isFramelessActive() || altPressed() {
+		return // Holding Alt moves the window alone
 	}
 
-	// quick accessors
-	getRect := func(cw *CamWindow) (x, y, rw, rh int) {
+	members := w.owner.glueMembers() // explicit glue wins over geometry
+	if members == nil {
+		if !w.snapActive() {
+			return // no-op glue when disabled
+		}
+		members = touchingCluster(w.owner, 1)
+	}
+	for _, cw := range members {
 		g := cw.win.Geometry()
-		return g.X(), g.Y(), g.Width(), g.Height()
-	}
-	touching := func(a, b *CamWindow) bool {
-		if a == nil || b == nil || a == b || a.win == nil || b.win == nil {
-			return false
-		}
-		ax, ay, aw, ah := getRect(a)
-		bx, by, bw, bh := getRect(b)
-		const tol = 1
-		// edges aligned and ranges overlap
-		ax2, ay2 := ax+aw, ay+ah
-		bx2, by2 := bx+bw, by+bh
-		overlapX := !(ax2 <= bx || bx2 <= ax)
-		overlapY := !(ay2 <= by || by2 <= ay)
-		return (abs(ax2-bx) <= tol && overlapY) || // a right to b left
-			(abs(bx2-ax) <= tol && overlapY) || // a left to b right
-			(abs(ay2-by) <= tol && overlapX) || // a bottom to b top
-			(abs(by2-ay) <= tol && overlapX) // a top to b bottom
+		w.group = append(w.group, cw)
+		w.groupPos[cw] = struct{ X, Y int }{g.X(), g.Y()}
 	}
+}
 
+// windowsTouch reports whether two windows share an edge (within tol px)
+// with overlapping ranges.
+func windowsTouch(a, b *CamWindow, tol int) bool {
+	if a == nil || b == nil || a == b || a.win == nil || b.win == nil {
+		return false
+	}
+	ga, gb := a.win.Geometry(), b.win.Geometry()
+	ax, ay, aw, ah := ga.X(), ga.Y(), ga.Width(), ga.Height()
+	bx, by, bw, bh := gb.X(), gb.Y(), gb.Width(), gb.Height()
+	// edges aligned and ranges overlap
+	ax2, ay2 := ax+aw, ay+ah
+	bx2, by2 := bx+bw, by+bh
+	overlapX := !(ax2 <= bx || bx2 <= ax)
+	overlapY := !(ay2 <= by || by2 <= ay)
+	return (abs(ax2-bx) <= tol && overlapY) || // a right to b left
+		(abs(bx2-ax) <= tol && overlapY) || // a left to b right
+		(abs(ay2-by) <= tol && overlapX) || // a bottom to b top
+		(abs(by2-ay) <= tol && overlapX) // a top to b bottom
+}
+
+// touchingCluster returns from and every window connected to it through
+// touching edges (within tol px), from first.
+func touchingCluster(from *CamWindow, tol int) []*CamWindow {
+	var out []*CamWindow
 	visited := map[*CamWindow]bool{}
-	queue := []*CamWindow{w.owner}
+	queue := []*CamWindow{from}
 	for len(queue) > 0 {
 		cur := queue[0]
 		queue = queue[1:]
@@ -566,9 +951,7 @@ func (w *VideoWidget) buildGroup() {
 			continue
 		}
 		visited[cur] = true
-		w.group = append(w.group, cur)
-		x, y, _, _ := getRect(cur)
-		w.groupPos[cur] = struct{ X, Y int }{x, y}
+		out = append(out, cur)
 		// enqueue neighbors that touch cur
 		for _, other := range wins {
 			if other == nil || other.win == nil || other.isFullscreen {
@@ -577,62 +960,12 @@ func (w *VideoWidget) buildGroup() {
 			if other == cur {
 				continue
 			}
-			if touching(cur, other) {
+			if windowsTouch(cur, other, tol) {
 				queue = append(queue, other)
 			}
 		}
 	}
-}
-
-func (w *VideoWidget) snapXY(x, y, ww, wh int) (int, int) {
-	if !w.isFramelessActive() {
-		return x, y
-	}
-	if !w.snapActive() {
-		return x, y
-	}
-
-	const snap = 12 // px
-	bestDx, bestDy := 0, 0
-	bestAbsX, bestAbsY := snap+1, snap+1 // “no snap” unless closer than snap
-
-	// snap against all OTHER windows not in the current group
-	inGroup := map[*CamWindow]bool{}
-	for _, g := range w.group {
-		inGroup[g] = true
-	}
-
-	for _, other := range wins {
-		if other == nil || other.win == nil || other.isFullscreen {
-			continue
-		}
-		if inGroup[other] {
-			continue
-		}
-		og := other.win.Geometry()
-		ox, oy, ow, oh := og.X(), og.Y(), og.Width(), og.Height()
-
-		// Candidate deltas for horizontal snap (left/right)
-		// my left to other right
-		if d := x - (ox + ow); abs(d) < bestAbsX && abs(d) <= snap {
-			bestAbsX, bestDx = abs(d), -d
-		}
-		// my right to other left
-		if d := (x + ww) - ox; abs(d) < bestAbsX && abs(d) <= snap {
-			bestAbsX, bestDx = abs(d), -d
-		}
-
-		// Vertical (top/bottom)
-		// my top to other bottom
-		if d := y - (oy + oh); abs(d) < bestAbsY && abs(d) <= snap {
-			bestAbsY, bestDy = abs(d), -d
-		}
-		// my bottom to other top
-		if d := (y + wh) - oy; abs(d) < bestAbsY && abs(d) <= snap {
-			bestAbsY, bestDy = abs(d), -d
-		}
-	}
-	return x + bestDx, y + bestDy
+	return out
 }
 
 func (w *VideoWidget) snapActive() bool {
@@ -671,6 +1004,11 @@ func (v *VideoWidget) SetContextMenu(menu *qt.QMenu) {
 			return
 		}
 		global := v.QWidget.MapToGlobal(pos)
+		if v.owner != nil {
+			// per-camera actions on top of the shared tray menu
+			v.owner.contextMenu(v.ctxMenu).Popup(global)
+			return
+		}
 		v.ctxMenu.Popup(global)
 	})
 }
@@ -0,0 +1,87 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/mappu/miqt/qt"
+)
+
+/*
+Native Wayland clients can't place their own windows: the compositor ignores
+Move/SetGeometry positions and reports every window at 0,0. Saved positions
+and formations need XWayland there, which the user can opt into; natively,
+only sizes are saved so the config keeps its last good positions.
+*/
+
+// usingWayland reports whether Qt runs as a native Wayland client.
+var usingWayland = sync.OnceValue(func() bool {
+	return strings.HasPrefix(strings.ToLower(qt.QGuiApplication_PlatformName()), "wayland")
+})
+
+// applyWaylandPreference runs the app through XWayland when the user chose
+// to and the session is Wayland. Must run before the QApplication exists;
+// an explicit QT_QPA_PLATFORM always wins.
+func applyWaylandPreference() {
+	if os.Getenv("WAYLAND_DISPLAY") == "" || os.Getenv("QT_QPA_PLATFORM") != "" {
+		return
+	}
+	cfg, err := loadConfig(env.settingsFile)
+	if err != nil || !cfg.PreferXWayland {
+		return
+	}
+	log.Printf("Wayland session: using XWayland (prefer_xwayland) so window positions apply")
+	_ = os.Setenv("QT_QPA_PLATFORM", "xcb")
+}
+
+// warnWayland explains once why windows don't go where they were saved on
+// native Wayland, and offers to restart under XWayland. Main thread only.
+func warnWayland() {
+	if !usingWayland() || globalConfig.WaylandWarned {
+		return
+	}
+	globalConfig.WaylandWarned = true
+	ans := qt.QMessageBox_Question(nil, "Window positions on Wayland",
+		"This session runs the app as a native Wayland client, and Wayland doesn't let applications place their windows: "+
+			"saved positions and formations can't be applied (window sizes still are).\n\n"+
+			"Restart using XWayland so positions work? You can change this later under Settings → Use XWayland on Wayland.")
+	if ans == qt.QMessageBox__Yes {
+		globalConfig.PreferXWayland = true
+		_ = SaveConfig()
+		doRestart()
+		return
+	}
+	_ = SaveConfig()
+}
+
+// windowPos is the position to save for a camera window: the real one,
+// or on native Wayland (where it always reads 0,0) the last saved one.
+func (w *CamWindow) windowPos() (x, y int) {
+	if usingWayland() {
+		return w.cfg.X, w.cfg.Y
+	}
+	p := w.win.Pos()
+	return p.X(), p.Y()
+}
@@ -29,11 +29,14 @@ package main
 import "C"
 
 import (
+	"errors"
 	"log"
 	"sync"
+	"time"
 	"unsafe"
 
 	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
 )
 
 /*
@@ -42,16 +45,17 @@ Such as detect wake/sleep
 */
 
 var (
-	user32               = windows.NewLazySystemDLL("user32.dll")
-	kernel32             = windows.NewLazySystemDLL("kernel32.dll")
-	procRegisterClassExW = user32.NewProc("RegisterClassExW")
-	procCreateWindowExW  = user32.NewProc("CreateWindowExW")
-	procDefWindowProcW   = user32.NewProc("DefWindowProcW")
-	procGetMessageW      = user32.NewProc("GetMessageW")
-	procTranslateMessage = user32.NewProc("TranslateMessage")
-	procDispatchMessageW = user32.NewProc("DispatchMessageW")
-	procGetModuleHandleW = kernel32.NewProc("GetModuleHandleW")
-	HWND_MESSAGE         = windows.Handle(^uintptr(2))
+	user32                = windows.NewLazySystemDLL("user32.dll")
+	kernel32              = windows.NewLazySystemDLL("kernel32.dll")
+	procRegisterClassExW  = user32.NewProc("RegisterClassExW")
+	procCreateWindowExW   = user32.NewProc("CreateWindowExW")
+	procDefWindowProcW    = user32.NewProc("DefWindowProcW")
+	procGetMessageW       = user32.NewProc("GetMessageW")
+	procTranslateMessage  = user32.NewProc("TranslateMessage")
+	procDispatchMessageW  = user32.NewProc("DispatchMessageW")
+	procGetModuleHandleW  = kernel32.NewProc("GetModuleHandleW")
+	procGetProcessMemInfo = kernel32.NewProc("K32GetProcessMemoryInfo")
+	HWND_MESSAGE          = windows.Handle(^uintptr(2))
 )
 
 const (
@@ -97,15 +101,22 @@ func IgnoreSignum() {
 
 }
 
+// hideDockIcon: nothing to do here, the camera windows leave the taskbar
+// through their window type instead (see taskbarHidden).
+func hideDockIcon() {}
+
 func HandleSleep(wins []*CamWindow) {
 	log.Printf("Windows handle sleep function loaded...")
 	startWindowsPowerWatcher(func(kind string) {
 		// Only restart windows that are currently visible
 		if kind == "resume" {
 			log.Println("machine awake")
+			slot := 0
 			for _, w := range wins {
 				if w != nil && !w.cfg.Disabled && w.win.IsVisible() {
-					CallOnQtMain(w.OnResumeFromSleep)
+					n := slot
+					CallOnQtMain(func() { w.OnResumeFromSleep(n) })
+					slot++
 				}
 			}
 		}
@@ -186,3 +197,80 @@ func getModuleHandle() windows.Handle {
 	r, _, _ := procGetModuleHandleW.Call(0) // NULL => current module
 	return windows.Handle(r)
 }
+
+// PROCESS_MEMORY_COUNTERS
+type processMemoryCounters struct {
+	Cb                         uint32
+	PageFaultCount             uint32
+	PeakWorkingSetSize         uintptr
+	WorkingSetSize             uintptr
+	QuotaPeakPagedPoolUsage    uintptr
+	QuotaPagedPoolUsage        uintptr
+	QuotaPeakNonPagedPoolUsage uintptr
+	QuotaNonPagedPoolUsage     uintptr
+	PagefileUsage              uintptr
+	PeakPagefileUsage          uintptr
+}
+
+// processCPUTime returns user+kernel CPU time consumed by the whole process.
+func processCPUTime() time.Duration {
+	var creation, exit, kernel, user windows.Filetime
+	if err := windows.GetProcessTimes(windows.CurrentProcess(), &creation, &exit, &kernel, &user); err != nil {
+		return 0
+	}
+	// FILETIME counts 100ns ticks
+	ticks := (int64(kernel.HighDateTime)<<32 | int64(kernel.LowDateTime)) +
+		(int64(user.HighDateTime)<<32 | int64(user.LowDateTime))
+	return time.Duration(ticks * 100)
+}
+
+// processRSS returns the working set size in bytes.
+func processRSS() uint64 {
+	var pmc processMemoryCounters
+	pmc.Cb = uint32(unsafe.Sizeof(pmc))
+	r, _, _ := procGetProcessMemInfo.Call(uintptr(windows.CurrentProcess()), uintptr(unsafe.Pointer(&pmc)), uintptr(pmc.Cb))
+	if r == 0 {
+		return 0
+	}
+	return uint64(pmc.WorkingSetSize)
+}
+
+// diskFree returns the bytes available to this user on the volume holding path.
+func diskFree(path string) (uint64, error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var free, total, totalFree uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &free, &total, &totalFree); err != nil {
+		return 0, err
+	}
+	return free, nil
+}
+
+// setAutostart adds or removes the app under
+// HKCU\Software\Microsoft\Windows\CurrentVersion\Run, which starts it at login.
+func setAutostart(on bool) error {
+	k, err := registry.OpenKey(registry.CURRENT_USER, `Software\Microsoft\Windows\CurrentVersion\Run`, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+	if !on {
+		if err := k.DeleteValue(appName); err != nil && !errors.Is(err, registry.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	exe, err := appExecutable()
+	if err != nil {
+		return err
+	}
+	return k.SetStringValue(appName, `"`+exe+`"`)
+}
+
+// listAudioOutputs: the Oto backend always plays through the system default
+// output here, so there is nothing to choose from.
+func listAudioOutputs() []audioOutput { return nil }
+
+func selectAudioOutput(id string) {}
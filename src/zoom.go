@@ -0,0 +1,257 @@
+/* SPDX-License-Identifier: GPL-3.0-or-later
+ *
+ * QAnotherRTSP
+ * Copyright (C) 2025 e1z0 <e1z0@icloud.com>
+ *
+ * This file is part of QAnotherRTSP.
+ *
+ * QAnotherRTSP is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * QAnotherRTSP is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with QAnotherRTSP.  If not, see <https://www.gnu.org/licenses/>.
+ */
+package main
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"github.com/mappu/miqt/qt"
+)
+
+// ZoomPreset is a saved digital zoom/pan view of a camera (pseudo-PTZ on a
+// high-resolution fixed camera).
+type ZoomPreset struct {
+	Name string  `yaml:"name"`
+	Zoom float64 `yaml:"zoom"` // magnification, 1 = whole frame
+	X    float64 `yaml:"x"`    // center of the view as a fraction of the frame width
+	Y    float64 `yaml:"y"`    // ... and height
+}
+
+const (
+	maxZoom       = 8.0
+	zoomWheelStep = 1.25 // magnification per wheel notch
+	zoomAnimMs    = 250  // preset recall glide
+)
+
+// zoomState is the VideoWidget's digital zoom: which part of the frame is
+// shown, plus the drag/animation state to change it.
+type zoomState struct {
+	z, cx, cy float64 // magnification (<=1: off) and view center (0..1)
+
+	dest       *qt.QRect // where the last frame was painted (maps mouse to frame)
+	panning    bool
+	panX, panY int
+
+	anim      *qt.QTimer
+	from, to  ZoomPreset
+	animStart time.Time
+}
+
+// zoomed reports whether only part of the frame is shown.
+func (w *VideoWidget) zoomed() bool { return w.zoom.z > 1 }
+
+// zoomView is the current view as an (unnamed) preset.
+func (w *VideoWidget) zoomView() ZoomPreset {
+	if !w.zoomed() {
+		return ZoomPreset{Zoom: 1, X: 0.5, Y: 0.5}
+	}
+	return ZoomPreset{Zoom: w.zoom.z, X: w.zoom.cx, Y: w.zoom.cy}
+}
+
+// setZoom applies a view, clamped so it stays inside the frame.
+func (w *VideoWidget) setZoom(z, cx, cy float64) {
+	z = math.Max(1, math.Min(maxZoom, z))
+	half := 0.5 / z
+	w.zoom.z = z
+	w.zoom.cx = math.Max(half, math.Min(1-half, cx))
+	w.zoom.cy = math.Max(half, math.Min(1-half, cy))
+	w.Update()
+}
+
+// zoomSource is the part of a srcW x srcH frame to draw.
+func (w *VideoWidget) zoomSource(srcW, srcH int) *qt.QRectF {
+	if !w.zoomed() {
+		return qt.NewQRectF4(0, 0, float64(srcW), float64(srcH))
+	}
+	vw, vh := float64(srcW)/w.zoom.z, float64(srcH)/w.zoom.z
+	return qt.NewQRectF4(w.zoom.cx*float64(srcW)-vw/2, w.zoom.cy*float64(srcH)-vh/2, vw, vh)
+}
+
+// frameDelta maps a vector in widget pixels to the same vector in frame
+// fractions at the current zoom, undoing the display rotation/mirroring.
+func (w *VideoWidget) frameDelta(dx, dy float64) (float64, float64) {
+	d := w.zoom.dest
+	if d == nil || d.Width() <= 0 || d.Height() <= 0 {
+		return 0, 0
+	}
+	outW, outH := float64(d.Width()), float64(d.Height())
+	switch w.Rotate {
+	case 90:
+		dx, dy = dy, -dx
+		outW, outH = outH, outW
+	case 180:
+		dx, dy = -dx, -dy
+	case 270:
+		dx, dy = -dy, dx
+		outW, outH = outH, outW
+	}
+	if w.FlipH {
+		dx = -dx
+	}
+	if w.FlipV {
+		dy = -dy
+	}
+	z := math.Max(1, w.zoom.z)
+	return dx / outW / z, dy / outH / z
+}
+
+// zoomAt changes the magnification by factor, keeping the frame point under
+// the widget position (px, py) where it is.
+func (w *VideoWidget) zoomAt(factor float64, px, py int) {
+	w.stopZoomAnim()
+	v := w.zoomView()
+	nz := math.Max(1, math.Min(maxZoom, v.Zoom*factor))
+	if nz == v.Zoom {
+		return
+	}
+	fx, fy := 0.0, 0.0
+	if d := w.zoom.dest; d != nil {
+		fx, fy = w.frameDelta(float64(px)-(float64(d.X())+float64(d.Width())/2), float64(py)-(float64(d.Y())+float64(d.Height())/2))
+	}
+	// frame point under the cursor, then the center that keeps it there
+	ptX, ptY := v.X+fx, v.Y+fy
+	k := v.Zoom / nz
+	w.setZoom(nz, ptX-fx*k, ptY-fy*k)
+}
+
+// startPan begins dragging the zoomed view: middle button always, left
+// button when it isn't moving a frameless window.
+func (w *VideoWidget) startPan(ev *qt.QMouseEvent) bool {
+	if !w.zoomed() {
+		return false
+	}
+	if ev.Button() != qt.MiddleButton && (ev.Button() != qt.LeftButton || w.isFramelessActive()) {
+		return false
+	}
+	w.stopZoomAnim()
+	w.zoom.panning = true
+	w.zoom.panX, w.zoom.panY = ev.Pos().X(), ev.Pos().Y()
+	w.SetCursor(qt.NewQCursor2(qt.ClosedHandCursor))
+	return true
+}
+
+// panTo drags the picture along with the mouse.
+func (w *VideoWidget) panTo(ev *qt.QMouseEvent) {
+	x, y := ev.Pos().X(), ev.Pos().Y()
+	fx, fy := w.frameDelta(float64(x-w.zoom.panX), float64(y-w.zoom.panY))
+	w.zoom.panX, w.zoom.panY = x, y
+	w.setZoom(w.zoom.z, w.zoom.cx-fx, w.zoom.cy-fy)
+}
+
+func (w *VideoWidget) endPan() {
+	w.zoom.panning = false
+	w.UnsetCursor()
+}
+
+// animateZoom glides from the current view to p.
+func (w *VideoWidget) animateZoom(p ZoomPreset) {
+	if w.zoom.anim == nil {
+		w.zoom.anim = qt.NewQTimer2(w.QObject)
+		w.zoom.anim.SetInterval(16)
+		w.zoom.anim.OnTimeout(w.stepZoomAnim)
+	}
+	w.zoom.from, w.zoom.to = w.zoomView(), p
+	w.zoom.animStart = time.Now()
+	w.zoom.anim.Start2()
+}
+
+func (w *VideoWidget) stepZoomAnim() {
+	t := float64(time.Since(w.zoom.animStart).Milliseconds()) / zoomAnimMs
+	if t >= 1 {
+		w.stopZoomAnim()
+		w.setZoom(w.zoom.to.Zoom, w.zoom.to.X, w.zoom.to.Y)
+		return
+	}
+	e := 1 - math.Pow(1-t, 3) // ease out
+	a, b := w.zoom.from, w.zoom.to
+	// interpolate the magnification logarithmically so it feels even
+	z := math.Exp(math.Log(a.Zoom) + (math.Log(b.Zoom)-math.Log(a.Zoom))*e)
+	w.setZoom(z, a.X+(b.X-a.X)*e, a.Y+(b.Y-a.Y)*e)
+}
+
+func (w *VideoWidget) stopZoomAnim() {
+	if w.zoom.anim != nil {
+		w.zoom.anim.Stop()
+	}
+}
+
+// SaveZoomPreset asks for a name and stores the current view in the
+// camera's config, replacing a preset with the same name.
+func (w *CamWindow) SaveZoomPreset() {
+	if w == nil || w.view == nil {
+		return
+	}
+	name, ok := promptText("Save Zoom Preset", "Name this view:")
+	if !ok || name == "" {
+		return
+	}
+	p := w.view.zoomView()
+	p.Name = name
+	presets := append([]ZoomPreset(nil), w.cfg.ZoomPresets...)
+	replaced := false
+	for i := range presets {
+		if presets[i].Name == name {
+			presets[i] = p
+			replaced = true
+		}
+	}
+	if !replaced {
+		presets = append(presets, p)
+	}
+	w.setZoomPresets(presets)
+}
+
+// DeleteZoomPreset removes the named preset from the camera's config.
+func (w *CamWindow) DeleteZoomPreset(name string) {
+	var presets []ZoomPreset
+	for _, p := range w.cfg.ZoomPresets {
+		if p.Name != name {
+			presets = append(presets, p)
+		}
+	}
+	w.setZoomPresets(presets)
+}
+
+func (w *CamWindow) setZoomPresets(presets []ZoomPreset) {
+	w.cfg.ZoomPresets = presets
+	if err := UpdateCamera(w.idKey, func(c *CameraConfig) { c.ZoomPresets = presets }); err != nil {
+		log.Printf("save config failed: %v", err)
+	}
+}
+
+// RecallZoomPreset glides the view to a saved preset.
+func (w *CamWindow) RecallZoomPreset(p ZoomPreset) {
+	if w == nil || w.view == nil {
+		return
+	}
+	w.logf("zoom preset %q", p.Name)
+	w.view.animateZoom(p)
+}
+
+// ResetZoom glides back to the whole frame.
+func (w *CamWindow) ResetZoom() {
+	if w == nil || w.view == nil {
+		return
+	}
+	w.view.animateZoom(ZoomPreset{Zoom: 1, X: 0.5, Y: 0.5})
+}